@@ -0,0 +1,127 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditionalstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	// The CouchDB driver.
+	_ "github.com/go-kivik/couchdb"
+	"github.com/go-kivik/kivik"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+
+	"github.com/trustbloc/orb/pkg/didtxnref/storedidtxnref"
+)
+
+// CouchDBProvider wraps the aries CouchDB storage provider. The stores that it opens support conditional writes
+// using the CouchDB document revision (_rev).
+type CouchDBProvider struct {
+	storage.Provider
+	client   *kivik.Client
+	dbPrefix string
+}
+
+// NewCouchDBProvider returns a provider that wraps the given aries CouchDB storage provider, which must have been
+// created for the same CouchDB URL and database prefix.
+func NewCouchDBProvider(p storage.Provider, hostURL, dbPrefix string) (*CouchDBProvider, error) {
+	client, err := kivik.New("couch", hostURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CouchDB client: %w", err)
+	}
+
+	return &CouchDBProvider{Provider: p, client: client, dbPrefix: dbPrefix}, nil
+}
+
+// OpenStore opens the store with the given name (the database is created by the wrapped provider).
+func (p *CouchDBProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.dbPrefix != "" {
+		name = p.dbPrefix + "_" + name
+	}
+
+	db := p.client.DB(context.Background(), name)
+	if db.Err() != nil {
+		return nil, fmt.Errorf("failed to open CouchDB database [%s]: %w", name, db.Err())
+	}
+
+	return NewCouchDBStore(s, db), nil
+}
+
+// Close closes the wrapped provider and the CouchDB client.
+func (p *CouchDBProvider) Close() error {
+	if err := p.Provider.Close(); err != nil {
+		return err
+	}
+
+	return p.client.Close(context.Background())
+}
+
+// CouchDBStore is an aries CouchDB store that supports conditional writes. Values are stored in the same format
+// as the aries CouchDB store (i.e. as the JSON payload of the document) so only JSON values may be written
+// conditionally.
+type CouchDBStore struct {
+	storage.Store
+	db *kivik.DB
+}
+
+// NewCouchDBStore returns a conditional store for the given aries CouchDB store and its database.
+func NewCouchDBStore(s storage.Store, db *kivik.DB) *CouchDBStore {
+	return &CouchDBStore{Store: s, db: db}
+}
+
+type couchDBDocument struct {
+	Rev     string          `json:"_rev,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// GetWithRevision returns the value and the document revision for the given key.
+func (s *CouchDBStore) GetWithRevision(k string) ([]byte, string, error) {
+	doc := &couchDBDocument{}
+
+	err := s.db.Get(context.Background(), k).ScanDoc(doc)
+	if err != nil {
+		if kivik.StatusCode(err) == http.StatusNotFound {
+			return nil, "", fmt.Errorf("%w: %s", storage.ErrDataNotFound, k)
+		}
+
+		return nil, "", fmt.Errorf("failed to get document [%s]: %w", k, err)
+	}
+
+	if len(doc.Payload) == 0 {
+		return nil, "", fmt.Errorf("document [%s] doesn't have a JSON payload", k)
+	}
+
+	return doc.Payload, doc.Rev, nil
+}
+
+// PutIfRevision stores the given JSON value only if the revision of the document is rev (or if the document
+// doesn't exist and rev is empty). CouchDB rejects the write with a conflict otherwise.
+func (s *CouchDBStore) PutIfRevision(k string, v []byte, rev string) error {
+	if !json.Valid(v) {
+		return errors.New("only JSON values may be written conditionally")
+	}
+
+	_, err := s.db.Put(context.Background(), k, &couchDBDocument{Rev: rev, Payload: v})
+	if err != nil {
+		if kivik.StatusCode(err) == http.StatusConflict {
+			return fmt.Errorf("%w: document [%s] with revision [%s]: %s",
+				storedidtxnref.ErrRevisionConflict, k, rev, err)
+		}
+
+		return fmt.Errorf("failed to put document [%s]: %w", k, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditionalstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-kivik/kivik"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/didtxnref/storedidtxnref"
+)
+
+func TestCouchDBProvider(t *testing.T) {
+	couchDB := newMockCouchDB()
+
+	server := httptest.NewServer(couchDB)
+	defer server.Close()
+
+	p, err := NewCouchDBProvider(mem.NewProvider(), server.URL, "prefix")
+	require.NoError(t, err)
+
+	s, err := p.OpenStore("didtxnref")
+	require.NoError(t, err)
+
+	require.NoError(t, s.(*CouchDBStore).PutIfRevision("k", []byte(`["cid"]`), ""))
+	require.Contains(t, couchDB.docs, "prefix_didtxnref/k")
+
+	require.NoError(t, p.Close())
+}
+
+func TestCouchDBStore(t *testing.T) {
+	couchDB := newMockCouchDB()
+
+	server := httptest.NewServer(couchDB)
+	defer server.Close()
+
+	client, err := kivik.New("couch", server.URL)
+	require.NoError(t, err)
+
+	memStore, err := mem.NewProvider().OpenStore("db")
+	require.NoError(t, err)
+
+	s := NewCouchDBStore(memStore, client.DB(context.Background(), "db"))
+
+	t.Run("success", func(t *testing.T) {
+		v, rev, err := s.GetWithRevision("k1")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+		require.Nil(t, v)
+		require.Empty(t, rev)
+
+		require.NoError(t, s.PutIfRevision("k1", []byte(`["cid1"]`), ""))
+
+		v, rev, err = s.GetWithRevision("k1")
+		require.NoError(t, err)
+		require.JSONEq(t, `["cid1"]`, string(v))
+		require.Equal(t, "1-rev", rev)
+
+		require.NoError(t, s.PutIfRevision("k1", []byte(`["cid1","cid2"]`), rev))
+
+		v, rev, err = s.GetWithRevision("k1")
+		require.NoError(t, err)
+		require.JSONEq(t, `["cid1","cid2"]`, string(v))
+		require.Equal(t, "2-rev", rev)
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		require.NoError(t, s.PutIfRevision("k2", []byte(`["cid1"]`), ""))
+
+		err := s.PutIfRevision("k2", []byte(`["cid2"]`), "")
+		require.True(t, errors.Is(err, storedidtxnref.ErrRevisionConflict))
+
+		_, rev, err := s.GetWithRevision("k2")
+		require.NoError(t, err)
+
+		require.NoError(t, s.PutIfRevision("k2", []byte(`["cid1","cid2"]`), rev))
+
+		err = s.PutIfRevision("k2", []byte(`["cid1","cid3"]`), rev)
+		require.True(t, errors.Is(err, storedidtxnref.ErrRevisionConflict))
+	})
+
+	t.Run("error - not JSON", func(t *testing.T) {
+		err := s.PutIfRevision("k3", []byte("value"), "")
+		require.EqualError(t, err, "only JSON values may be written conditionally")
+	})
+
+	t.Run("error - no payload", func(t *testing.T) {
+		couchDB.docs["db/k4"] = &mockDocument{rev: 1, body: `{"_attachments":{}}`}
+
+		_, _, err := s.GetWithRevision("k4")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "document [k4] doesn't have a JSON payload")
+	})
+
+	t.Run("error - server error", func(t *testing.T) {
+		couchDB.err = true
+		defer func() { couchDB.err = false }()
+
+		_, _, err := s.GetWithRevision("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get document [k1]")
+
+		err = s.PutIfRevision("k1", []byte(`[]`), "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to put document [k1]")
+	})
+}
+
+type mockDocument struct {
+	rev  int
+	body string
+}
+
+// mockCouchDB implements the CouchDB document endpoints (GET and PUT /{db}/{docid}) including the revision check.
+type mockCouchDB struct {
+	mutex sync.Mutex
+	docs  map[string]*mockDocument
+	err   bool
+}
+
+func newMockCouchDB() *mockCouchDB {
+	return &mockCouchDB{docs: make(map[string]*mockDocument)}
+}
+
+func (m *mockCouchDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if m.err {
+		writeCouchDBResponse(w, http.StatusInternalServerError, `{"error":"internal","reason":"injected error"}`)
+
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/")
+	doc, exists := m.docs[id]
+
+	switch r.Method {
+	case http.MethodGet:
+		if !exists {
+			writeCouchDBResponse(w, http.StatusNotFound, `{"error":"not_found","reason":"missing"}`)
+
+			return
+		}
+
+		body := map[string]interface{}{}
+		_ = json.Unmarshal([]byte(doc.body), &body) //nolint:errcheck
+
+		body["_id"] = id
+		body["_rev"] = fmt.Sprintf("%d-rev", doc.rev)
+
+		bodyBytes, _ := json.Marshal(body) //nolint:errcheck
+
+		w.Header().Set("ETag", fmt.Sprintf("%q", body["_rev"]))
+
+		writeCouchDBResponse(w, http.StatusOK, string(bodyBytes))
+	case http.MethodPut:
+		reqBytes, _ := ioutil.ReadAll(r.Body) //nolint:errcheck
+
+		req := map[string]interface{}{}
+		_ = json.Unmarshal(reqBytes, &req) //nolint:errcheck
+
+		rev, _ := req["_rev"].(string) //nolint:errcheck
+
+		if (!exists && rev != "") || (exists && rev != fmt.Sprintf("%d-rev", doc.rev)) {
+			writeCouchDBResponse(w, http.StatusConflict, `{"error":"conflict","reason":"Document update conflict."}`)
+
+			return
+		}
+
+		delete(req, "_rev")
+
+		bodyBytes, _ := json.Marshal(req) //nolint:errcheck
+
+		if !exists {
+			doc = &mockDocument{}
+			m.docs[id] = doc
+		}
+
+		doc.rev++
+		doc.body = string(bodyBytes)
+
+		writeCouchDBResponse(w, http.StatusCreated, fmt.Sprintf(`{"ok":true,"id":%q,"rev":"%d-rev"}`, id, doc.rev))
+	default:
+		writeCouchDBResponse(w, http.StatusMethodNotAllowed, `{"error":"method_not_allowed"}`)
+	}
+}
+
+func writeCouchDBResponse(w http.ResponseWriter, status int, body string) {
+	w.WriteHeader(status)
+
+	_, _ = w.Write([]byte(body)) //nolint:errcheck
+}
@@ -0,0 +1,147 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditionalstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+
+	"github.com/trustbloc/orb/pkg/didtxnref/storedidtxnref"
+)
+
+// mysqlDuplicateEntry is the MySQL error number for a duplicate key.
+const mysqlDuplicateEntry = 1062
+
+// MySQLProvider wraps the aries MySQL storage provider. The stores that it opens support conditional writes
+// using compare-and-swap updates of the key-value table.
+type MySQLProvider struct {
+	storage.Provider
+	db       *sql.DB
+	dbPrefix string
+}
+
+// NewMySQLProvider returns a provider that wraps the given aries MySQL storage provider, which must have been
+// created for the same database URL and prefix.
+func NewMySQLProvider(p storage.Provider, dbURL, dbPrefix string) (*MySQLProvider, error) {
+	db, err := sql.Open("mysql", dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MySQL connection: %w", err)
+	}
+
+	return &MySQLProvider{Provider: p, db: db, dbPrefix: dbPrefix}, nil
+}
+
+// OpenStore opens the store with the given name (the table is created by the wrapped provider).
+func (p *MySQLProvider) OpenStore(name string) (storage.Store, error) {
+	s, err := p.Provider.OpenStore(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.dbPrefix != "" {
+		name = p.dbPrefix + "_" + name
+	}
+
+	// the aries MySQL provider creates a table with the same name as the database
+	return NewMySQLStore(s, p.db, fmt.Sprintf("`%s`.`%s`", name, name)), nil
+}
+
+// Close closes the wrapped provider and the MySQL connection.
+func (p *MySQLProvider) Close() error {
+	if err := p.Provider.Close(); err != nil {
+		return err
+	}
+
+	return p.db.Close()
+}
+
+// MySQLStore is an aries MySQL store that supports conditional writes. The revision of a value is its
+// SHA-256 hash, which is compared by the database when the value is updated.
+type MySQLStore struct {
+	storage.Store
+	db        *sql.DB
+	tableName string
+}
+
+// NewMySQLStore returns a conditional store for the given aries MySQL store and its (fully qualified) table.
+func NewMySQLStore(s storage.Store, db *sql.DB, tableName string) *MySQLStore {
+	return &MySQLStore{Store: s, db: db, tableName: tableName}
+}
+
+// GetWithRevision returns the value and the revision of the value for the given key.
+func (s *MySQLStore) GetWithRevision(k string) ([]byte, string, error) {
+	var v []byte
+
+	err := s.db.QueryRow("SELECT `value` FROM "+s.tableName+" WHERE `key` = ?", k).Scan(&v)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", fmt.Errorf("%w: %s", storage.ErrDataNotFound, k)
+		}
+
+		return nil, "", fmt.Errorf("failed to query key [%s]: %w", k, err)
+	}
+
+	return v, storedidtxnref.Revision(v), nil
+}
+
+// PutIfRevision stores the given value only if the revision of the stored value is rev. If rev is empty then
+// the value is inserted, which fails if the key already exists.
+func (s *MySQLStore) PutIfRevision(k string, v []byte, rev string) error {
+	if rev == "" {
+		return s.insert(k, v)
+	}
+
+	// MySQL doesn't count a row whose value doesn't change as affected, so the revision is checked explicitly
+	if storedidtxnref.Revision(v) == rev {
+		_, current, err := s.GetWithRevision(k)
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return err
+		}
+
+		if current != rev {
+			return fmt.Errorf("%w: key [%s] with revision [%s]", storedidtxnref.ErrRevisionConflict, k, rev)
+		}
+
+		return nil
+	}
+
+	result, err := s.db.Exec("UPDATE "+s.tableName+" SET `value` = ? WHERE `key` = ? AND SHA2(`value`, 256) = ?",
+		v, k, rev)
+	if err != nil {
+		return fmt.Errorf("failed to update key [%s]: %w", k, err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get the number of updated rows for key [%s]: %w", k, err)
+	}
+
+	if n == 0 {
+		return fmt.Errorf("%w: key [%s] with revision [%s]", storedidtxnref.ErrRevisionConflict, k, rev)
+	}
+
+	return nil
+}
+
+func (s *MySQLStore) insert(k string, v []byte) error {
+	_, err := s.db.Exec("INSERT INTO "+s.tableName+" (`key`, `value`) VALUES (?, ?)", k, v)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return fmt.Errorf("%w: key [%s] already exists", storedidtxnref.ErrRevisionConflict, k)
+		}
+
+		return fmt.Errorf("failed to insert key [%s]: %w", k, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package conditionalstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/didtxnref/storedidtxnref"
+)
+
+func TestMySQLProvider(t *testing.T) {
+	p, err := NewMySQLProvider(mem.NewProvider(), "root:secret@tcp(localhost:3306)/", "prefix")
+	require.NoError(t, err)
+
+	s, err := p.OpenStore("didtxnref")
+	require.NoError(t, err)
+	require.Equal(t, "`prefix_didtxnref`.`prefix_didtxnref`", s.(*MySQLStore).tableName)
+
+	require.NoError(t, p.Close())
+}
+
+func TestMySQLStore(t *testing.T) {
+	db := newMockMySQL()
+
+	memStore, err := mem.NewProvider().OpenStore("db")
+	require.NoError(t, err)
+
+	s := NewMySQLStore(memStore, sql.OpenDB(db), "`db`.`db`")
+
+	t.Run("success", func(t *testing.T) {
+		v, rev, err := s.GetWithRevision("k1")
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+		require.Nil(t, v)
+		require.Empty(t, rev)
+
+		require.NoError(t, s.PutIfRevision("k1", []byte("v1"), ""))
+
+		v, rev, err = s.GetWithRevision("k1")
+		require.NoError(t, err)
+		require.Equal(t, "v1", string(v))
+		require.Equal(t, storedidtxnref.Revision([]byte("v1")), rev)
+
+		require.NoError(t, s.PutIfRevision("k1", []byte("v2"), rev))
+
+		// unchanged value
+		_, rev, err = s.GetWithRevision("k1")
+		require.NoError(t, err)
+		require.NoError(t, s.PutIfRevision("k1", []byte("v2"), rev))
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		require.NoError(t, s.PutIfRevision("k2", []byte("v1"), ""))
+
+		err := s.PutIfRevision("k2", []byte("v2"), "")
+		require.True(t, errors.Is(err, storedidtxnref.ErrRevisionConflict))
+
+		_, rev, err := s.GetWithRevision("k2")
+		require.NoError(t, err)
+
+		require.NoError(t, s.PutIfRevision("k2", []byte("v2"), rev))
+
+		err = s.PutIfRevision("k2", []byte("v3"), rev)
+		require.True(t, errors.Is(err, storedidtxnref.ErrRevisionConflict))
+
+		// unchanged value with an outdated revision
+		err = s.PutIfRevision("k2", []byte("v1"), rev)
+		require.True(t, errors.Is(err, storedidtxnref.ErrRevisionConflict))
+	})
+
+	t.Run("error - database error", func(t *testing.T) {
+		db.err = errors.New("injected error")
+		defer func() { db.err = nil }()
+
+		_, _, err := s.GetWithRevision("k1")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to query key [k1]: injected error")
+
+		err = s.PutIfRevision("k1", []byte("v3"), "")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to insert key [k1]: injected error")
+
+		err = s.PutIfRevision("k1", []byte("v3"), "rev")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to update key [k1]: injected error")
+
+		err = s.PutIfRevision("k1", []byte("v3"), storedidtxnref.Revision([]byte("v3")))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "injected error")
+	})
+}
+
+// mockMySQL is a database/sql connector that implements the statements of the MySQL store
+// (including the duplicate key error and the affected rows of MySQL).
+type mockMySQL struct {
+	mutex  sync.Mutex
+	values map[string][]byte
+	err    error
+}
+
+func newMockMySQL() *mockMySQL {
+	return &mockMySQL{values: make(map[string][]byte)}
+}
+
+func (m *mockMySQL) Connect(context.Context) (driver.Conn, error) {
+	return &mockConn{db: m}, nil
+}
+
+func (m *mockMySQL) Driver() driver.Driver {
+	return nil
+}
+
+type mockConn struct {
+	db *mockMySQL
+}
+
+func (c *mockConn) Prepare(query string) (driver.Stmt, error) {
+	return &mockStmt{db: c.db, query: query}, nil
+}
+
+func (c *mockConn) Close() error {
+	return nil
+}
+
+func (c *mockConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type mockStmt struct {
+	db    *mockMySQL
+	query string
+}
+
+func (s *mockStmt) Close() error {
+	return nil
+}
+
+func (s *mockStmt) NumInput() int {
+	return -1
+}
+
+func (s *mockStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	if s.db.err != nil {
+		return nil, s.db.err
+	}
+
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		k := args[0].(string)
+
+		if _, exists := s.db.values[k]; exists {
+			return nil, &mysql.MySQLError{Number: mysqlDuplicateEntry, Message: "Duplicate entry"}
+		}
+
+		s.db.values[k] = args[1].([]byte)
+
+		return driver.RowsAffected(1), nil
+	case strings.HasPrefix(s.query, "UPDATE"):
+		v, k, rev := args[0].([]byte), args[1].(string), args[2].(string)
+
+		current, exists := s.db.values[k]
+		if !exists || storedidtxnref.Revision(current) != rev || string(current) == string(v) {
+			return driver.RowsAffected(0), nil
+		}
+
+		s.db.values[k] = v
+
+		return driver.RowsAffected(1), nil
+	default:
+		return nil, errors.New("unsupported statement")
+	}
+}
+
+func (s *mockStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mutex.Lock()
+	defer s.db.mutex.Unlock()
+
+	if s.db.err != nil {
+		return nil, s.db.err
+	}
+
+	v, exists := s.db.values[args[0].(string)]
+	if !exists {
+		return &mockRows{}, nil
+	}
+
+	return &mockRows{values: [][]byte{v}}, nil
+}
+
+type mockRows struct {
+	values [][]byte
+}
+
+func (r *mockRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *mockRows) Close() error {
+	return nil
+}
+
+func (r *mockRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+
+	return nil
+}
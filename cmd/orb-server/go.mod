@@ -5,6 +5,9 @@
 module github.com/trustbloc/orb/cmd/orb-server
 
 require (
+	github.com/go-kivik/couchdb v2.0.0+incompatible
+	github.com/go-kivik/kivik v2.0.0+incompatible
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/google/tink/go v1.5.0
 	github.com/hyperledger/aries-framework-go v0.1.6-0.20210127113808-f60b9683e266
 	github.com/hyperledger/aries-framework-go-ext/component/storage/couchdb v0.0.0-20201119153638-fc5d5e680587
@@ -7,8 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package startcmd
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 	cmdutils "github.com/trustbloc/edge-core/pkg/utils/cmd"
+
+	"github.com/trustbloc/orb/pkg/anchor/publisher"
 )
 
 const (
@@ -19,6 +27,13 @@ const (
 	hostURLFlagUsage     = "URL to run the orb-server instance on. Format: HostName:Port."
 	hostURLEnvKey        = "ORB_HOST_URL"
 
+	externalEndpointFlagName  = "external-endpoint"
+	externalEndpointEnvKey    = "ORB_EXTERNAL_ENDPOINT"
+	externalEndpointFlagUsage = "The external URL of this orb server (e.g. https://orb.domain.com). The anchor " +
+		"credentials that are issued by this server are served under this URL. Defaults to the host URL " +
+		"(with https if a TLS certificate is configured, otherwise http). " + commonEnvVarUsageText +
+		externalEndpointEnvKey
+
 	tlsCertificateFlagName      = "tls-certificate"
 	tlsCertificateFlagShorthand = "y"
 	tlsCertificateFlagUsage     = "TLS certificate for ORB server. " + commonEnvVarUsageText + tlsCertificateLEnvKey
@@ -42,7 +57,82 @@ const (
 	casURLFlagName      = "cas-url"
 	casURLFlagShorthand = "c"
 	casURLEnvKey        = "CAS_URL"
-	casURLFlagUsage     = "The URL of the Content Addressable Storage(CAS). " + commonEnvVarUsageText + casURLEnvKey
+	casURLFlagUsage     = "The URL of the Content Addressable Storage(CAS). Required if cas-type is ipfs. " +
+		commonEnvVarUsageText + casURLEnvKey
+
+	casTypeFlagName  = "cas-type"
+	casTypeEnvKey    = "CAS_TYPE"
+	casTypeFlagUsage = "The type of the Content Addressable Storage(CAS). Supported options: ipfs, local. " +
+		"Defaults to ipfs. If local then content is stored in the database configured by database-type. " +
+		commonEnvVarUsageText + casTypeEnvKey
+
+	casCacheEnabledFlagName  = "cas-cache-enabled"
+	casCacheEnabledEnvKey    = "CAS_CACHE_ENABLED"
+	casCacheEnabledFlagUsage = "If true then content that is read from IPFS or from peers is cached in the database " +
+		"configured by database-type. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + casCacheEnabledEnvKey
+
+	casPeerURLsFlagName  = "cas-peer-urls"
+	casPeerURLsEnvKey    = "CAS_PEER_URLS"
+	casPeerURLsFlagUsage = "The CAS endpoint of a peer orb server (e.g. https://orb2.domain.com/cas) which is used " +
+		"if content is not found in the CAS. This flag can be repeated, allowing for multiple peers. " +
+		commonEnvVarUsageText + casPeerURLsEnvKey + " (comma-separated)"
+
+	casReplicaURLsFlagName  = "cas-replica-urls"
+	casReplicaURLsEnvKey    = "CAS_REPLICA_URLS"
+	casReplicaURLsFlagUsage = "The URL of an additional IPFS node to which all content is replicated. " +
+		"This flag can be repeated, allowing for multiple replicas. Only supported if cas-type is ipfs. " +
+		commonEnvVarUsageText + casReplicaURLsEnvKey + " (comma-separated)"
+
+	casWriteQuorumFlagName  = "cas-write-quorum"
+	casWriteQuorumEnvKey    = "CAS_WRITE_QUORUM"
+	casWriteQuorumFlagUsage = "The number of IPFS nodes (the node at cas-url plus the replicas) that must confirm " +
+		"a write. Replicas that miss a write are repaired in the background. Defaults to a majority of the nodes. " +
+		commonEnvVarUsageText + casWriteQuorumEnvKey
+
+	casReadTimeoutFlagName  = "cas-read-timeout"
+	casReadTimeoutEnvKey    = "CAS_READ_TIMEOUT"
+	casReadTimeoutFlagUsage = "The timeout for reading content from IPFS, e.g. 20s. Zero means no timeout. " +
+		"Defaults to 20s. " + commonEnvVarUsageText + casReadTimeoutEnvKey
+
+	casWriteTimeoutFlagName  = "cas-write-timeout"
+	casWriteTimeoutEnvKey    = "CAS_WRITE_TIMEOUT"
+	casWriteTimeoutFlagUsage = "The timeout for writing content to IPFS, e.g. 1m. Zero means no timeout. " +
+		"Defaults to 1m. " + commonEnvVarUsageText + casWriteTimeoutEnvKey
+
+	casCacheTimeoutFlagName  = "cas-cache-timeout"
+	casCacheTimeoutEnvKey    = "CAS_CACHE_TIMEOUT"
+	casCacheTimeoutFlagUsage = "The timeout for reading from the CAS cache, e.g. 1s. Zero means no timeout. " +
+		"Defaults to 0. " + commonEnvVarUsageText + casCacheTimeoutEnvKey
+
+	casCacheFailFastFlagName  = "cas-cache-fail-fast"
+	casCacheFailFastEnvKey    = "CAS_CACHE_FAIL_FAST"
+	casCacheFailFastFlagUsage = "If true then an error from the CAS cache is returned instead of falling back " +
+		"to the next tier. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + casCacheFailFastEnvKey
+
+	casPrimaryTimeoutFlagName  = "cas-primary-timeout"
+	casPrimaryTimeoutEnvKey    = "CAS_PRIMARY_TIMEOUT"
+	casPrimaryTimeoutFlagUsage = "The timeout for reading from the primary CAS (the CAS of cas-type), e.g. 30s, " +
+		"in addition to cas-read-timeout. Zero means no timeout. Defaults to 0. " +
+		commonEnvVarUsageText + casPrimaryTimeoutEnvKey
+
+	casPrimaryFailFastFlagName  = "cas-primary-fail-fast"
+	casPrimaryFailFastEnvKey    = "CAS_PRIMARY_FAIL_FAST"
+	casPrimaryFailFastFlagUsage = "If true then an error from the primary CAS is returned instead of falling back " +
+		"to the CAS peers. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + casPrimaryFailFastEnvKey
+
+	casPeerTimeoutFlagName  = "cas-peer-timeout"
+	casPeerTimeoutEnvKey    = "CAS_PEER_TIMEOUT"
+	casPeerTimeoutFlagUsage = "The timeout for reading from a CAS peer. Zero means no timeout. Defaults to 10s. " +
+		commonEnvVarUsageText + casPeerTimeoutEnvKey
+
+	casPeerFailFastFlagName  = "cas-peer-fail-fast"
+	casPeerFailFastEnvKey    = "CAS_PEER_FAIL_FAST"
+	casPeerFailFastFlagUsage = "If true then an error from a CAS peer is returned instead of falling back " +
+		"to the next peer. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + casPeerFailFastEnvKey
 
 	databaseTypeFlagName      = "database-type"
 	databaseTypeEnvKey        = "DATABASE_TYPE"
@@ -101,21 +191,120 @@ const (
 	anchorCredentialSignatureSuiteFlagUsage     = "Anchor credential signature suite (required). " +
 		commonEnvVarUsageText + anchorCredentialSignatureSuiteEnvKey
 
+	anchorCredentialFormatFlagName  = "anchor-credential-format"
+	anchorCredentialFormatEnvKey    = "ANCHOR_CREDENTIAL_FORMAT"
+	anchorCredentialFormatFlagUsage = "Anchor credential format. Possible values [ldp] [jwt]. Defaults to ldp " +
+		"(JSON-LD credential with linked data proof). " + commonEnvVarUsageText + anchorCredentialFormatEnvKey
+
 	anchorCredentialDomainFlagName      = "anchor-credential-domain"
 	anchorCredentialDomainEnvKey        = "ANCHOR_CREDENTIAL_DOMAIN"
 	anchorCredentialDomainFlagShorthand = "d"
 	anchorCredentialDomainFlagUsage     = "Anchor credential domain (required). " +
 		commonEnvVarUsageText + anchorCredentialDomainEnvKey
 
+	kmsTypeFlagName  = "kms-type"
+	kmsTypeEnvKey    = "KMS_TYPE"
+	kmsTypeFlagUsage = "The type of KMS used for anchor credential signing keys. " +
+		"Supported options: local, web. Defaults to local. " + commonEnvVarUsageText + kmsTypeEnvKey
+
+	kmsEndpointFlagName  = "kms-endpoint"
+	kmsEndpointEnvKey    = "KMS_ENDPOINT"
+	kmsEndpointFlagUsage = "The URL of the remote KMS server used to create a keystore. " +
+		"Required if kms-type is web and kms-store-endpoint is not set. " + commonEnvVarUsageText + kmsEndpointEnvKey
+
+	kmsStoreEndpointFlagName  = "kms-store-endpoint"
+	kmsStoreEndpointEnvKey    = "KMS_STORE_ENDPOINT"
+	kmsStoreEndpointFlagUsage = "The URL of an existing keystore on the remote KMS server (optional). " +
+		"If not set, a keystore is created at kms-endpoint and its URL is saved in the KMS secrets database. " +
+		commonEnvVarUsageText + kmsStoreEndpointEnvKey
+
+	secretLockKeyPathFlagName  = "secret-lock-key-path" //nolint: gosec
+	secretLockKeyPathEnvKey    = "SECRET_LOCK_KEY_PATH" //nolint: gosec
+	secretLockKeyPathFlagUsage = "The path to a file containing the master lock key used to protect " +
+		"the local KMS master key (optional). " + commonEnvVarUsageText + secretLockKeyPathEnvKey
+
+	secretLockKeyFlagName  = "secret-lock-key" //nolint: gosec
+	secretLockKeyEnvKey    = "SECRET_LOCK_KEY" //nolint: gosec
+	secretLockKeyFlagUsage = "The master lock key used to protect the local KMS master key (optional). " +
+		"Setting this with the environment variable is recommended. " + commonEnvVarUsageText + secretLockKeyEnvKey
+
+	jsonldContextsDirFlagName  = "jsonld-contexts-dir"
+	jsonldContextsDirEnvKey    = "JSONLD_CONTEXTS_DIR"
+	jsonldContextsDirFlagUsage = "A directory containing additional JSON-LD context documents (optional). " +
+		"Each file must contain a JSON object with the context 'url' and 'document'. " +
+		commonEnvVarUsageText + jsonldContextsDirEnvKey
+
+	jsonldStrictModeFlagName  = "jsonld-strict-mode"
+	jsonldStrictModeEnvKey    = "JSONLD_STRICT_MODE"
+	jsonldStrictModeFlagUsage = "If true then JSON-LD contexts that are not available locally are never fetched " +
+		"from the network. Possible values [true] [false]. Defaults to true. " + commonEnvVarUsageText + jsonldStrictModeEnvKey
+
+	anchorPublisherTypeFlagName  = "anchor-publisher-type"
+	anchorPublisherTypeEnvKey    = "ANCHOR_PUBLISHER_TYPE"
+	anchorPublisherTypeFlagUsage = "The type of publisher that delivers written anchors to the observer. " +
+		"Supported options: mem, durable. Defaults to mem. The durable publisher persists anchors until they're " +
+		"received by the observer. " + commonEnvVarUsageText + anchorPublisherTypeEnvKey
+
+	anchorPublisherOverflowFlagName  = "anchor-publisher-overflow"
+	anchorPublisherOverflowEnvKey    = "ANCHOR_PUBLISHER_OVERFLOW"
+	anchorPublisherOverflowFlagUsage = "The policy that's applied by the mem publisher when its buffer is full. " +
+		"Supported options: block, spill (to the database), error (the writer retries the anchor later). " +
+		"Defaults to block. " +
+		commonEnvVarUsageText + anchorPublisherOverflowEnvKey
+
+	anchorPublisherBufferSizeFlagName  = "anchor-publisher-buffer-size"
+	anchorPublisherBufferSizeEnvKey    = "ANCHOR_PUBLISHER_BUFFER_SIZE"
+	anchorPublisherBufferSizeFlagUsage = "The size of the buffer of the anchor publisher. " +
+		"Defaults to 100 for the mem publisher and 0 for the durable publisher. " +
+		commonEnvVarUsageText + anchorPublisherBufferSizeEnvKey
+
+	archiveImportEnabledFlagName  = "archive-import-enabled"
+	archiveImportEnabledEnvKey    = "ARCHIVE_IMPORT_ENABLED"
+	archiveImportEnabledFlagUsage = "If true then the archive import endpoint (POST /archive), which writes the " +
+		"content of an uploaded CAR archive to CAS, is enabled. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + archiveImportEnabledEnvKey
+
+	archiveMaxSizeFlagName  = "archive-max-size"
+	archiveMaxSizeEnvKey    = "ARCHIVE_MAX_SIZE"
+	archiveMaxSizeFlagUsage = "The maximum size (in bytes) of an exported or imported archive. " +
+		"Defaults to 104857600 (100MB). " + commonEnvVarUsageText + archiveMaxSizeEnvKey
+
+	rebuildIndexHeadsFlagName  = "rebuild-index-heads"
+	rebuildIndexHeadsEnvKey    = "REBUILD_INDEX_HEADS"
+	rebuildIndexHeadsFlagUsage = "The CID of a head anchor from which the DID/transaction index and the operation " +
+		"store are rebuilt (in the background) when the server starts. This flag can be repeated, allowing for " +
+		"multiple heads. An interrupted rebuild is resumed when the server is started again with the same heads. " +
+		commonEnvVarUsageText + rebuildIndexHeadsEnvKey + " (comma-separated)"
+
+	rebuildIndexRestartFlagName  = "rebuild-index-restart"
+	rebuildIndexRestartEnvKey    = "REBUILD_INDEX_RESTART"
+	rebuildIndexRestartFlagUsage = "If true then the index is rebuilt from scratch instead of resuming a previous " +
+		"(interrupted) rebuild. Possible values [true] [false]. Defaults to false. " +
+		commonEnvVarUsageText + rebuildIndexRestartEnvKey
+
+	anchorPublisherTypeMemOption     = "mem"
+	anchorPublisherTypeDurableOption = "durable"
+
+	kmsTypeLocalOption = "local"
+	kmsTypeWebOption   = "web"
+
+	casTypeIPFSOption  = "ipfs"
+	casTypeLocalOption = "local"
+
+	defaultCASReadTimeout  = 20 * time.Second
+	defaultCASWriteTimeout = time.Minute
+	defaultCASPeerTimeout  = 10 * time.Second
+
 	// TODO: Add verification method
 
 )
 
 type orbParameters struct {
 	hostURL                string
+	externalEndpoint       string
 	didNamespace           string
 	didAliases             []string
-	casURL                 string
+	casParams              *casParameters
 	dbParameters           *dbParameters
 	token                  string
 	logLevel               string
@@ -124,6 +313,61 @@ type orbParameters struct {
 	tlsCertificate         string
 	tlsKey                 string
 	anchorCredentialParams *anchorCredentialParams
+	kmsParams              *kmsParameters
+	jsonldParams           *jsonldParameters
+	publisherParams        *publisherParameters
+	archiveParams          *archiveParameters
+	rebuildParams          *rebuildParameters
+}
+
+type publisherParameters struct {
+	publisherType  string
+	overflowPolicy publisher.OverflowPolicy
+	bufferSize     int
+	bufferSizeSet  bool
+}
+
+type archiveParameters struct {
+	importEnabled bool
+	maxSize       int64
+}
+
+type rebuildParameters struct {
+	heads   []string
+	restart bool
+}
+
+type casParameters struct {
+	casType      string
+	casURL       string
+	cacheEnabled bool
+	peerURLs     []string
+	replicaURLs  []string
+	writeQuorum  int
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	cacheTier    tierParameters
+	primaryTier  tierParameters
+	peerTier     tierParameters
+}
+
+// tierParameters are the parameters of a tier of the layered CAS client.
+type tierParameters struct {
+	timeout  time.Duration
+	failFast bool
+}
+
+type jsonldParameters struct {
+	contextsDir string
+	strictMode  bool
+}
+
+type kmsParameters struct {
+	kmsType           string
+	kmsEndpoint       string
+	kmsStoreEndpoint  string
+	secretLockKeyPath string
+	secretLockKey     string
 }
 
 type anchorCredentialParams struct {
@@ -131,6 +375,7 @@ type anchorCredentialParams struct {
 	signatureSuite     string
 	domain             string
 	issuer             string
+	format             string
 }
 
 type dbParameters struct {
@@ -159,7 +404,12 @@ func getOrbParameters(cmd *cobra.Command) (*orbParameters, error) {
 		return nil, err
 	}
 
-	casURL, err := cmdutils.GetUserSetVarFromString(cmd, casURLFlagName, casURLEnvKey, false)
+	externalEndpoint, err := getExternalEndpoint(cmd, hostURL, tlsCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	casParams, err := getCASParameters(cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -194,20 +444,216 @@ func getOrbParameters(cmd *cobra.Command) (*orbParameters, error) {
 		return nil, err
 	}
 
+	kmsParams, err := getKMSParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonldParams, err := getJSONLDParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	publisherParams, err := getPublisherParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveParams, err := getArchiveParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	rebuildParams, err := getRebuildParameters(cmd)
+	if err != nil {
+		return nil, err
+	}
+
 	return &orbParameters{
 		hostURL:                hostURL,
+		externalEndpoint:       externalEndpoint,
 		tlsKey:                 tlsKey,
 		tlsCertificate:         tlsCertificate,
 		didNamespace:           didNamespace,
 		didAliases:             didAliases,
-		casURL:                 casURL,
+		casParams:              casParams,
 		anchorCredentialParams: anchorCredentialParams,
+		kmsParams:              kmsParams,
+		jsonldParams:           jsonldParams,
+		publisherParams:        publisherParams,
+		archiveParams:          archiveParams,
+		rebuildParams:          rebuildParams,
 		dbParameters:           dbParams,
 		token:                  token,
 		logLevel:               loggingLevel,
 	}, nil
 }
 
+// getExternalEndpoint returns the external URL of the server (without a trailing slash). The URL is derived from
+// the host URL if it's not set.
+func getExternalEndpoint(cmd *cobra.Command, hostURL, tlsCertificate string) (string, error) {
+	externalEndpoint := cmdutils.GetUserSetOptionalVarFromString(cmd, externalEndpointFlagName, externalEndpointEnvKey)
+	if externalEndpoint == "" {
+		scheme := "http"
+
+		if tlsCertificate != "" {
+			scheme = "https"
+		}
+
+		return fmt.Sprintf("%s://%s", scheme, hostURL), nil
+	}
+
+	u, err := url.Parse(externalEndpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid value for %s [%s]", externalEndpointFlagName, externalEndpoint)
+	}
+
+	return strings.TrimSuffix(externalEndpoint, "/"), nil
+}
+
+// nolint: gocyclo
+func getCASParameters(cmd *cobra.Command) (*casParameters, error) {
+	params := &casParameters{
+		readTimeout:  defaultCASReadTimeout,
+		writeTimeout: defaultCASWriteTimeout,
+		peerTier:     tierParameters{timeout: defaultCASPeerTimeout},
+	}
+
+	casType := cmdutils.GetUserSetOptionalVarFromString(cmd, casTypeFlagName, casTypeEnvKey)
+
+	switch {
+	case casType == "" || strings.EqualFold(casType, casTypeIPFSOption):
+		casURL, err := cmdutils.GetUserSetVarFromString(cmd, casURLFlagName, casURLEnvKey, false)
+		if err != nil {
+			return nil, err
+		}
+
+		params.casType = casTypeIPFSOption
+		params.casURL = casURL
+	case strings.EqualFold(casType, casTypeLocalOption):
+		params.casType = casTypeLocalOption
+	default:
+		return nil, fmt.Errorf("cas type not set to a valid type: %s", casType)
+	}
+
+	cacheEnabledStr := cmdutils.GetUserSetOptionalVarFromString(cmd, casCacheEnabledFlagName, casCacheEnabledEnvKey)
+	if cacheEnabledStr != "" {
+		cacheEnabled, err := strconv.ParseBool(cacheEnabledStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", casCacheEnabledFlagName, cacheEnabledStr, err)
+		}
+
+		params.cacheEnabled = cacheEnabled
+	}
+
+	peerURLs, err := cmdutils.GetUserSetVarFromArrayString(cmd, casPeerURLsFlagName, casPeerURLsEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	params.peerURLs = peerURLs
+
+	err = setReplicaParameters(cmd, params)
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeoutStr := cmdutils.GetUserSetOptionalVarFromString(cmd, casReadTimeoutFlagName, casReadTimeoutEnvKey)
+	if readTimeoutStr != "" {
+		params.readTimeout, err = time.ParseDuration(readTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", casReadTimeoutFlagName, readTimeoutStr, err)
+		}
+	}
+
+	writeTimeoutStr := cmdutils.GetUserSetOptionalVarFromString(cmd, casWriteTimeoutFlagName, casWriteTimeoutEnvKey)
+	if writeTimeoutStr != "" {
+		params.writeTimeout, err = time.ParseDuration(writeTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", casWriteTimeoutFlagName, writeTimeoutStr, err)
+		}
+	}
+
+	err = setTierParameters(cmd, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+func setTierParameters(cmd *cobra.Command, params *casParameters) error {
+	tiers := []struct {
+		params                        *tierParameters
+		timeoutFlagName, timeoutEnv   string
+		failFastFlagName, failFastEnv string
+	}{
+		{&params.cacheTier, casCacheTimeoutFlagName, casCacheTimeoutEnvKey,
+			casCacheFailFastFlagName, casCacheFailFastEnvKey},
+		{&params.primaryTier, casPrimaryTimeoutFlagName, casPrimaryTimeoutEnvKey,
+			casPrimaryFailFastFlagName, casPrimaryFailFastEnvKey},
+		{&params.peerTier, casPeerTimeoutFlagName, casPeerTimeoutEnvKey,
+			casPeerFailFastFlagName, casPeerFailFastEnvKey},
+	}
+
+	for _, tier := range tiers {
+		timeoutStr := cmdutils.GetUserSetOptionalVarFromString(cmd, tier.timeoutFlagName, tier.timeoutEnv)
+		if timeoutStr != "" {
+			timeout, err := time.ParseDuration(timeoutStr)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s [%s]: %w", tier.timeoutFlagName, timeoutStr, err)
+			}
+
+			tier.params.timeout = timeout
+		}
+
+		failFastStr := cmdutils.GetUserSetOptionalVarFromString(cmd, tier.failFastFlagName, tier.failFastEnv)
+		if failFastStr != "" {
+			failFast, err := strconv.ParseBool(failFastStr)
+			if err != nil {
+				return fmt.Errorf("invalid value for %s [%s]: %w", tier.failFastFlagName, failFastStr, err)
+			}
+
+			tier.params.failFast = failFast
+		}
+	}
+
+	return nil
+}
+
+func setReplicaParameters(cmd *cobra.Command, params *casParameters) error {
+	replicaURLs, err := cmdutils.GetUserSetVarFromArrayString(cmd, casReplicaURLsFlagName, casReplicaURLsEnvKey, true)
+	if err != nil {
+		return err
+	}
+
+	if len(replicaURLs) > 0 && params.casType != casTypeIPFSOption {
+		return fmt.Errorf("%s is only supported if cas-type is %s", casReplicaURLsFlagName, casTypeIPFSOption)
+	}
+
+	params.replicaURLs = replicaURLs
+
+	// the node at cas-url and the replicas
+	nodes := len(replicaURLs) + 1
+
+	params.writeQuorum = nodes/2 + 1
+
+	writeQuorumStr := cmdutils.GetUserSetOptionalVarFromString(cmd, casWriteQuorumFlagName, casWriteQuorumEnvKey)
+	if writeQuorumStr != "" {
+		params.writeQuorum, err = strconv.Atoi(writeQuorumStr)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s [%s]: %w", casWriteQuorumFlagName, writeQuorumStr, err)
+		}
+
+		if params.writeQuorum < 1 || params.writeQuorum > nodes {
+			return fmt.Errorf("invalid value for %s [%s]: must be between 1 and %d",
+				casWriteQuorumFlagName, writeQuorumStr, nodes)
+		}
+	}
+
+	return nil
+}
+
 func getAnchorCredentialParameters(cmd *cobra.Command) (*anchorCredentialParams, error) {
 	domain, err := cmdutils.GetUserSetVarFromString(cmd, anchorCredentialDomainFlagName, anchorCredentialDomainEnvKey, false)
 	if err != nil {
@@ -224,14 +670,168 @@ func getAnchorCredentialParameters(cmd *cobra.Command) (*anchorCredentialParams,
 		return nil, err
 	}
 
+	format := cmdutils.GetUserSetOptionalVarFromString(cmd, anchorCredentialFormatFlagName, anchorCredentialFormatEnvKey)
+
 	// TODO: Add verification method here
 
 	return &anchorCredentialParams{
 		issuer:         issuer,
 		domain:         domain,
 		signatureSuite: signatureSuite,
+		format:         format,
+	}, nil
+
+}
+
+func getKMSParameters(cmd *cobra.Command) (*kmsParameters, error) {
+	kmsType := cmdutils.GetUserSetOptionalVarFromString(cmd, kmsTypeFlagName, kmsTypeEnvKey)
+	kmsEndpoint := cmdutils.GetUserSetOptionalVarFromString(cmd, kmsEndpointFlagName, kmsEndpointEnvKey)
+	kmsStoreEndpoint := cmdutils.GetUserSetOptionalVarFromString(cmd, kmsStoreEndpointFlagName, kmsStoreEndpointEnvKey)
+
+	switch {
+	case kmsType == "" || strings.EqualFold(kmsType, kmsTypeLocalOption):
+		kmsType = kmsTypeLocalOption
+	case strings.EqualFold(kmsType, kmsTypeWebOption):
+		kmsType = kmsTypeWebOption
+
+		if kmsEndpoint == "" && kmsStoreEndpoint == "" {
+			return nil, fmt.Errorf("either %s or %s is required for kms type %s",
+				kmsEndpointFlagName, kmsStoreEndpointFlagName, kmsTypeWebOption)
+		}
+	default:
+		return nil, fmt.Errorf("kms type not set to a valid type: %s", kmsType)
+	}
+
+	secretLockKeyPath := cmdutils.GetUserSetOptionalVarFromString(cmd, secretLockKeyPathFlagName,
+		secretLockKeyPathEnvKey)
+	secretLockKey := cmdutils.GetUserSetOptionalVarFromString(cmd, secretLockKeyFlagName, secretLockKeyEnvKey)
+
+	if secretLockKeyPath != "" && secretLockKey != "" {
+		return nil, fmt.Errorf("only one of %s or %s may be set", secretLockKeyPathFlagName, secretLockKeyFlagName)
+	}
+
+	return &kmsParameters{
+		kmsType:           kmsType,
+		kmsEndpoint:       kmsEndpoint,
+		kmsStoreEndpoint:  kmsStoreEndpoint,
+		secretLockKeyPath: secretLockKeyPath,
+		secretLockKey:     secretLockKey,
+	}, nil
+}
+
+func getJSONLDParameters(cmd *cobra.Command) (*jsonldParameters, error) {
+	contextsDir := cmdutils.GetUserSetOptionalVarFromString(cmd, jsonldContextsDirFlagName, jsonldContextsDirEnvKey)
+
+	strictMode := true
+
+	strictModeStr := cmdutils.GetUserSetOptionalVarFromString(cmd, jsonldStrictModeFlagName, jsonldStrictModeEnvKey)
+	if strictModeStr != "" {
+		var err error
+
+		strictMode, err = strconv.ParseBool(strictModeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", jsonldStrictModeFlagName, strictModeStr, err)
+		}
+	}
+
+	return &jsonldParameters{
+		contextsDir: contextsDir,
+		strictMode:  strictMode,
 	}, nil
+}
+
+func getPublisherParameters(cmd *cobra.Command) (*publisherParameters, error) {
+	params := &publisherParameters{
+		publisherType:  anchorPublisherTypeMemOption,
+		overflowPolicy: publisher.OverflowBlock,
+	}
+
+	publisherType := cmdutils.GetUserSetOptionalVarFromString(cmd, anchorPublisherTypeFlagName,
+		anchorPublisherTypeEnvKey)
+
+	switch {
+	case publisherType == "" || strings.EqualFold(publisherType, anchorPublisherTypeMemOption):
+	case strings.EqualFold(publisherType, anchorPublisherTypeDurableOption):
+		params.publisherType = anchorPublisherTypeDurableOption
+	default:
+		return nil, fmt.Errorf("invalid value for %s [%s]", anchorPublisherTypeFlagName, publisherType)
+	}
+
+	overflow := cmdutils.GetUserSetOptionalVarFromString(cmd, anchorPublisherOverflowFlagName,
+		anchorPublisherOverflowEnvKey)
+
+	switch policy := publisher.OverflowPolicy(strings.ToLower(overflow)); policy {
+	case "":
+	case publisher.OverflowBlock, publisher.OverflowSpill, publisher.OverflowError:
+		params.overflowPolicy = policy
+	default:
+		return nil, fmt.Errorf("invalid value for %s [%s]", anchorPublisherOverflowFlagName, overflow)
+	}
+
+	bufferSizeStr := cmdutils.GetUserSetOptionalVarFromString(cmd, anchorPublisherBufferSizeFlagName,
+		anchorPublisherBufferSizeEnvKey)
+	if bufferSizeStr != "" {
+		bufferSize, err := strconv.Atoi(bufferSizeStr)
+		if err != nil || bufferSize < 0 {
+			return nil, fmt.Errorf("invalid value for %s [%s]", anchorPublisherBufferSizeFlagName, bufferSizeStr)
+		}
+
+		params.bufferSize = bufferSize
+		params.bufferSizeSet = true
+	}
+
+	return params, nil
+}
+
+// getArchiveParameters returns the archive parameters. The maximum size is zero if it's not set, in which case the
+// default of the archive handlers is used.
+func getArchiveParameters(cmd *cobra.Command) (*archiveParameters, error) {
+	params := &archiveParameters{}
+
+	importEnabledStr := cmdutils.GetUserSetOptionalVarFromString(cmd, archiveImportEnabledFlagName,
+		archiveImportEnabledEnvKey)
+	if importEnabledStr != "" {
+		importEnabled, err := strconv.ParseBool(importEnabledStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", archiveImportEnabledFlagName, importEnabledStr, err)
+		}
+
+		params.importEnabled = importEnabled
+	}
+
+	maxSizeStr := cmdutils.GetUserSetOptionalVarFromString(cmd, archiveMaxSizeFlagName, archiveMaxSizeEnvKey)
+	if maxSizeStr != "" {
+		maxSize, err := strconv.ParseInt(maxSizeStr, 10, 64)
+		if err != nil || maxSize <= 0 {
+			return nil, fmt.Errorf("invalid value for %s [%s]", archiveMaxSizeFlagName, maxSizeStr)
+		}
+
+		params.maxSize = maxSize
+	}
+
+	return params, nil
+}
+
+// getRebuildParameters returns the parameters of the index rebuild. The index isn't rebuilt if no heads are set.
+func getRebuildParameters(cmd *cobra.Command) (*rebuildParameters, error) {
+	heads, err := cmdutils.GetUserSetVarFromArrayString(cmd, rebuildIndexHeadsFlagName, rebuildIndexHeadsEnvKey, true)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &rebuildParameters{heads: heads}
+
+	restartStr := cmdutils.GetUserSetOptionalVarFromString(cmd, rebuildIndexRestartFlagName, rebuildIndexRestartEnvKey)
+	if restartStr != "" {
+		restart, err := strconv.ParseBool(restartStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s [%s]: %w", rebuildIndexRestartFlagName, restartStr, err)
+		}
+
+		params.restart = restart
+	}
 
+	return params, nil
 }
 
 func getDBParameters(cmd *cobra.Command) (*dbParameters, error) {
@@ -283,14 +883,29 @@ func getDBParameters(cmd *cobra.Command) (*dbParameters, error) {
 
 func createFlags(startCmd *cobra.Command) {
 	startCmd.Flags().StringP(hostURLFlagName, hostURLFlagShorthand, "", hostURLFlagUsage)
+	startCmd.Flags().StringP(externalEndpointFlagName, "", "", externalEndpointFlagUsage)
 	startCmd.Flags().StringP(tlsCertificateFlagName, tlsCertificateFlagShorthand, "", tlsCertificateFlagUsage)
 	startCmd.Flags().StringP(tlsKeyFlagName, tlsKeyFlagShorthand, "", tlsKeyFlagUsage)
 	startCmd.Flags().StringP(casURLFlagName, casURLFlagShorthand, "", casURLFlagUsage)
+	startCmd.Flags().StringP(casTypeFlagName, "", "", casTypeFlagUsage)
+	startCmd.Flags().StringP(casCacheEnabledFlagName, "", "", casCacheEnabledFlagUsage)
+	startCmd.Flags().StringArrayP(casPeerURLsFlagName, "", []string{}, casPeerURLsFlagUsage)
+	startCmd.Flags().StringArrayP(casReplicaURLsFlagName, "", []string{}, casReplicaURLsFlagUsage)
+	startCmd.Flags().StringP(casWriteQuorumFlagName, "", "", casWriteQuorumFlagUsage)
+	startCmd.Flags().StringP(casReadTimeoutFlagName, "", "", casReadTimeoutFlagUsage)
+	startCmd.Flags().StringP(casWriteTimeoutFlagName, "", "", casWriteTimeoutFlagUsage)
+	startCmd.Flags().StringP(casCacheTimeoutFlagName, "", "", casCacheTimeoutFlagUsage)
+	startCmd.Flags().StringP(casCacheFailFastFlagName, "", "", casCacheFailFastFlagUsage)
+	startCmd.Flags().StringP(casPrimaryTimeoutFlagName, "", "", casPrimaryTimeoutFlagUsage)
+	startCmd.Flags().StringP(casPrimaryFailFastFlagName, "", "", casPrimaryFailFastFlagUsage)
+	startCmd.Flags().StringP(casPeerTimeoutFlagName, "", "", casPeerTimeoutFlagUsage)
+	startCmd.Flags().StringP(casPeerFailFastFlagName, "", "", casPeerFailFastFlagUsage)
 	startCmd.Flags().StringP(didNamespaceFlagName, didNamespaceFlagShorthand, "", didNamespaceFlagUsage)
 	startCmd.Flags().StringP(didAliasesFlagName, didAliasesFlagShorthand, "", didAliasesFlagUsage)
 	startCmd.Flags().StringP(anchorCredentialDomainFlagName, anchorCredentialDomainFlagShorthand, "", anchorCredentialDomainFlagUsage)
 	startCmd.Flags().StringP(anchorCredentialIssuerFlagName, anchorCredentialIssuerFlagShorthand, "", anchorCredentialIssuerFlagUsage)
 	startCmd.Flags().StringP(anchorCredentialSignatureSuiteFlagName, anchorCredentialSignatureSuiteFlagShorthand, "", anchorCredentialSignatureSuiteFlagUsage)
+	startCmd.Flags().StringP(anchorCredentialFormatFlagName, "", "", anchorCredentialFormatFlagUsage)
 	startCmd.Flags().StringP(databaseTypeFlagName, databaseTypeFlagShorthand, "", databaseTypeFlagUsage)
 	startCmd.Flags().StringP(databaseURLFlagName, databaseURLFlagShorthand, "", databaseURLFlagUsage)
 	startCmd.Flags().StringP(databasePrefixFlagName, "", "", databasePrefixFlagUsage)
@@ -299,6 +914,20 @@ func createFlags(startCmd *cobra.Command) {
 	startCmd.Flags().StringP(kmsSecretsDatabaseURLFlagName, kmsSecretsDatabaseURLFlagShorthand, "",
 		kmsSecretsDatabaseURLFlagUsage)
 	startCmd.Flags().StringP(kmsSecretsDatabasePrefixFlagName, "", "", kmsSecretsDatabasePrefixFlagUsage)
+	startCmd.Flags().StringP(kmsTypeFlagName, "", "", kmsTypeFlagUsage)
+	startCmd.Flags().StringP(kmsEndpointFlagName, "", "", kmsEndpointFlagUsage)
+	startCmd.Flags().StringP(kmsStoreEndpointFlagName, "", "", kmsStoreEndpointFlagUsage)
+	startCmd.Flags().StringP(secretLockKeyPathFlagName, "", "", secretLockKeyPathFlagUsage)
+	startCmd.Flags().StringP(secretLockKeyFlagName, "", "", secretLockKeyFlagUsage)
+	startCmd.Flags().StringP(jsonldContextsDirFlagName, "", "", jsonldContextsDirFlagUsage)
+	startCmd.Flags().StringP(jsonldStrictModeFlagName, "", "", jsonldStrictModeFlagUsage)
+	startCmd.Flags().StringP(anchorPublisherTypeFlagName, "", "", anchorPublisherTypeFlagUsage)
+	startCmd.Flags().StringP(anchorPublisherOverflowFlagName, "", "", anchorPublisherOverflowFlagUsage)
+	startCmd.Flags().StringP(anchorPublisherBufferSizeFlagName, "", "", anchorPublisherBufferSizeFlagUsage)
+	startCmd.Flags().StringP(archiveImportEnabledFlagName, "", "", archiveImportEnabledFlagUsage)
+	startCmd.Flags().StringP(archiveMaxSizeFlagName, "", "", archiveMaxSizeFlagUsage)
+	startCmd.Flags().StringArrayP(rebuildIndexHeadsFlagName, "", []string{}, rebuildIndexHeadsFlagUsage)
+	startCmd.Flags().StringP(rebuildIndexRestartFlagName, "", "", rebuildIndexRestartFlagUsage)
 
 	startCmd.Flags().StringP(tokenFlagName, "", "", tokenFlagUsage)
 	startCmd.Flags().StringP(LogLevelFlagName, LogLevelFlagShorthand, "", LogLevelPrefixFlagUsage)
@@ -6,12 +6,17 @@ SPDX-License-Identifier: Apache-2.0
 package startcmd
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/orb/pkg/anchor/publisher"
 	"github.com/trustbloc/orb/pkg/httpserver"
 )
 
@@ -204,6 +209,542 @@ func TestStartCmdCreateKMSFailure(t *testing.T) {
 	require.Contains(t, err.Error(), "failed to ping couchDB")
 }
 
+func TestStartCmdWithInvalidKMSArgs(t *testing.T) {
+	t.Run("test invalid kms type", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+			"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+			"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+			"--" + anchorCredentialDomainFlagName, "domain.com",
+			"--" + anchorCredentialIssuerFlagName, "issuer.com",
+			"--" + kmsTypeFlagName, "invalid"}
+		startCmd.SetArgs(args)
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Equal(t, "kms type not set to a valid type: invalid", err.Error())
+	})
+
+	t.Run("test missing kms endpoint for web kms", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+			"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+			"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+			"--" + anchorCredentialDomainFlagName, "domain.com",
+			"--" + anchorCredentialIssuerFlagName, "issuer.com",
+			"--" + kmsTypeFlagName, kmsTypeWebOption}
+		startCmd.SetArgs(args)
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Equal(t, "either kms-endpoint or kms-store-endpoint is required for kms type web", err.Error())
+	})
+}
+
+func TestStartCmdWithSecretLockKey(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+			"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+			"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+			"--" + anchorCredentialDomainFlagName, "domain.com",
+			"--" + anchorCredentialIssuerFlagName, "issuer.com",
+			"--" + secretLockKeyFlagName, "secret"}
+		startCmd.SetArgs(args)
+
+		err := startCmd.Execute()
+		require.NoError(t, err)
+	})
+
+	t.Run("error - both key and key path set", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+			"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+			"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+			"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+			"--" + anchorCredentialDomainFlagName, "domain.com",
+			"--" + anchorCredentialIssuerFlagName, "issuer.com",
+			"--" + secretLockKeyFlagName, "secret",
+			"--" + secretLockKeyPathFlagName, "/path"}
+		startCmd.SetArgs(args)
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Equal(t, "only one of secret-lock-key-path or secret-lock-key may be set", err.Error())
+	})
+}
+
+func TestStartCmdWithJSONLDArgs(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("success", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "contexts")
+		require.NoError(t, err)
+
+		defer func() {
+			require.NoError(t, os.RemoveAll(dir))
+		}()
+
+		err = ioutil.WriteFile(filepath.Join(dir, "context.json"),
+			[]byte(`{"url":"https://example.com/context/v1","document":{"@context":{"name":"https://example.com#name"}}}`),
+			0600)
+		require.NoError(t, err)
+
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs,
+			"--"+jsonldContextsDirFlagName, dir,
+			"--"+jsonldStrictModeFlagName, "false"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("invalid strict mode", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+jsonldStrictModeFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for jsonld-strict-mode [invalid]")
+	})
+
+	t.Run("invalid contexts dir", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+jsonldContextsDirFlagName, "/invalid/contexts/dir"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to load JSON-LD contexts")
+	})
+}
+
+func TestStartCmdWithArchiveArgs(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("defaults", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		require.NoError(t, startCmd.ParseFlags(baseArgs))
+
+		params, err := getArchiveParameters(startCmd)
+		require.NoError(t, err)
+		require.False(t, params.importEnabled)
+		require.Equal(t, int64(0), params.maxSize)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := append(baseArgs, "--"+archiveImportEnabledFlagName, "true", "--"+archiveMaxSizeFlagName, "1024")
+
+		require.NoError(t, startCmd.ParseFlags(args))
+
+		params, err := getArchiveParameters(startCmd)
+		require.NoError(t, err)
+		require.True(t, params.importEnabled)
+		require.Equal(t, int64(1024), params.maxSize)
+
+		startCmd = GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(args)
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("invalid import enabled", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+archiveImportEnabledFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for archive-import-enabled [invalid]")
+	})
+
+	t.Run("invalid max size", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+archiveMaxSizeFlagName, "0"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for archive-max-size [0]")
+	})
+}
+
+func TestStartCmdWithAnchorPublisherArgs(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("success - mem", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs,
+			"--"+anchorPublisherTypeFlagName, "mem",
+			"--"+anchorPublisherOverflowFlagName, "spill",
+			"--"+anchorPublisherBufferSizeFlagName, "10"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("success - error overflow policy", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		require.NoError(t, startCmd.ParseFlags(append(baseArgs, "--"+anchorPublisherOverflowFlagName, "error")))
+
+		params, err := getPublisherParameters(startCmd)
+		require.NoError(t, err)
+		require.Equal(t, publisher.OverflowError, params.overflowPolicy)
+	})
+
+	t.Run("success - durable", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorPublisherTypeFlagName, "durable"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("invalid publisher type", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorPublisherTypeFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for anchor-publisher-type [invalid]")
+	})
+
+	t.Run("invalid overflow policy", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorPublisherOverflowFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for anchor-publisher-overflow [invalid]")
+	})
+
+	t.Run("invalid buffer size", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorPublisherBufferSizeFlagName, "-1"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for anchor-publisher-buffer-size [-1]")
+	})
+}
+
+func TestGetExternalEndpoint(t *testing.T) {
+	t.Run("success - derived from host URL", func(t *testing.T) {
+		cmd := GetStartCmd(&mockServer{})
+
+		endpoint, err := getExternalEndpoint(cmd, "orb.domain.com:443", "cert.pem")
+		require.NoError(t, err)
+		require.Equal(t, "https://orb.domain.com:443", endpoint)
+
+		endpoint, err = getExternalEndpoint(cmd, "localhost:8080", "")
+		require.NoError(t, err)
+		require.Equal(t, "http://localhost:8080", endpoint)
+	})
+
+	t.Run("success - external endpoint", func(t *testing.T) {
+		cmd := GetStartCmd(&mockServer{})
+		require.NoError(t, cmd.ParseFlags([]string{"--" + externalEndpointFlagName, "https://orb.domain.com/"}))
+
+		endpoint, err := getExternalEndpoint(cmd, "0.0.0.0:8080", "")
+		require.NoError(t, err)
+		require.Equal(t, "https://orb.domain.com", endpoint)
+	})
+
+	t.Run("error - invalid external endpoint", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs([]string{"--" + hostURLFlagName, "localhost:8080",
+			"--" + externalEndpointFlagName, "orb.domain.com"})
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for external-endpoint [orb.domain.com]")
+	})
+}
+
+func TestStartCmdWithAnchorCredentialFormat(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("success - JWT", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorCredentialFormatFlagName, "jwt"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+anchorCredentialFormatFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unsupported credential format: invalid")
+	})
+}
+
+func TestStartCmdWithCASType(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080",
+		"--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("success - local CAS without CAS URL", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("error - IPFS CAS without CAS URL", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeIPFSOption))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "CAS_URL")
+	})
+
+	t.Run("success - IPFS with cache and peers", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs,
+			"--"+casURLFlagName, "localhost:8081",
+			"--"+casCacheEnabledFlagName, "true",
+			"--"+casPeerURLsFlagName, "https://orb2.domain.com/cas",
+			"--"+casPeerURLsFlagName, "https://orb3.domain.com/cas",
+			"--"+casReadTimeoutFlagName, "20s",
+			"--"+casWriteTimeoutFlagName, "1m",
+			"--"+casPeerTimeoutFlagName, "5s"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("success - tier parameters", func(t *testing.T) {
+		cmd := GetStartCmd(&mockServer{})
+		require.NoError(t, cmd.ParseFlags([]string{"--" + casURLFlagName, "localhost:8081",
+			"--" + casCacheTimeoutFlagName, "1s", "--" + casCacheFailFastFlagName, "true",
+			"--" + casPrimaryTimeoutFlagName, "30s", "--" + casPrimaryFailFastFlagName, "true",
+			"--" + casPeerTimeoutFlagName, "5s", "--" + casPeerFailFastFlagName, "true"}))
+
+		params, err := getCASParameters(cmd)
+		require.NoError(t, err)
+		require.Equal(t, tierParameters{timeout: time.Second, failFast: true}, params.cacheTier)
+		require.Equal(t, tierParameters{timeout: 30 * time.Second, failFast: true}, params.primaryTier)
+		require.Equal(t, tierParameters{timeout: 5 * time.Second, failFast: true}, params.peerTier)
+	})
+
+	t.Run("success - default tier parameters", func(t *testing.T) {
+		cmd := GetStartCmd(&mockServer{})
+		require.NoError(t, cmd.ParseFlags([]string{"--" + casURLFlagName, "localhost:8081"}))
+
+		params, err := getCASParameters(cmd)
+		require.NoError(t, err)
+		require.Equal(t, tierParameters{}, params.cacheTier)
+		require.Equal(t, tierParameters{}, params.primaryTier)
+		require.Equal(t, tierParameters{timeout: defaultCASPeerTimeout}, params.peerTier)
+	})
+
+	t.Run("success - IPFS with replicas", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs,
+			"--"+casURLFlagName, "localhost:8081",
+			"--"+casReplicaURLsFlagName, "localhost:8082",
+			"--"+casReplicaURLsFlagName, "localhost:8083",
+			"--"+casWriteQuorumFlagName, "3"))
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("success - default write quorum", func(t *testing.T) {
+		cmd := GetStartCmd(&mockServer{})
+		require.NoError(t, cmd.ParseFlags([]string{"--" + casURLFlagName, "localhost:8081",
+			"--" + casReplicaURLsFlagName, "localhost:8082", "--" + casReplicaURLsFlagName, "localhost:8083"}))
+
+		params, err := getCASParameters(cmd)
+		require.NoError(t, err)
+		require.Equal(t, []string{"localhost:8082", "localhost:8083"}, params.replicaURLs)
+		require.Equal(t, 2, params.writeQuorum)
+	})
+
+	t.Run("error - replicas with local CAS", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casReplicaURLsFlagName, "localhost:8082"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cas-replica-urls is only supported if cas-type is ipfs")
+	})
+
+	t.Run("error - invalid write quorum", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casURLFlagName, "localhost:8081",
+			"--"+casReplicaURLsFlagName, "localhost:8082",
+			"--"+casWriteQuorumFlagName, "3"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-write-quorum [3]: must be between 1 and 2")
+
+		startCmd = GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casURLFlagName, "localhost:8081",
+			"--"+casWriteQuorumFlagName, "invalid"))
+
+		err = startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-write-quorum [invalid]")
+	})
+
+	t.Run("error - invalid cache enabled", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casCacheEnabledFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-cache-enabled [invalid]")
+	})
+
+	t.Run("error - invalid read timeout", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casReadTimeoutFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-read-timeout [invalid]")
+	})
+
+	t.Run("error - invalid write timeout", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casWriteTimeoutFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-write-timeout [invalid]")
+	})
+
+	t.Run("error - invalid peer timeout", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casPeerTimeoutFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for cas-peer-timeout [invalid]")
+	})
+
+	t.Run("error - invalid tier parameters", func(t *testing.T) {
+		for _, flagName := range []string{
+			casCacheTimeoutFlagName, casCacheFailFastFlagName,
+			casPrimaryTimeoutFlagName, casPrimaryFailFastFlagName,
+			casPeerFailFastFlagName,
+		} {
+			startCmd := GetStartCmd(&mockServer{})
+
+			startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+				"--"+flagName, "invalid"))
+
+			err := startCmd.Execute()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "invalid value for "+flagName+" [invalid]")
+		}
+	})
+
+	t.Run("error - blank peer URLs", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, casTypeLocalOption,
+			"--"+casPeerURLsFlagName, ""))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cas-peer-urls value is empty")
+	})
+
+	t.Run("error - invalid CAS type", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+casTypeFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cas type not set to a valid type: invalid")
+	})
+}
+
+func TestStartCmdValidArgsWithWebKMS(t *testing.T) {
+	kmsServer := newMockKMSServer()
+	defer kmsServer.Close()
+
+	startCmd := GetStartCmd(&mockServer{})
+
+	args := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "Ed25519Signature2018",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com",
+		"--" + kmsTypeFlagName, kmsTypeWebOption,
+		"--" + kmsEndpointFlagName, kmsServer.URL}
+	startCmd.SetArgs(args)
+
+	err := startCmd.Execute()
+	require.NoError(t, err)
+	require.Equal(t, 1, kmsServer.keystoreCount())
+}
+
 func TestStartCmdValidArgsEnvVar(t *testing.T) {
 	startCmd := GetStartCmd(&mockServer{})
 
@@ -224,6 +765,7 @@ func TestStartCmdValidArgs(t *testing.T) {
 		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
 		"--" + anchorCredentialDomainFlagName, "domain.com",
 		"--" + anchorCredentialIssuerFlagName, "issuer.com",
+		"--" + externalEndpointFlagName, "https://orb.domain.com",
 		"--" + LogLevelFlagName, log.ParseString(log.ERROR)}
 	startCmd.SetArgs(args)
 
@@ -0,0 +1,72 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/trustbloc/orb/pkg/anchor/rebuilder"
+)
+
+// rebuildProgressInterval is the number of anchors after which the progress of a rebuild is logged.
+const rebuildProgressInterval = 100
+
+// startIndexRebuild starts rebuilding the DID/transaction references and the operation store of the server from
+// the anchors that are reachable from the configured heads. The rebuild runs in the background (while the server
+// is serving requests) until it's done or until the given context is done. Nothing is done if no heads are
+// configured.
+func startIndexRebuild(ctx context.Context, params *rebuildParameters, provs *indexProviders) error {
+	if len(params.heads) == 0 {
+		return nil
+	}
+
+	r, err := rebuilder.New(&rebuilder.Providers{
+		TxnGraph:               provs.txnGraph,
+		DidTxns:                provs.didTxns,
+		OpStore:                provs.opStore,
+		ProtocolClientProvider: provs.pcp,
+		Store:                  provs.edgeServiceProvs.provider,
+	}, rebuilder.WithProgressHandler(logRebuildProgress))
+	if err != nil {
+		return fmt.Errorf("failed to create index rebuilder: %w", err)
+	}
+
+	if params.restart {
+		err = r.Reset()
+		if err != nil {
+			return fmt.Errorf("failed to reset index rebuild: %w", err)
+		}
+	}
+
+	go rebuildIndex(ctx, r, params.heads)
+
+	logger.Infof("started rebuilding index from heads %s", params.heads)
+
+	return nil
+}
+
+func rebuildIndex(ctx context.Context, r *rebuilder.Rebuilder, heads []string) {
+	progress, err := r.Rebuild(ctx, heads)
+	if err != nil {
+		logger.Errorf("Failed to rebuild index (restart the server to resume the rebuild): %s", err)
+
+		return
+	}
+
+	logger.Infof("Rebuilt index from %d anchors (%d indexed, %d already indexed)",
+		progress.Total, progress.Indexed, progress.Skipped)
+}
+
+func logRebuildProgress(progress rebuilder.Progress) {
+	done := progress.Indexed + progress.Skipped
+
+	if done%rebuildProgressInterval == 0 || done == progress.Total {
+		logger.Infof("Rebuild progress: %d of %d anchors (%d indexed, %d already indexed)",
+			done, progress.Total, progress.Indexed, progress.Skipped)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package startcmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ariesmockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/anchor/rebuilder"
+)
+
+const headCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestStartCmdWithRebuildIndexArgs(t *testing.T) {
+	baseArgs := []string{"--" + hostURLFlagName, "localhost:8080", "--" + casURLFlagName,
+		"localhost:8081", "--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com"}
+
+	t.Run("defaults", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		require.NoError(t, startCmd.ParseFlags(baseArgs))
+
+		params, err := getRebuildParameters(startCmd)
+		require.NoError(t, err)
+		require.Empty(t, params.heads)
+		require.False(t, params.restart)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		args := append(baseArgs, "--"+rebuildIndexHeadsFlagName, headCID,
+			"--"+rebuildIndexHeadsFlagName, "head2", "--"+rebuildIndexRestartFlagName, "true")
+
+		require.NoError(t, startCmd.ParseFlags(args))
+
+		params, err := getRebuildParameters(startCmd)
+		require.NoError(t, err)
+		require.Equal(t, []string{headCID, "head2"}, params.heads)
+		require.True(t, params.restart)
+
+		startCmd = GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(args)
+
+		require.NoError(t, startCmd.Execute())
+	})
+
+	t.Run("invalid restart", func(t *testing.T) {
+		startCmd := GetStartCmd(&mockServer{})
+
+		startCmd.SetArgs(append(baseArgs, "--"+rebuildIndexHeadsFlagName, headCID,
+			"--"+rebuildIndexRestartFlagName, "invalid"))
+
+		err := startCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value for rebuild-index-restart [invalid]")
+	})
+}
+
+func TestStartIndexRebuild(t *testing.T) {
+	provs := newTestIndexProviders(t)
+
+	t.Run("no heads", func(t *testing.T) {
+		require.NoError(t, startIndexRebuild(context.Background(), &rebuildParameters{}, provs))
+	})
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		require.NoError(t, startIndexRebuild(ctx, &rebuildParameters{heads: []string{headCID}, restart: true}, provs))
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		p := *provs
+		p.edgeServiceProvs = &edgeServiceProviders{
+			provider: &ariesmockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")},
+		}
+
+		err := startIndexRebuild(context.Background(), &rebuildParameters{heads: []string{headCID}}, &p)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create index rebuilder")
+	})
+
+	t.Run("error - reset", func(t *testing.T) {
+		storeProvider := ariesmockstorage.NewMockStoreProvider()
+		storeProvider.Store.ErrItr = errors.New("iterator error")
+
+		p := *provs
+		p.edgeServiceProvs = &edgeServiceProviders{provider: storeProvider}
+
+		err := startIndexRebuild(context.Background(), &rebuildParameters{heads: []string{headCID}, restart: true}, &p)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to reset index rebuild")
+	})
+}
+
+func TestRebuildIndex(t *testing.T) {
+	provs := newTestIndexProviders(t)
+
+	r, err := rebuilder.New(&rebuilder.Providers{
+		TxnGraph:               provs.txnGraph,
+		DidTxns:                provs.didTxns,
+		OpStore:                provs.opStore,
+		ProtocolClientProvider: provs.pcp,
+		Store:                  provs.edgeServiceProvs.provider,
+	})
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		rebuildIndex(context.Background(), r, nil)
+	})
+
+	t.Run("error - anchor not found", func(t *testing.T) {
+		rebuildIndex(context.Background(), r, []string{headCID})
+	})
+}
+
+func newTestIndexProviders(t *testing.T) *indexProviders {
+	t.Helper()
+
+	startCmd := GetStartCmd(&mockServer{})
+
+	require.NoError(t, startCmd.ParseFlags([]string{"--" + hostURLFlagName, "localhost:8080",
+		"--" + didNamespaceFlagName, "namespace", "--" + databaseTypeFlagName, databaseTypeMemOption,
+		"--" + kmsSecretsDatabaseTypeFlagName, databaseTypeMemOption,
+		"--" + anchorCredentialSignatureSuiteFlagName, "suite",
+		"--" + anchorCredentialDomainFlagName, "domain.com",
+		"--" + anchorCredentialIssuerFlagName, "issuer.com",
+		"--" + casTypeFlagName, casTypeLocalOption}))
+
+	params, err := getOrbParameters(startCmd)
+	require.NoError(t, err)
+
+	provs, err := createIndexProviders(params)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { stopCASClient(provs.casClient) })
+
+	return provs
+}
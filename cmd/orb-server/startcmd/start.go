@@ -8,40 +8,71 @@ package startcmd
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/google/tink/go/subtle/random"
 	ariescouchdbstorage "github.com/hyperledger/aries-framework-go-ext/component/storage/couchdb"
 	ariesmysqlstorage "github.com/hyperledger/aries-framework-go-ext/component/storage/mysql"
+	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
 	"github.com/hyperledger/aries-framework-go/pkg/crypto/tinkcrypto"
+	webcrypto "github.com/hyperledger/aries-framework-go/pkg/crypto/webkms"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	"github.com/hyperledger/aries-framework-go/pkg/kms/localkms"
+	"github.com/hyperledger/aries-framework-go/pkg/kms/webkms"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
 	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock/local/masterlock/hkdf"
 	ariesstorage "github.com/hyperledger/aries-framework-go/pkg/storage"
 	ariesmemstorage "github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	"github.com/spf13/cobra"
 	"github.com/trustbloc/edge-core/pkg/log"
 	sidetreecontext "github.com/trustbloc/orb/pkg/context"
 	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-core-go/pkg/batch"
 	"github.com/trustbloc/sidetree-core-go/pkg/dochandler"
 	"github.com/trustbloc/sidetree-core-go/pkg/processor"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
 	"github.com/trustbloc/sidetree-core-go/pkg/restapi/diddochandler"
 
+	"github.com/trustbloc/orb/cmd/orb-server/conditionalstore"
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+	"github.com/trustbloc/orb/pkg/anchor/anchorlogresthandler"
+	"github.com/trustbloc/orb/pkg/anchor/archive"
+	"github.com/trustbloc/orb/pkg/anchor/archiveresthandler"
 	"github.com/trustbloc/orb/pkg/anchor/builder"
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+	"github.com/trustbloc/orb/pkg/anchor/didindexresthandler"
 	"github.com/trustbloc/orb/pkg/anchor/graph"
+	"github.com/trustbloc/orb/pkg/anchor/pending"
+	"github.com/trustbloc/orb/pkg/anchor/pinner"
+	"github.com/trustbloc/orb/pkg/anchor/pinresthandler"
+	"github.com/trustbloc/orb/pkg/anchor/publisher"
+	"github.com/trustbloc/orb/pkg/anchor/status"
+	"github.com/trustbloc/orb/pkg/anchor/statusresthandler"
+	"github.com/trustbloc/orb/pkg/anchor/vcresthandler"
+	"github.com/trustbloc/orb/pkg/anchor/vcstore"
 	"github.com/trustbloc/orb/pkg/anchor/writer"
 	"github.com/trustbloc/orb/pkg/context/cas"
-	"github.com/trustbloc/orb/pkg/didtxnref/memdidtxnref"
+	"github.com/trustbloc/orb/pkg/context/casresthandler"
+	"github.com/trustbloc/orb/pkg/didtxnref/storedidtxnref"
 	"github.com/trustbloc/orb/pkg/httpserver"
+	"github.com/trustbloc/orb/pkg/jsonld"
+	"github.com/trustbloc/orb/pkg/metrics"
 	"github.com/trustbloc/orb/pkg/mocks"
 	"github.com/trustbloc/orb/pkg/observer"
 	"github.com/trustbloc/orb/pkg/txnprocessor"
@@ -53,9 +84,15 @@ const (
 	masterKeyStoreName = "masterkey"
 	masterKeyDBKeyName = masterKeyStoreName
 
+	// masterKeyLockedDBKeyName is the key under which the master key is stored when protected by a master lock.
+	masterKeyLockedDBKeyName = masterKeyStoreName + "-locked"
+
 	masterKeyNumBytes = 32
 
-	txnBuffer = 100
+	keystoreURLStoreName = "keystore"
+	keystoreURLDBKeyName = "keystoreurl"
+
+	kmsHTTPTimeout = 20 * time.Second
 )
 
 var logger = log.New("orb-server")
@@ -121,50 +158,34 @@ func startOrbServices(parameters *orbParameters, srv server) error {
 		SetDefaultLogLevel(logger, parameters.logLevel)
 	}
 
-	edgeServiceProvs, err := createStoreProviders(parameters)
+	provs, err := createIndexProviders(parameters)
 	if err != nil {
 		return err
 	}
 
-	localKMS, err := createKMS(edgeServiceProvs.kmsSecretsProvider)
-	if err != nil {
-		return err
-	}
+	defer stopCASClient(provs.casClient)
 
-	crypto, err := tinkcrypto.New()
+	pc, err := provs.pcp.ForNamespace(mocks.DefaultNS)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get protocol client for namespace [%s]: %s", mocks.DefaultNS, err.Error())
 	}
 
-	// basic providers (CAS + operation store)
-	casClient := cas.New(parameters.casURL)
-
-	didTxns := memdidtxnref.New()
-	opStore := mocks.NewMockOperationStore()
-
-	// TODO: For now fetch signing public key from local KMS (this will handled differently later on: webfinger or did:web)
-	txnGraph := graph.New(casClient, func(_, keyID string) (*verifier.PublicKey, error) {
-		pubKeyBytes, err := localKMS.ExportPubKeyBytes(keyID[1:])
-		if err != nil {
-			return nil, fmt.Errorf("failed to export public key[%s] from kms: %s", keyID, err.Error())
-		}
+	pins, ok := provs.casClient.(cas.Pinner)
+	if !ok {
+		return fmt.Errorf("CAS client does not support pinning")
+	}
 
-		return &verifier.PublicKey{
-			Type:  kms.ED25519,
-			Value: pubKeyBytes,
-		}, nil
+	// anchors and batch files are pinned so that the anchor history isn't garbage collected by IPFS
+	anchorPinner := pinner.New(&pinner.Providers{
+		CAS:                    provs.casClient,
+		Pins:                   pins,
+		TxnGraph:               provs.txnGraph,
+		ProtocolClientProvider: provs.pcp,
 	})
 
-	// get protocol client provider
-	pcp := getProtocolClientProvider(parameters, casClient, opStore, txnGraph)
-	pc, err := pcp.ForNamespace(mocks.DefaultNS)
-	if err != nil {
-		return fmt.Errorf("failed to get protocol client for namespace [%s]: %s", mocks.DefaultNS, err.Error())
-	}
-
 	// TODO: For now create key at startup, we need different way of handling this key as orb parameter
 	// once we figure out how to expose verification method (webfinger, did:web)
-	keyID, _, err := localKMS.Create(kms.ED25519Type)
+	keyID, _, err := provs.km.Create(kms.ED25519Type)
 	if err != nil {
 		return fmt.Errorf("failed to create anchor credential signing key: %s", err.Error())
 	}
@@ -175,20 +196,67 @@ func startOrbServices(parameters *orbParameters, srv server) error {
 		SignatureSuite:     parameters.anchorCredentialParams.signatureSuite,
 	}
 
-	vcSigner, err := vcsigner.New(localKMS, crypto, signingParams)
+	vcSigner, err := vcsigner.New(provs.km, provs.crypto, signingParams, vcsigner.WithDocumentLoader(provs.documentLoader))
 	if err != nil {
 		return fmt.Errorf("failed to create vc signer: %s", err.Error())
 	}
 
-	vcBuilder, err := builder.New(vcSigner, builder.Params{Issuer: parameters.anchorCredentialParams.issuer})
+	// anchor credentials are served by this node under its external endpoint
+	vcBaseURL := parameters.externalEndpoint + vcresthandler.Path
+
+	vcStore, err := vcstore.New(provs.edgeServiceProvs.provider)
+	if err != nil {
+		return err
+	}
+
+	vcBuilder, err := builder.New(vcSigner, builder.Params{
+		Issuer:  parameters.anchorCredentialParams.issuer,
+		BaseURL: vcBaseURL,
+		Format:  parameters.anchorCredentialParams.format,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create vc builder: %s", err.Error())
 	}
 
-	// create transaction channel (used by transaction client to notify observer about orb transactions)
-	sidetreeTxnCh := make(chan []string, txnBuffer)
-	txnClientProviders := &writer.Providers{TxnGraph: txnGraph, DidTxns: didTxns, TxnBuilder: vcBuilder}
-	txnClient := writer.New("did:sidetree", txnClientProviders, sidetreeTxnCh)
+	pendingStore, err := pending.New(provs.edgeServiceProvs.provider)
+	if err != nil {
+		return err
+	}
+
+	anchorStatus, err := status.New(provs.edgeServiceProvs.provider)
+	if err != nil {
+		return err
+	}
+
+	anchorLog, err := anchorlog.New(provs.edgeServiceProvs.provider)
+	if err != nil {
+		return err
+	}
+
+	// create the publisher (used by transaction client to notify observer about orb transactions)
+	anchorPublisher, err := createAnchorPublisher(parameters.publisherParams, provs.edgeServiceProvs.provider)
+	if err != nil {
+		return err
+	}
+
+	metrics.RegisterGauge("anchorPublisherQueueDepth", func() int64 {
+		return int64(anchorPublisher.QueueDepth())
+	})
+
+	anchorPublisher.Start()
+
+	txnClientProviders := &writer.Providers{
+		TxnGraph:     provs.txnGraph,
+		DidTxns:      provs.didTxns,
+		TxnBuilder:   vcBuilder,
+		VCStore:      vcStore,
+		DIDIndex:     provs.didIndex,
+		PendingStore: pendingStore,
+		AnchorStatus: anchorStatus,
+		Publisher:    anchorPublisher,
+		AnchorLog:    anchorLog,
+	}
+	txnClient := writer.New("did:sidetree", txnClientProviders)
 
 	// create new batch writer
 	batchWriter, err := batch.New(parameters.didNamespace, sidetreecontext.New(pc, txnClient))
@@ -202,36 +270,302 @@ func startOrbServices(parameters *orbParameters, srv server) error {
 
 	// create new observer and start it
 	providers := &observer.Providers{
-		TxnProvider:            mockTxnProvider{registerForSidetreeTxnValue: sidetreeTxnCh},
-		ProtocolClientProvider: pcp,
-		TxnGraph:               txnGraph,
+		TxnProvider:            anchorPublisher,
+		ProtocolClientProvider: provs.pcp,
+		TxnGraph:               provs.txnGraph,
+		AnchorPinner:           anchorPinner,
+		AnchorStatus:           anchorStatus,
+		AnchorLog:              anchorLog,
 	}
 
 	observer.New(providers).Start()
 	logger.Infof("started observer")
 
+	rebuildCtx, cancelRebuild := context.WithCancel(context.Background())
+	defer cancelRebuild()
+
+	err = startIndexRebuild(rebuildCtx, parameters.rebuildParams, provs)
+	if err != nil {
+		return err
+	}
+
+	// start writing anchors (anchors that weren't completely written before the last shutdown are resumed)
+	txnClient.Start()
+	logger.Infof("started anchor writer")
+
 	didDocHandler := dochandler.New(
 		parameters.didNamespace,
 		parameters.didAliases,
 		pc,
 		batchWriter,
-		processor.New(parameters.didNamespace, opStore, pc),
+		processor.New(parameters.didNamespace, provs.opStore, pc),
 	)
 
+	var archiveOpts []archiveresthandler.Opt
+
+	if parameters.archiveParams.maxSize > 0 {
+		archiveOpts = append(archiveOpts, archiveresthandler.WithMaxArchiveSize(parameters.archiveParams.maxSize))
+	}
+
+	handlers := []common.HTTPHandler{
+		diddochandler.NewUpdateHandler(basePath, didDocHandler, pc),
+		diddochandler.NewResolveHandler(basePath, didDocHandler),
+		vcresthandler.New(vcBaseURL, vcStore, provs.txnGraph),
+		// content is only served from the local tiers so that reads aren't forwarded between peers
+		casresthandler.New(localCASClient(provs.casClient)),
+		pinresthandler.NewCheckHandler(anchorPinner),
+		pinresthandler.NewRepinHandler(anchorPinner),
+		archiveresthandler.NewExportHandler(archive.NewExporter(&archive.Providers{
+			CAS:                    provs.casClient,
+			TxnGraph:               provs.txnGraph,
+			ProtocolClientProvider: provs.pcp,
+		}), archiveOpts...),
+		didindexresthandler.New(provs.didIndex),
+		statusresthandler.NewAnchorHandler(anchorStatus),
+		statusresthandler.NewDIDHandler(anchorStatus),
+		anchorlogresthandler.New(anchorLog),
+		metrics.NewHandler(),
+	}
+
+	// importing archives writes arbitrary (verified) content to CAS so it must be explicitly enabled
+	if parameters.archiveParams.importEnabled {
+		handlers = append(handlers, archiveresthandler.NewImportHandler(provs.casClient, archiveOpts...))
+	}
+
 	httpServer := httpserver.New(
 		parameters.hostURL,
 		parameters.tlsCertificate,
 		parameters.tlsKey,
 		parameters.token,
-		diddochandler.NewUpdateHandler(basePath, didDocHandler, pc),
-		diddochandler.NewResolveHandler(basePath, didDocHandler),
+		handlers...,
 	)
 
 	return srv.Start(httpServer)
 }
 
-func getProtocolClientProvider(parameters *orbParameters, casClient casapi.Client, opStore txnprocessor.OperationStore, graph *graph.Graph) *mocks.MockProtocolClientProvider {
-	return mocks.NewMockProtocolClientProvider().
+// indexProviders contains the providers that are required for indexing anchors.
+type indexProviders struct {
+	edgeServiceProvs *edgeServiceProviders
+	km               kms.KeyManager
+	crypto           ariescrypto.Crypto
+	documentLoader   *jsonld.DocumentLoader
+	casClient        casapi.Client
+	didTxns          *storedidtxnref.StoreDidTxnRef
+	didIndex         *didindex.Store
+	opStore          *mocks.MockOperationStore
+	txnGraph         *graph.Graph
+	pcp              *mocks.MockProtocolClientProvider
+}
+
+func createIndexProviders(parameters *orbParameters) (*indexProviders, error) {
+	edgeServiceProvs, err := createStoreProviders(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	km, crypto, err := createKMSAndCrypto(parameters, edgeServiceProvs.kmsSecretsProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	documentLoader, err := createJSONLDDocumentLoader(parameters.jsonldParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// The protocol client provider is configured once the CAS client and the anchor graph are created. The allowed
+	// multihash algorithms are resolved from the current protocol version when content is read.
+	pcp := mocks.NewMockProtocolClientProvider()
+	multihashAlgorithms := currentMultihashAlgorithms(pcp, mocks.DefaultNS)
+
+	// basic providers (CAS + operation store)
+	casClient, err := createCASClient(parameters, edgeServiceProvs.provider, multihashAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+
+	// did/txn references are persisted so that new anchors keep their links to previous anchors after a restart
+	didTxnRefProvider, err := createDidTxnRefProvider(parameters.dbParameters, edgeServiceProvs.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	didTxns, err := storedidtxnref.New(didTxnRefProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	// reverse index from anchors to the DIDs that were anchored (populated by the writer and the txn processor)
+	didIndex, err := didindex.New(edgeServiceProvs.provider)
+	if err != nil {
+		return nil, err
+	}
+
+	opStore := mocks.NewMockOperationStore()
+
+	// TODO: For now fetch signing public key from KMS (this will handled differently later on: webfinger or did:web)
+	// The key ID is "#<kid>" for linked data proofs and the full verification method for JWTs.
+	txnGraph := graph.New(casClient, func(_, keyID string) (*verifier.PublicKey, error) {
+		pubKeyBytes, err := km.ExportPubKeyBytes(keyID[strings.LastIndex(keyID, "#")+1:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to export public key[%s] from kms: %s", keyID, err.Error())
+		}
+
+		return &verifier.PublicKey{
+			Type:  kms.ED25519,
+			Value: pubKeyBytes,
+		}, nil
+	}, graph.WithDocumentLoader(documentLoader), graph.WithMultihashAlgorithmsProvider(multihashAlgorithms))
+
+	configureProtocolClientProvider(pcp, parameters, casClient, opStore, txnGraph).WithDIDIndex(didIndex)
+
+	return &indexProviders{
+		edgeServiceProvs: edgeServiceProvs,
+		km:               km,
+		crypto:           crypto,
+		documentLoader:   documentLoader,
+		casClient:        casClient,
+		didTxns:          didTxns,
+		didIndex:         didIndex,
+		opStore:          opStore,
+		txnGraph:         txnGraph,
+		pcp:              pcp,
+	}, nil
+}
+
+// createCASClient creates the CAS client. If a cache or peers are configured then a layered CAS client is
+// returned which reads through the cache, the primary CAS (IPFS or local) and the peers (in that order).
+func createCASClient(parameters *orbParameters, provider ariesstorage.Provider,
+	multihashAlgorithms cas.MultihashAlgorithmsProvider) (casapi.Client, error) {
+	params := parameters.casParams
+
+	var tiers []*cas.Tier
+
+	primary := &cas.Tier{
+		Name:     params.casType,
+		Timeout:  params.primaryTier.timeout,
+		FailFast: params.primaryTier.failFast,
+	}
+
+	if params.casType == casTypeLocalOption {
+		casClient, err := cas.NewLocal(provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create local CAS: %w", err)
+		}
+
+		primary.Client = casClient
+	} else {
+		ipfsClient, err := createIPFSClient(params, multihashAlgorithms)
+		if err != nil {
+			return nil, err
+		}
+
+		primary.Client = ipfsClient
+
+		if params.cacheEnabled {
+			cache, err := cas.NewLocal(provider)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create CAS cache: %w", err)
+			}
+
+			tiers = append(tiers, &cas.Tier{
+				Name:     "cache",
+				Client:   cache,
+				Timeout:  params.cacheTier.timeout,
+				FailFast: params.cacheTier.failFast,
+			})
+		}
+	}
+
+	tiers = append(tiers, primary)
+
+	for _, peerURL := range params.peerURLs {
+		tiers = append(tiers, &cas.Tier{
+			Name:     peerURL,
+			Client:   cas.NewHTTP(peerURL, cas.WithAuthToken(parameters.token)),
+			Timeout:  params.peerTier.timeout,
+			FailFast: params.peerTier.failFast,
+		})
+	}
+
+	if len(tiers) == 1 {
+		return primary.Client, nil
+	}
+
+	return cas.NewLayered(primary.Name, tiers...)
+}
+
+// localCASClient returns a CAS client that only reads from the local tiers (i.e. the cache and the primary tier)
+// of the given CAS client.
+func localCASClient(casClient casapi.Client) casapi.Client {
+	if l, ok := casClient.(*cas.LayeredClient); ok {
+		return l.Local()
+	}
+
+	return casClient
+}
+
+// createIPFSClient creates the IPFS client. If replicas are configured then a replicating CAS client is returned
+// which writes to the IPFS node at the CAS URL and to the replicas.
+func createIPFSClient(params *casParameters,
+	multihashAlgorithms cas.MultihashAlgorithmsProvider) (casapi.Client, error) {
+	newClient := func(url string) *cas.Client {
+		return cas.New(url,
+			cas.WithReadTimeout(params.readTimeout),
+			cas.WithWriteTimeout(params.writeTimeout),
+			cas.WithMultihashAlgorithmsProvider(multihashAlgorithms),
+		)
+	}
+
+	if len(params.replicaURLs) == 0 {
+		return newClient(params.casURL), nil
+	}
+
+	replicas := []*cas.Replica{{Name: params.casURL, Client: newClient(params.casURL)}}
+
+	for _, replicaURL := range params.replicaURLs {
+		replicas = append(replicas, &cas.Replica{Name: replicaURL, Client: newClient(replicaURL)})
+	}
+
+	replicated, err := cas.NewReplicated(params.writeQuorum, replicas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replicating CAS: %w", err)
+	}
+
+	return replicated, nil
+}
+
+// stopCASClient stops the background work (e.g. the repair of CAS replicas) of the given CAS client.
+func stopCASClient(casClient casapi.Client) {
+	if s, ok := casClient.(cas.Stopper); ok {
+		s.Stop()
+	}
+}
+
+func createJSONLDDocumentLoader(params *jsonldParameters) (*jsonld.DocumentLoader, error) {
+	var opts []jsonld.Opt
+
+	if !params.strictMode {
+		opts = append(opts, jsonld.WithRemoteFetch())
+	}
+
+	if params.contextsDir != "" {
+		contexts, err := jsonld.ReadContextsDir(params.contextsDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load JSON-LD contexts: %w", err)
+		}
+
+		logger.Infof("loaded %d JSON-LD contexts from %s", len(contexts), params.contextsDir)
+
+		opts = append(opts, jsonld.WithContexts(contexts...))
+	}
+
+	return jsonld.NewDocumentLoader(opts...), nil
+}
+
+func configureProtocolClientProvider(pcp *mocks.MockProtocolClientProvider, parameters *orbParameters,
+	casClient casapi.Client, opStore txnprocessor.OperationStore, graph *graph.Graph) *mocks.MockProtocolClientProvider {
+	return pcp.
 		WithOpStore(opStore).
 		WithOpStoreClient(opStore).
 		WithMethodContext(parameters.methodContext).
@@ -240,6 +574,24 @@ func getProtocolClientProvider(parameters *orbParameters, casClient casapi.Clien
 		WithTxnGraph(graph)
 }
 
+// currentMultihashAlgorithms returns a provider of the multihash algorithms of the current protocol version of
+// the given namespace.
+func currentMultihashAlgorithms(pcp protocol.ClientProvider, namespace string) cas.MultihashAlgorithmsProvider {
+	return func() ([]uint, error) {
+		pc, err := pcp.ForNamespace(namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get protocol client for namespace [%s]: %w", namespace, err)
+		}
+
+		pv, err := pc.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current protocol version: %w", err)
+		}
+
+		return pv.Protocol().MultihashAlgorithms, nil
+	}
+}
+
 type kmsProvider struct {
 	storageProvider   ariesstorage.Provider
 	secretLockService secretlock.Service
@@ -253,6 +605,20 @@ func (k kmsProvider) SecretLock() secretlock.Service {
 	return k.secretLockService
 }
 
+// createDidTxnRefProvider wraps the given storage provider so that the DID transaction references are updated with
+// conditional writes. A shared database may therefore be used by several servers that write anchors.
+func createDidTxnRefProvider(params *dbParameters, provider ariesstorage.Provider) (ariesstorage.Provider, error) {
+	switch {
+	case strings.EqualFold(params.databaseType, databaseTypeCouchDBOption):
+		return conditionalstore.NewCouchDBProvider(provider, params.databaseURL, params.databasePrefix)
+	case strings.EqualFold(params.databaseType, databaseTypeMYSQLDBOption):
+		return conditionalstore.NewMySQLProvider(provider, params.databaseURL, params.databasePrefix)
+	default:
+		// the mem store isn't shared so conditional writes within this process are sufficient
+		return provider, nil
+	}
+}
+
 type edgeServiceProviders struct {
 	provider           ariesstorage.Provider
 	kmsSecretsProvider ariesstorage.Provider
@@ -317,8 +683,88 @@ func createStoreProviders(parameters *orbParameters) (*edgeServiceProviders, err
 	return &edgeServiceProvs, nil
 }
 
-func createKMS(kmsSecretsProvider ariesstorage.Provider) (*localkms.LocalKMS, error) {
-	localKMS, err := createLocalKMS(kmsSecretsProvider)
+func createKMSAndCrypto(parameters *orbParameters,
+	kmsSecretsProvider ariesstorage.Provider) (kms.KeyManager, ariescrypto.Crypto, error) {
+	if parameters.kmsParams.kmsType == kmsTypeWebOption {
+		return createWebKMSAndCrypto(parameters, kmsSecretsProvider)
+	}
+
+	masterLock, err := createMasterLock(parameters.kmsParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localKMS, err := createKMS(kmsSecretsProvider, masterLock)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	crypto, err := tinkcrypto.New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return localKMS, crypto, nil
+}
+
+// createWebKMSAndCrypto creates key manager and crypto that delegate all key operations to a remote KMS server,
+// so that private keys are never held by the orb process.
+func createWebKMSAndCrypto(parameters *orbParameters,
+	kmsSecretsProvider ariesstorage.Provider) (kms.KeyManager, ariescrypto.Crypto, error) {
+	httpClient := &http.Client{Timeout: kmsHTTPTimeout}
+
+	keystoreURL, err := getKeystoreURL(httpClient, parameters, kmsSecretsProvider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Infof("using remote keystore: %s", keystoreURL)
+
+	return webkms.New(keystoreURL, httpClient), webcrypto.New(keystoreURL, httpClient), nil
+}
+
+// getKeystoreURL returns the configured keystore URL. If not configured, the keystore URL that was previously
+// created is loaded from the KMS secrets store; otherwise a new keystore is created on the remote KMS server.
+func getKeystoreURL(httpClient *http.Client, parameters *orbParameters,
+	kmsSecretsProvider ariesstorage.Provider) (string, error) {
+	if parameters.kmsParams.kmsStoreEndpoint != "" {
+		return parameters.kmsParams.kmsStoreEndpoint, nil
+	}
+
+	keystoreURLStore, err := kmsSecretsProvider.OpenStore(keystoreURLStoreName)
+	if err != nil {
+		return "", err
+	}
+
+	keystoreURL, err := keystoreURLStore.Get(keystoreURLDBKeyName)
+	if err == nil {
+		return string(keystoreURL), nil
+	}
+
+	if !errors.Is(err, ariesstorage.ErrDataNotFound) {
+		return "", err
+	}
+
+	newKeystoreURL, err := webkms.CreateKeyStore(httpClient, parameters.kmsParams.kmsEndpoint,
+		parameters.anchorCredentialParams.issuer, "", json.Marshal)
+	if err != nil {
+		return "", fmt.Errorf("failed to create keystore: %w", err)
+	}
+
+	if newKeystoreURL == "" {
+		return "", fmt.Errorf("remote KMS server [%s] did not return keystore URL", parameters.kmsParams.kmsEndpoint)
+	}
+
+	err = keystoreURLStore.Put(keystoreURLDBKeyName, []byte(newKeystoreURL))
+	if err != nil {
+		return "", err
+	}
+
+	return newKeystoreURL, nil
+}
+
+func createKMS(kmsSecretsProvider ariesstorage.Provider, masterLock secretlock.Service) (*localkms.LocalKMS, error) {
+	localKMS, err := createLocalKMS(kmsSecretsProvider, masterLock)
 	if err != nil {
 		return nil, err
 	}
@@ -326,13 +772,39 @@ func createKMS(kmsSecretsProvider ariesstorage.Provider) (*localkms.LocalKMS, er
 	return localKMS, nil
 }
 
-func createLocalKMS(kmsSecretsStoreProvider ariesstorage.Provider) (*localkms.LocalKMS, error) {
-	masterKeyReader, err := prepareMasterKeyReader(kmsSecretsStoreProvider)
+// createMasterLock creates the master lock used to protect the local KMS master key from the master lock key
+// configured in a file or directly. Returns nil if master lock key is not configured.
+func createMasterLock(params *kmsParameters) (secretlock.Service, error) {
+	masterLockKey := params.secretLockKey
+
+	if params.secretLockKeyPath != "" {
+		keyBytes, err := ioutil.ReadFile(filepath.Clean(params.secretLockKeyPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read master lock key file: %w", err)
+		}
+
+		masterLockKey = strings.TrimSpace(string(keyBytes))
+	}
+
+	if masterLockKey == "" {
+		if params.secretLockKeyPath != "" {
+			return nil, fmt.Errorf("master lock key file [%s] is empty", params.secretLockKeyPath)
+		}
+
+		return nil, nil
+	}
+
+	return hkdf.NewMasterLock(masterLockKey, sha256.New, nil)
+}
+
+func createLocalKMS(kmsSecretsStoreProvider ariesstorage.Provider,
+	masterLock secretlock.Service) (*localkms.LocalKMS, error) {
+	masterKeyReader, err := prepareMasterKeyReader(kmsSecretsStoreProvider, masterLock)
 	if err != nil {
 		return nil, err
 	}
 
-	secretLockService, err := local.NewService(masterKeyReader, nil)
+	secretLockService, err := local.NewService(masterKeyReader, masterLock)
 	if err != nil {
 		return nil, err
 	}
@@ -345,13 +817,28 @@ func createLocalKMS(kmsSecretsStoreProvider ariesstorage.Provider) (*localkms.Lo
 	return localkms.New(masterKeyURI, kmsProv)
 }
 
-// prepareMasterKeyReader prepares a master key reader for secret lock usage
-func prepareMasterKeyReader(kmsSecretsStoreProvider ariesstorage.Provider) (*bytes.Reader, error) {
+// prepareMasterKeyReader prepares a master key reader for secret lock usage. If master lock is provided
+// the reader will contain master key protected by master lock.
+func prepareMasterKeyReader(kmsSecretsStoreProvider ariesstorage.Provider,
+	masterLock secretlock.Service) (*bytes.Reader, error) {
 	masterKeyStore, err := kmsSecretsStoreProvider.OpenStore(masterKeyStoreName)
 	if err != nil {
 		return nil, err
 	}
 
+	if masterLock != nil {
+		return prepareLockedMasterKeyReader(masterKeyStore, masterLock)
+	}
+
+	_, err = masterKeyStore.Get(masterKeyLockedDBKeyName)
+	if err == nil {
+		return nil, errors.New("master key is protected by master lock but master lock key is not configured")
+	}
+
+	if !errors.Is(err, ariesstorage.ErrDataNotFound) {
+		return nil, err
+	}
+
 	masterKey, err := masterKeyStore.Get(masterKeyDBKeyName)
 	if err != nil {
 		if errors.Is(err, ariesstorage.ErrDataNotFound) {
@@ -372,10 +859,80 @@ func prepareMasterKeyReader(kmsSecretsStoreProvider ariesstorage.Provider) (*byt
 	return masterKeyReader, nil
 }
 
-type mockTxnProvider struct {
-	registerForSidetreeTxnValue chan []string
+// prepareLockedMasterKeyReader returns a reader for the master key protected by master lock. A new master key
+// is generated if one doesn't exist. An existing unprotected master key is migrated: it is protected by
+// master lock, stored and then removed from the store.
+func prepareLockedMasterKeyReader(masterKeyStore ariesstorage.Store,
+	masterLock secretlock.Service) (*bytes.Reader, error) {
+	lockedMasterKey, err := masterKeyStore.Get(masterKeyLockedDBKeyName)
+	if err == nil {
+		return bytes.NewReader(lockedMasterKey), nil
+	}
+
+	if !errors.Is(err, ariesstorage.ErrDataNotFound) {
+		return nil, err
+	}
+
+	var masterKeyRaw []byte
+
+	unlockedMasterKey, err := masterKeyStore.Get(masterKeyDBKeyName)
+
+	migrate := err == nil
+
+	switch {
+	case migrate:
+		logger.Infof("migrating unprotected master key to master key protected by master lock")
+
+		masterKeyRaw, err = base64.URLEncoding.DecodeString(string(unlockedMasterKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode unprotected master key: %w", err)
+		}
+	case errors.Is(err, ariesstorage.ErrDataNotFound):
+		masterKeyRaw = random.GetRandomBytes(uint32(masterKeyNumBytes))
+	default:
+		return nil, err
+	}
+
+	encResp, err := masterLock.Encrypt("", &secretlock.EncryptRequest{Plaintext: string(masterKeyRaw)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to protect master key with master lock: %w", err)
+	}
+
+	lockedMasterKey = []byte(encResp.Ciphertext)
+
+	err = masterKeyStore.Put(masterKeyLockedDBKeyName, lockedMasterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if migrate {
+		err = masterKeyStore.Delete(masterKeyDBKeyName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete unprotected master key: %w", err)
+		}
+	}
+
+	return bytes.NewReader(lockedMasterKey), nil
 }
 
-func (m mockTxnProvider) RegisterForOrbTxn() <-chan []string {
-	return m.registerForSidetreeTxnValue
+func createAnchorPublisher(params *publisherParameters,
+	provider ariesstorage.Provider) (*publisher.Publisher, error) {
+	var opts []publisher.Opt
+
+	if params.bufferSizeSet {
+		opts = append(opts, publisher.WithBufferSize(params.bufferSize))
+	}
+
+	if params.publisherType == anchorPublisherTypeDurableOption {
+		logger.Infof("using durable anchor publisher")
+
+		return publisher.NewDurable(provider, opts...)
+	}
+
+	logger.Infof("using mem anchor publisher with overflow policy [%s]", params.overflowPolicy)
+
+	return publisher.New(append(opts,
+		publisher.WithOverflowPolicy(params.overflowPolicy),
+		publisher.WithSpillStore(provider),
+	)...)
 }
@@ -6,12 +6,32 @@ SPDX-License-Identifier: Apache-2.0
 package startcmd
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
 	ariesmockstorage "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/secretlock"
 	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	ariesmemstorage "github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	coremocks "github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/cmd/orb-server/conditionalstore"
+	"github.com/trustbloc/orb/pkg/context/cas"
+	"github.com/trustbloc/orb/pkg/mocks"
 )
 
 func TestCreateProviders(t *testing.T) {
@@ -53,9 +73,35 @@ func TestCreateProviders(t *testing.T) {
 	})
 }
 
+func TestCreateDidTxnRefProvider(t *testing.T) {
+	provider := ariesmemstorage.NewProvider()
+
+	t.Run("mem", func(t *testing.T) {
+		p, err := createDidTxnRefProvider(&dbParameters{databaseType: databaseTypeMemOption}, provider)
+		require.NoError(t, err)
+		require.Equal(t, provider, p)
+	})
+
+	t.Run("CouchDB", func(t *testing.T) {
+		p, err := createDidTxnRefProvider(&dbParameters{
+			databaseType: databaseTypeCouchDBOption, databaseURL: "localhost:5984",
+		}, provider)
+		require.NoError(t, err)
+		require.IsType(t, &conditionalstore.CouchDBProvider{}, p)
+	})
+
+	t.Run("MySQL", func(t *testing.T) {
+		p, err := createDidTxnRefProvider(&dbParameters{
+			databaseType: databaseTypeMYSQLDBOption, databaseURL: "root:secret@tcp(localhost:3306)/",
+		}, provider)
+		require.NoError(t, err)
+		require.IsType(t, &conditionalstore.MySQLProvider{}, p)
+	})
+}
+
 func TestCreateKMS(t *testing.T) {
 	t.Run("fail to open master key store", func(t *testing.T) {
-		localKMS, err := createKMS(&ariesmockstorage.MockStoreProvider{FailNamespace: "masterkey"})
+		localKMS, err := createKMS(&ariesmockstorage.MockStoreProvider{FailNamespace: "masterkey"}, nil)
 
 		require.Nil(t, localKMS)
 		require.EqualError(t, err, "failed to open store for name space masterkey")
@@ -72,7 +118,7 @@ func TestCreateKMS(t *testing.T) {
 		err := masterKeyStore.Put("masterkey", []byte(""))
 		require.NoError(t, err)
 
-		localKMS, err := createKMS(&ariesmockstorage.MockStoreProvider{Store: &masterKeyStore})
+		localKMS, err := createKMS(&ariesmockstorage.MockStoreProvider{Store: &masterKeyStore}, nil)
 		require.EqualError(t, err, "masterKeyReader is empty")
 		require.Nil(t, localKMS)
 	})
@@ -83,7 +129,7 @@ func TestPrepareMasterKeyReader(t *testing.T) {
 		reader, err := prepareMasterKeyReader(
 			&ariesmockstorage.MockStoreProvider{
 				Store: &ariesmockstorage.MockStore{
-					ErrGet: errors.New("testError")}})
+					ErrGet: errors.New("testError")}}, nil)
 		require.Equal(t, errors.New("testError"), err)
 		require.Nil(t, reader)
 	})
@@ -92,11 +138,554 @@ func TestPrepareMasterKeyReader(t *testing.T) {
 			&ariesmockstorage.MockStoreProvider{
 				Store: &ariesmockstorage.MockStore{
 					ErrGet: storage.ErrDataNotFound,
-					ErrPut: errors.New("testError")}})
+					ErrPut: errors.New("testError")}}, nil)
+		require.Equal(t, errors.New("testError"), err)
+		require.Nil(t, reader)
+	})
+}
+
+func TestCreateKMSWithMasterLock(t *testing.T) {
+	masterLock, err := createMasterLock(&kmsParameters{secretLockKey: "secret"})
+	require.NoError(t, err)
+	require.NotNil(t, masterLock)
+
+	t.Run("success - new protected master key", func(t *testing.T) {
+		kmsSecretsProvider := ariesmemstorage.NewProvider()
+
+		localKMS, err := createKMS(kmsSecretsProvider, masterLock)
+		require.NoError(t, err)
+
+		keyID, _, err := localKMS.Create(kms.ED25519Type)
+		require.NoError(t, err)
+
+		// master key must not be stored unprotected
+		masterKeyStore, err := kmsSecretsProvider.OpenStore(masterKeyStoreName)
+		require.NoError(t, err)
+
+		_, err = masterKeyStore.Get(masterKeyDBKeyName)
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		// reopen KMS with the same master lock
+		localKMS, err = createKMS(kmsSecretsProvider, masterLock)
+		require.NoError(t, err)
+
+		_, err = localKMS.Get(keyID)
+		require.NoError(t, err)
+	})
+
+	t.Run("success - migrate unprotected master key", func(t *testing.T) {
+		kmsSecretsProvider := ariesmemstorage.NewProvider()
+
+		localKMS, err := createKMS(kmsSecretsProvider, nil)
+		require.NoError(t, err)
+
+		keyID, _, err := localKMS.Create(kms.ED25519Type)
+		require.NoError(t, err)
+
+		localKMS, err = createKMS(kmsSecretsProvider, masterLock)
+		require.NoError(t, err)
+
+		_, err = localKMS.Get(keyID)
+		require.NoError(t, err)
+
+		masterKeyStore, err := kmsSecretsProvider.OpenStore(masterKeyStoreName)
+		require.NoError(t, err)
+
+		_, err = masterKeyStore.Get(masterKeyDBKeyName)
+		require.True(t, errors.Is(err, storage.ErrDataNotFound))
+
+		_, err = masterKeyStore.Get(masterKeyLockedDBKeyName)
+		require.NoError(t, err)
+	})
+
+	t.Run("error - protected master key and no master lock", func(t *testing.T) {
+		kmsSecretsProvider := ariesmemstorage.NewProvider()
+
+		_, err := createKMS(kmsSecretsProvider, masterLock)
+		require.NoError(t, err)
+
+		_, err = createKMS(kmsSecretsProvider, nil)
+		require.EqualError(t, err, "master key is protected by master lock but master lock key is not configured")
+	})
+
+	t.Run("error - wrong master lock key", func(t *testing.T) {
+		kmsSecretsProvider := ariesmemstorage.NewProvider()
+
+		_, err := createKMS(kmsSecretsProvider, masterLock)
+		require.NoError(t, err)
+
+		otherMasterLock, err := createMasterLock(&kmsParameters{secretLockKey: "other"})
+		require.NoError(t, err)
+
+		_, err = createKMS(kmsSecretsProvider, otherMasterLock)
+		require.Error(t, err)
+	})
+
+	t.Run("error - invalid unprotected master key", func(t *testing.T) {
+		masterKeyStore := &ariesmockstorage.MockStore{Store: map[string][]byte{masterKeyDBKeyName: []byte("!!!")}}
+
+		_, err := prepareMasterKeyReader(&ariesmockstorage.MockStoreProvider{Store: masterKeyStore}, masterLock)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode unprotected master key")
+	})
+
+	t.Run("error - get protected master key", func(t *testing.T) {
+		reader, err := prepareMasterKeyReader(
+			&ariesmockstorage.MockStoreProvider{
+				Store: &ariesmockstorage.MockStore{
+					ErrGet: errors.New("testError")}}, masterLock)
+		require.Equal(t, errors.New("testError"), err)
+		require.Nil(t, reader)
+	})
+
+	t.Run("error - put protected master key", func(t *testing.T) {
+		reader, err := prepareMasterKeyReader(
+			&ariesmockstorage.MockStoreProvider{
+				Store: &ariesmockstorage.MockStore{
+					Store:  make(map[string][]byte),
+					ErrPut: errors.New("testError")}}, masterLock)
 		require.Equal(t, errors.New("testError"), err)
 		require.Nil(t, reader)
 	})
 }
 
+func TestCreateMasterLock(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		masterLock, err := createMasterLock(&kmsParameters{})
+		require.NoError(t, err)
+		require.Nil(t, masterLock)
+	})
+
+	t.Run("success - from file", func(t *testing.T) {
+		file, err := ioutil.TempFile("", "masterlock")
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, os.Remove(file.Name())) }()
+
+		_, err = file.WriteString("secret\n")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		masterLock, err := createMasterLock(&kmsParameters{secretLockKeyPath: file.Name()})
+		require.NoError(t, err)
+		require.NotNil(t, masterLock)
+
+		// master lock from file must match master lock with the same key
+		keyMasterLock, err := createMasterLock(&kmsParameters{secretLockKey: "secret"})
+		require.NoError(t, err)
+
+		encResp, err := masterLock.Encrypt("", &secretlock.EncryptRequest{Plaintext: strings.Repeat("k", 32)})
+		require.NoError(t, err)
+
+		_, err = keyMasterLock.Decrypt("", &secretlock.DecryptRequest{Ciphertext: encResp.Ciphertext})
+		require.NoError(t, err)
+	})
+
+	t.Run("error - file not found", func(t *testing.T) {
+		masterLock, err := createMasterLock(&kmsParameters{secretLockKeyPath: "/invalid/path"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read master lock key file")
+		require.Nil(t, masterLock)
+	})
+
+	t.Run("error - empty file", func(t *testing.T) {
+		file, err := ioutil.TempFile("", "masterlock")
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, os.Remove(file.Name())) }()
+
+		require.NoError(t, file.Close())
+
+		masterLock, err := createMasterLock(&kmsParameters{secretLockKeyPath: file.Name()})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "is empty")
+		require.Nil(t, masterLock)
+	})
+}
+
+func TestCreateKMSAndCrypto(t *testing.T) {
+	t.Run("success - local kms", func(t *testing.T) {
+		km, crypto, err := createKMSAndCrypto(&orbParameters{
+			kmsParams: &kmsParameters{kmsType: kmsTypeLocalOption},
+		}, ariesmemstorage.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, km)
+		require.NotNil(t, crypto)
+	})
+
+	t.Run("success - web kms", func(t *testing.T) {
+		kmsServer := newMockKMSServer()
+		defer kmsServer.Close()
+
+		kmsSecretsProvider := ariesmemstorage.NewProvider()
+
+		parameters := &orbParameters{
+			kmsParams:              &kmsParameters{kmsType: kmsTypeWebOption, kmsEndpoint: kmsServer.URL},
+			anchorCredentialParams: &anchorCredentialParams{issuer: "issuer"},
+		}
+
+		km, crypto, err := createKMSAndCrypto(parameters, kmsSecretsProvider)
+		require.NoError(t, err)
 
+		keyID, kh, err := km.Create(kms.ED25519Type)
+		require.NoError(t, err)
+		require.NotEmpty(t, keyID)
+
+		sig, err := crypto.Sign([]byte("data"), kh)
+		require.NoError(t, err)
 
+		pubKey, err := km.ExportPubKeyBytes(keyID)
+		require.NoError(t, err)
+		require.True(t, ed25519.Verify(pubKey, []byte("data"), sig))
+
+		// keystore URL should be loaded from the store on subsequent calls
+		_, _, err = createKMSAndCrypto(parameters, kmsSecretsProvider)
+		require.NoError(t, err)
+		require.Equal(t, 1, kmsServer.keystoreCount())
+	})
+
+	t.Run("success - web kms with keystore endpoint", func(t *testing.T) {
+		kmsServer := newMockKMSServer()
+		defer kmsServer.Close()
+
+		km, _, err := createKMSAndCrypto(&orbParameters{
+			kmsParams: &kmsParameters{
+				kmsType:          kmsTypeWebOption,
+				kmsStoreEndpoint: kmsServer.URL + "/kms/keystores/ks1",
+			},
+		}, &ariesmockstorage.MockStoreProvider{FailNamespace: keystoreURLStoreName})
+		require.NoError(t, err)
+
+		_, _, err = km.Create(kms.ED25519Type)
+		require.NoError(t, err)
+		require.Equal(t, 0, kmsServer.keystoreCount())
+	})
+
+	t.Run("error - fail to open keystore URL store", func(t *testing.T) {
+		_, _, err := createKMSAndCrypto(&orbParameters{
+			kmsParams: &kmsParameters{kmsType: kmsTypeWebOption, kmsEndpoint: "http://localhost"},
+		}, &ariesmockstorage.MockStoreProvider{FailNamespace: keystoreURLStoreName})
+		require.EqualError(t, err, "failed to open store for name space keystore")
+	})
+
+	t.Run("error - remote kms unavailable", func(t *testing.T) {
+		kmsServer := newMockKMSServer()
+		kmsServer.Close()
+
+		_, _, err := createKMSAndCrypto(&orbParameters{
+			kmsParams:              &kmsParameters{kmsType: kmsTypeWebOption, kmsEndpoint: kmsServer.URL},
+			anchorCredentialParams: &anchorCredentialParams{issuer: "issuer"},
+		}, ariesmemstorage.NewProvider())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create keystore")
+	})
+
+	t.Run("error - keystore URL not returned", func(t *testing.T) {
+		kmsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer kmsServer.Close()
+
+		_, _, err := createKMSAndCrypto(&orbParameters{
+			kmsParams:              &kmsParameters{kmsType: kmsTypeWebOption, kmsEndpoint: kmsServer.URL},
+			anchorCredentialParams: &anchorCredentialParams{issuer: "issuer"},
+		}, ariesmemstorage.NewProvider())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "did not return keystore URL")
+	})
+}
+
+// mockKMSServer is a stand-in for a remote web KMS server that supports ED25519 keys.
+type mockKMSServer struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	keystores int
+	keys      map[string]ed25519.PrivateKey
+}
+
+func newMockKMSServer() *mockKMSServer {
+	m := &mockKMSServer{keys: make(map[string]ed25519.PrivateKey)}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.handle))
+
+	return m
+}
+
+func (m *mockKMSServer) keystoreCount() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.keystores
+}
+
+func (m *mockKMSServer) handle(w http.ResponseWriter, r *http.Request) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	path := r.URL.Path
+
+	switch {
+	case path == "/kms/keystores":
+		m.keystores++
+
+		w.Header().Set("Location", m.URL+"/kms/keystores/ks1")
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasSuffix(path, "/keys"):
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		keyID := base64.RawURLEncoding.EncodeToString(privKey.Public().(ed25519.PublicKey)[:8])
+		m.keys[keyID] = privKey
+
+		w.Header().Set("Location", m.URL+path+"/"+keyID)
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasSuffix(path, "/export"):
+		privKey, ok := m.keys[keyIDFromPath(path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		writeJSON(w, map[string]string{
+			"publicKey": base64.URLEncoding.EncodeToString(privKey.Public().(ed25519.PublicKey)),
+		})
+	case strings.HasSuffix(path, "/sign"):
+		privKey, ok := m.keys[keyIDFromPath(path)]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		req := struct {
+			Message string `json:"message"`
+		}{}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		msg, err := base64.URLEncoding.DecodeString(req.Message)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		writeJSON(w, map[string]string{
+			"signature": base64.URLEncoding.EncodeToString(ed25519.Sign(privKey, msg)),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func keyIDFromPath(path string) string {
+	parts := strings.Split(path, "/")
+
+	return parts[len(parts)-2]
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func TestLocalCASClient(t *testing.T) {
+	t.Run("layered", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:  casTypeLocalOption,
+				peerURLs: []string{"https://orb2.domain.com/cas"},
+			},
+			token: "token",
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+
+		local := localCASClient(casClient)
+		require.IsType(t, &cas.LayeredClient{}, local)
+		require.NotEqual(t, casClient, local)
+	})
+
+	t.Run("not layered", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{casType: casTypeLocalOption},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.Equal(t, casClient, localCASClient(casClient))
+	})
+}
+
+func TestCreateCASClient(t *testing.T) {
+	t.Run("IPFS", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{casType: casTypeIPFSOption, casURL: "localhost:5001"},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.IsType(t, &cas.Client{}, casClient)
+	})
+
+	t.Run("local", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{casType: casTypeLocalOption, cacheEnabled: true},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.IsType(t, &cas.LocalClient{}, casClient)
+	})
+
+	t.Run("layered - IPFS with cache and peers", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:      casTypeIPFSOption,
+				casURL:       "localhost:5001",
+				cacheEnabled: true,
+				peerURLs:     []string{"https://orb2.domain.com/cas"},
+			},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.IsType(t, &cas.LayeredClient{}, casClient)
+	})
+
+	t.Run("layered - local with peers", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:  casTypeLocalOption,
+				peerURLs: []string{"https://orb2.domain.com/cas"},
+			},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.IsType(t, &cas.LayeredClient{}, casClient)
+	})
+
+	t.Run("layered - auth token is sent to peers", func(t *testing.T) {
+		var authHeader string
+
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader = r.Header.Get("Authorization")
+
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer peer.Close()
+
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:  casTypeLocalOption,
+				peerURLs: []string{peer.URL + "/cas"},
+			},
+			token: "token",
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+
+		_, err = casClient.Read("bafkreibvpkpxjm5qp3tjw3dxa4yzdawpmtprqrpjf6rqyjkdhfywj6xpia")
+		require.True(t, errors.Is(err, cas.ErrContentNotFound))
+		require.Equal(t, "Bearer token", authHeader)
+	})
+
+	t.Run("layered - peer fail fast", func(t *testing.T) {
+		failingPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer failingPeer.Close()
+
+		var secondPeerCalled bool
+
+		secondPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secondPeerCalled = true
+
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer secondPeer.Close()
+
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:  casTypeLocalOption,
+				peerURLs: []string{failingPeer.URL + "/cas", secondPeer.URL + "/cas"},
+				peerTier: tierParameters{timeout: time.Second, failFast: true},
+			},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+
+		_, err = casClient.Read("bafkreibvpkpxjm5qp3tjw3dxa4yzdawpmtprqrpjf6rqyjkdhfywj6xpia")
+		require.Error(t, err)
+		require.False(t, errors.Is(err, cas.ErrContentNotFound))
+		require.False(t, secondPeerCalled)
+	})
+
+	t.Run("layered - IPFS with replicas and peers", func(t *testing.T) {
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{
+				casType:     casTypeIPFSOption,
+				casURL:      "localhost:5001",
+				replicaURLs: []string{"localhost:5002"},
+				writeQuorum: 1,
+				peerURLs:    []string{"https://orb2.domain.com/cas"},
+			},
+		}, ariesmemstorage.NewProvider(), nil)
+		require.NoError(t, err)
+		require.IsType(t, &cas.LayeredClient{}, casClient)
+
+		// stops the repair of the replicas
+		stopCASClient(casClient)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		provider := &ariesmockstorage.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")}
+
+		casClient, err := createCASClient(&orbParameters{
+			casParams: &casParameters{casType: casTypeLocalOption},
+		}, provider, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create local CAS")
+		require.Nil(t, casClient)
+
+		casClient, err = createCASClient(&orbParameters{
+			casParams: &casParameters{casType: casTypeIPFSOption, casURL: "localhost:5001", cacheEnabled: true},
+		}, provider, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create CAS cache")
+		require.Nil(t, casClient)
+	})
+}
+
+func TestCurrentMultihashAlgorithms(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		algorithms, err := currentMultihashAlgorithms(mocks.NewMockProtocolClientProvider(), mocks.DefaultNS)()
+		require.NoError(t, err)
+		require.Equal(t, mocks.DefaultMultihashAlgorithms, algorithms)
+	})
+
+	t.Run("error - protocol client", func(t *testing.T) {
+		algorithms, err := currentMultihashAlgorithms(&mockProtocolClientProvider{err: errors.New("namespace error")},
+			mocks.DefaultNS)()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get protocol client for namespace")
+		require.Nil(t, algorithms)
+	})
+
+	t.Run("error - current protocol version", func(t *testing.T) {
+		pcp := &mockProtocolClientProvider{client: &coremocks.MockProtocolClient{Err: errors.New("current error")}}
+
+		algorithms, err := currentMultihashAlgorithms(pcp, mocks.DefaultNS)()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get current protocol version: current error")
+		require.Nil(t, algorithms)
+	})
+}
+
+type mockProtocolClientProvider struct {
+	client protocol.Client
+	err    error
+}
+
+func (m *mockProtocolClientProvider) ForNamespace(string) (protocol.Client, error) {
+	return m.client, m.err
+}
@@ -0,0 +1,257 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package anchorlog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+var logger = log.New("anchor-log")
+
+const (
+	nameSpace = "anchorlog"
+
+	entryKeyFormat = "seq_%020d"
+	cidKeyPrefix   = "cid_"
+	nextKey        = "next"
+)
+
+// ErrNotFound is returned if an anchor log entry is not found.
+var ErrNotFound = errors.New("anchor log entry not found")
+
+// Source indicates how the node learned about an anchor.
+type Source string
+
+const (
+	// SourceWritten means that the anchor was written by this node.
+	SourceWritten Source = "written"
+	// SourceObserved means that the anchor was observed (i.e. processed) by this node.
+	SourceObserved Source = "observed"
+)
+
+// Entry is an anchor in the anchor log.
+type Entry struct {
+	// Sequence is the position of the entry in the log (starting at zero).
+	Sequence uint64 `json:"sequence"`
+	// CID is the CID of the anchor credential.
+	CID string `json:"cid"`
+	// Anchor is the Sidetree anchor string.
+	Anchor string `json:"anchor"`
+	// Namespace is the Sidetree namespace of the anchor.
+	Namespace string `json:"namespace"`
+	// Version is the protocol version (genesis time) of the anchor.
+	Version uint64 `json:"version"`
+	// Source indicates whether the anchor was written or observed by this node.
+	Source Source `json:"source"`
+	// Time is the time at which the entry was added.
+	Time time.Time `json:"time"`
+}
+
+// Store is a persistent, ordered log of the anchors that were written or observed by this node. Each anchor
+// (identified by its CID) is added once and is assigned the next sequence number.
+type Store struct {
+	store storage.Store
+	mutex sync.Mutex
+	next  uint64
+	now   func() time.Time
+}
+
+// New returns a new anchor log.
+func New(provider storage.Provider) (*Store, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open anchor log store: %w", err)
+	}
+
+	s := &Store{store: store, now: time.Now}
+
+	err = s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Append adds the given anchor to the end of the log and returns its sequence number. If the anchor (CID) is
+// already in the log then the sequence number of the existing entry is returned.
+func (s *Store) Append(entry *Entry) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seq, err := s.getSequence(entry.CID)
+	if err == nil {
+		return seq, nil
+	}
+
+	if !errors.Is(err, ErrNotFound) {
+		return 0, err
+	}
+
+	e := *entry
+	e.Sequence = s.next
+	e.Time = s.now()
+
+	entryBytes, err := json.Marshal(&e)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal anchor log entry [%s]: %w", e.CID, err)
+	}
+
+	err = s.put(fmt.Sprintf(entryKeyFormat, e.Sequence), entryBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.index(&e)
+	if err != nil {
+		return 0, err
+	}
+
+	s.next++
+
+	// if the counter isn't updated then the entry is found when the log is loaded
+	err = s.putUint(nextKey, s.next)
+	if err != nil {
+		logger.Warnf("Failed to update anchor log counter: %s", err)
+	}
+
+	return e.Sequence, nil
+}
+
+// Get returns the entry with the given sequence number.
+func (s *Store) Get(seq uint64) (*Entry, error) {
+	entryBytes, err := s.store.Get(fmt.Sprintf(entryKeyFormat, seq))
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get anchor log entry [%d]: %w", seq, err)
+	}
+
+	entry := &Entry{}
+
+	err = json.Unmarshal(entryBytes, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal anchor log entry [%d]: %w", seq, err)
+	}
+
+	return entry, nil
+}
+
+// List returns up to limit entries, starting with the entry with the given sequence number.
+func (s *Store) List(from uint64, limit int) ([]*Entry, error) {
+	last := s.Len()
+
+	var entries []*Entry
+
+	for seq := from; seq < last && len(entries) < limit; seq++ {
+		entry, err := s.Get(seq)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Len returns the number of entries in the log.
+func (s *Store) Len() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.next
+}
+
+// load loads the sequence number of the next entry. Entries that were added after the counter was last updated
+// (e.g. before a crash) are indexed again.
+func (s *Store) load() error {
+	next, err := s.getUint(nextKey)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to get anchor log counter: %w", err)
+	}
+
+	s.next = next
+
+	for {
+		entry, e := s.Get(s.next)
+		if e != nil {
+			if errors.Is(e, ErrNotFound) {
+				return nil
+			}
+
+			return e
+		}
+
+		e = s.index(entry)
+		if e != nil {
+			return e
+		}
+
+		s.next++
+	}
+}
+
+func (s *Store) index(entry *Entry) error {
+	return s.putUint(cidKeyPrefix+entry.CID, entry.Sequence)
+}
+
+func (s *Store) getSequence(cid string) (uint64, error) {
+	seq, err := s.getUint(cidKeyPrefix + cid)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return 0, fmt.Errorf("failed to get anchor log entry for CID [%s]: %w", cid, err)
+	}
+
+	return seq, err
+}
+
+func (s *Store) getUint(key string) (uint64, error) {
+	valueBytes, err := s.store.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return 0, ErrNotFound
+		}
+
+		return 0, err
+	}
+
+	var value uint64
+
+	err = json.Unmarshal(valueBytes, &value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value [%s]: %w", valueBytes, err)
+	}
+
+	return value, nil
+}
+
+func (s *Store) putUint(key string, value uint64) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal [%s]: %w", key, err)
+	}
+
+	return s.put(key, valueBytes)
+}
+
+func (s *Store) put(key string, value []byte) error {
+	err := s.store.Put(key, value)
+	if err != nil {
+		return fmt.Errorf("failed to store anchor log [%s]: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package anchorlog
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cid1 = "bafkreiarkubvukdidicmqynkyls3iqawdqvthi7e6mbky2amuw3inxsi3y"
+	cid2 = "bafkreibmrmenuxhgaomod2luojctmz7ngwuz7sgafhevnhbx3hhtecqs3e"
+	cid3 = "bafkreihwsnuregceqh263vgdathcprnbvatyat6h6mu7ipjhhodcdbyhoy"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+		require.Equal(t, uint64(0), s.Len())
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		s, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open anchor log store: open error")
+		require.Nil(t, s)
+	})
+
+	t.Run("error - get counter", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		s, err := New(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get anchor log counter: get error")
+		require.Nil(t, s)
+	})
+
+	t.Run("error - invalid counter", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[nextKey] = []byte("invalid")
+
+		s, err := New(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid value [invalid]")
+		require.Nil(t, s)
+	})
+
+	t.Run("error - invalid entry", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[fmt.Sprintf(entryKeyFormat, 0)] = []byte("invalid")
+
+		s, err := New(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal anchor log entry [0]")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		seq, err := s.Append(&Entry{CID: cid1, Anchor: "1.anchor", Namespace: "did:orb", Version: 1})
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), seq)
+
+		seq, err = s.Append(&Entry{CID: cid2, Anchor: "2.anchor", Source: SourceObserved})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), seq)
+
+		// the same anchor isn't added again
+		seq, err = s.Append(&Entry{CID: cid1, Anchor: "1.anchor", Source: SourceObserved})
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), seq)
+
+		seq, err = s.Append(&Entry{CID: cid3, Anchor: "3.anchor", Source: SourceWritten})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), seq)
+
+		require.Equal(t, uint64(3), s.Len())
+
+		entry, err := s.Get(0)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), entry.Sequence)
+		require.Equal(t, cid1, entry.CID)
+		require.Equal(t, "1.anchor", entry.Anchor)
+		require.Equal(t, "did:orb", entry.Namespace)
+		require.Equal(t, uint64(1), entry.Version)
+		require.False(t, entry.Time.IsZero())
+
+		entries, err := s.List(0, 2)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, cid1, entries[0].CID)
+		require.Equal(t, cid2, entries[1].CID)
+
+		entries, err = s.List(2, 2)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, cid3, entries[0].CID)
+
+		entries, err = s.List(3, 2)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		entry, err = s.Get(3)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, entry)
+	})
+
+	t.Run("success - reload", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Append(&Entry{CID: cid1})
+		require.NoError(t, err)
+
+		_, err = s.Append(&Entry{CID: cid2})
+		require.NoError(t, err)
+
+		s, err = New(provider)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), s.Len())
+
+		seq, err := s.Append(&Entry{CID: cid3})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), seq)
+	})
+
+	t.Run("success - entry added after the counter", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Append(&Entry{CID: cid1})
+		require.NoError(t, err)
+
+		// simulate a crash after the entry was stored but before it was indexed and the counter was updated
+		entryBytes := provider.Store.Store[fmt.Sprintf(entryKeyFormat, 0)]
+		provider.Store.Store = map[string][]byte{fmt.Sprintf(entryKeyFormat, 0): entryBytes}
+
+		s, err = New(provider)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), s.Len())
+
+		seq, err := s.Append(&Entry{CID: cid1})
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), seq)
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Append(&Entry{CID: cid1})
+		require.NoError(t, err)
+
+		provider.Store.ErrGet = errors.New("get error")
+
+		_, err = s.Append(&Entry{CID: cid2})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get anchor log entry for CID")
+
+		entries, err := s.List(0, 10)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get anchor log entry [0]: get error")
+		require.Nil(t, entries)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Append(&Entry{CID: cid1})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to store anchor log")
+		require.Equal(t, uint64(0), s.Len())
+	})
+}
@@ -0,0 +1,161 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package anchorlogresthandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+)
+
+var logger = log.New("anchor-log-handler")
+
+const (
+	// Path is the path of the anchor log endpoint.
+	Path = "/anchorlog"
+
+	fromParam  = "from"
+	limitParam = "limit"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	jsonContentType = "application/json"
+)
+
+type anchorLog interface {
+	List(from uint64, limit int) ([]*anchorlog.Entry, error)
+	Len() uint64
+}
+
+// Page is a page of the anchor log.
+type Page struct {
+	// Entries contains the entries of the page (in order).
+	Entries []*anchorlog.Entry `json:"entries"`
+	// Next is the sequence number from which to request the next page.
+	Next uint64 `json:"next"`
+	// Total is the total number of entries in the log.
+	Total uint64 `json:"total"`
+}
+
+// Handler serves the anchor log as a paginated feed. The page starts at the entry with the sequence number given
+// by the "from" query parameter (defaults to 0) and contains up to "limit" entries (defaults to 100, max 1000).
+// A consumer catches up by requesting pages (from the "next" sequence number of the previous page) until a page
+// is empty.
+type Handler struct {
+	log anchorLog
+}
+
+// New returns a new anchor log handler.
+func New(l anchorLog) *Handler {
+	return &Handler{log: l}
+}
+
+// Path returns the HTTP REST endpoint for the anchor log handler.
+func (h *Handler) Path() string {
+	return Path
+}
+
+// Method returns the HTTP REST method for the anchor log handler.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the anchor log handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, req *http.Request) {
+	from, limit, err := getPageParams(req)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+		return
+	}
+
+	total := h.log.Len()
+
+	entries, err := h.log.List(from, limit)
+	if err != nil {
+		logger.Errorf("Error retrieving anchor log from [%d]: %s", from, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	page := &Page{
+		Entries: []*anchorlog.Entry{},
+		Next:    from,
+		Total:   total,
+	}
+
+	if len(entries) > 0 {
+		page.Entries = entries
+		page.Next = entries[len(entries)-1].Sequence + 1
+	}
+
+	pageBytes, err := json.Marshal(page)
+	if err != nil {
+		logger.Errorf("Error marshalling anchor log page: %s", err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	writeResponse(w, http.StatusOK, jsonContentType, pageBytes)
+}
+
+func getPageParams(req *http.Request) (uint64, int, error) {
+	var from uint64
+
+	limit := defaultLimit
+
+	if fromStr := req.URL.Query().Get(fromParam); fromStr != "" {
+		value, err := strconv.Atoi(fromStr)
+		if err != nil || value < 0 {
+			return 0, 0, fmt.Errorf("invalid value for %s [%s]", fromParam, fromStr)
+		}
+
+		from = uint64(value)
+	}
+
+	if limitStr := req.URL.Query().Get(limitParam); limitStr != "" {
+		var err error
+
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid value for %s [%s]", limitParam, limitStr)
+		}
+
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+	}
+
+	return from, limit, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package anchorlogresthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+)
+
+func TestHandler(t *testing.T) {
+	anchorLog, err := anchorlog.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = anchorLog.Append(&anchorlog.Entry{CID: fmt.Sprintf("cid%d", i), Anchor: fmt.Sprintf("%d.anchor", i)})
+		require.NoError(t, err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		h := New(anchorLog)
+		require.Equal(t, Path, h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, "")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		page := readPage(t, rw)
+		require.Len(t, page.Entries, 5)
+		require.Equal(t, "cid0", page.Entries[0].CID)
+		require.Equal(t, uint64(5), page.Next)
+		require.Equal(t, uint64(5), page.Total)
+	})
+
+	t.Run("success - pages", func(t *testing.T) {
+		h := New(anchorLog)
+
+		page := readPage(t, serve(h, "?limit=2"))
+		require.Len(t, page.Entries, 2)
+		require.Equal(t, "cid1", page.Entries[1].CID)
+		require.Equal(t, uint64(2), page.Next)
+
+		page = readPage(t, serve(h, fmt.Sprintf("?from=%d&limit=2", page.Next)))
+		require.Len(t, page.Entries, 2)
+		require.Equal(t, "cid2", page.Entries[0].CID)
+		require.Equal(t, uint64(4), page.Next)
+
+		page = readPage(t, serve(h, fmt.Sprintf("?from=%d&limit=2", page.Next)))
+		require.Len(t, page.Entries, 1)
+		require.Equal(t, "cid4", page.Entries[0].CID)
+		require.Equal(t, uint64(5), page.Next)
+
+		// caught up
+		page = readPage(t, serve(h, fmt.Sprintf("?from=%d&limit=2", page.Next)))
+		require.NotNil(t, page.Entries)
+		require.Empty(t, page.Entries)
+		require.Equal(t, uint64(5), page.Next)
+		require.Equal(t, uint64(5), page.Total)
+	})
+
+	t.Run("success - limit is capped", func(t *testing.T) {
+		page := readPage(t, serve(New(anchorLog), "?limit=5000"))
+		require.Len(t, page.Entries, 5)
+	})
+
+	t.Run("error - invalid from", func(t *testing.T) {
+		rw := serve(New(anchorLog), "?from=-1")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Equal(t, "invalid value for from [-1]", string(readBody(t, rw)))
+	})
+
+	t.Run("error - invalid limit", func(t *testing.T) {
+		rw := serve(New(anchorLog), "?limit=0")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Equal(t, "invalid value for limit [0]", string(readBody(t, rw)))
+	})
+
+	t.Run("error - anchor log error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		errLog, err := anchorlog.New(provider)
+		require.NoError(t, err)
+
+		_, err = errLog.Append(&anchorlog.Entry{CID: "cid"})
+		require.NoError(t, err)
+
+		provider.Store.ErrGet = errors.New("get error")
+
+		rw := serve(New(errLog), "")
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), string(readBody(t, rw)))
+	})
+}
+
+func serve(h *Handler, query string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(h.Method(), Path+query, nil))
+
+	return rw
+}
+
+func readPage(t *testing.T, rw *httptest.ResponseRecorder) *Page {
+	t.Helper()
+
+	require.Equal(t, http.StatusOK, rw.Code)
+
+	page := &Page{}
+	require.NoError(t, json.Unmarshal(readBody(t, rw), page))
+
+	return page
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return body
+}
@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package anchortest provides an anchor graph fixture for the tests of the packages that walk the anchor graph.
+package anchortest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/stretchr/testify/require"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/compression"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprovider/models"
+
+	"github.com/trustbloc/orb/pkg/anchor/graph"
+	"github.com/trustbloc/orb/pkg/anchor/txn"
+)
+
+// DID is the DID for which the second anchor of the fixture references the first anchor.
+const DID = "did:method:abc"
+
+// Fixture is an anchor graph with two anchors where the first anchor references all types of batch files and
+// the second anchor (which references the first anchor) only has a core index file.
+type Fixture struct {
+	CAS      casapi.Client
+	TxnGraph *graph.Graph
+	Anchor1  string
+	// Anchor1Files contains the first anchor and the batch files that it references.
+	Anchor1Files []string
+	Anchor2      string
+}
+
+// NewFixture creates the anchor graph of the fixture in the given CAS.
+func NewFixture(t *testing.T, casClient casapi.Client) *Fixture {
+	t.Helper()
+
+	f := &Fixture{
+		CAS:      casClient,
+		TxnGraph: graph.New(casClient, PubKeyFetcher),
+	}
+
+	chunk := WriteFile(t, casClient, &models.ChunkFile{})
+	provisionalProof := WriteFile(t, casClient, &models.ProvisionalProofFile{
+		Operations: models.ProvisionalProofOperations{Update: []string{"update"}},
+	})
+	provisionalIndex := WriteFile(t, casClient, &models.ProvisionalIndexFile{
+		ProvisionalProofFileURI: provisionalProof,
+		Chunks:                  []models.Chunk{{ChunkFileURI: chunk}},
+	})
+	coreProof := WriteFile(t, casClient, &models.CoreProofFile{
+		Operations: models.CoreProofOperations{Recover: []string{"recover"}},
+	})
+	coreIndex := WriteFile(t, casClient, &models.CoreIndexFile{
+		CoreProofFileURI:        coreProof,
+		ProvisionalIndexFileURI: provisionalIndex,
+	})
+
+	f.Anchor1 = AddAnchor(t, f.TxnGraph, "1."+coreIndex, nil)
+	f.Anchor1Files = []string{f.Anchor1, coreIndex, coreProof, provisionalIndex, provisionalProof, chunk}
+
+	f.Anchor2 = AddAnchor(t, f.TxnGraph, "1."+WriteFile(t, casClient, &models.CoreIndexFile{}),
+		map[string]string{DID: f.Anchor1})
+
+	return f
+}
+
+// WriteFile writes the given batch file model (JSON encoded and compressed) to CAS and returns its CID.
+func WriteFile(t *testing.T, c casapi.Client, model interface{}) string {
+	t.Helper()
+
+	content, err := json.Marshal(model)
+	require.NoError(t, err)
+
+	compressed, err := compression.New(compression.WithDefaultAlgorithms()).Compress("GZIP", content)
+	require.NoError(t, err)
+
+	id, err := c.Write(compressed)
+	require.NoError(t, err)
+
+	return id
+}
+
+// AddAnchor adds an anchor credential with the given anchor string and previous anchors to the anchor graph
+// and returns its CID.
+func AddAnchor(t *testing.T, txnGraph *graph.Graph, anchorString string, previous map[string]string) string {
+	t.Helper()
+
+	vc := &verifiable.Credential{
+		Types:   []string{"VerifiableCredential"},
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		Subject: &txn.Payload{
+			AnchorString:         anchorString,
+			Namespace:            mocks.DefaultNS,
+			PreviousTransactions: previous,
+		},
+		Issuer: verifiable.Issuer{ID: "http://orb.domain.com"},
+		Issued: &util.TimeWithTrailingZeroMsec{Time: time.Now()},
+	}
+
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	id, err := txnGraph.Add(context.Background(), vcBytes)
+	require.NoError(t, err)
+
+	return id
+}
+
+// PubKeyFetcher is the public key fetcher of the anchor graph. It's not expected to be called since the
+// anchor credentials of the fixture aren't signed.
+func PubKeyFetcher(issuerID, keyID string) (*verifier.PublicKey, error) {
+	return nil, fmt.Errorf("not expected")
+}
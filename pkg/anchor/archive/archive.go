@@ -0,0 +1,199 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/ipfs/go-cid"
+	"github.com/trustbloc/edge-core/pkg/log"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+
+	"github.com/trustbloc/orb/pkg/anchor/walker"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-archive")
+
+type txnGraph interface {
+	Read(ctx context.Context, cid string) (*verifiable.Credential, error)
+}
+
+// nodeReader is implemented by CAS clients that are able to read IPLD (dag-json) nodes.
+type nodeReader interface {
+	ReadNode(ctx context.Context, cid string) ([]byte, error)
+}
+
+// Providers contains the providers required by the exporter.
+type Providers struct {
+	CAS                    casapi.Client
+	TxnGraph               txnGraph
+	ProtocolClientProvider protocol.ClientProvider
+}
+
+// Exporter exports the anchor graph to a CARv1 archive.
+type Exporter struct {
+	cas    casapi.Client
+	walker *walker.Walker
+}
+
+// ExportResult contains the number of anchors, batch files and blocks that were exported.
+type ExportResult struct {
+	Anchors    int `json:"anchors"`
+	BatchFiles int `json:"batchFiles"`
+	Blocks     int `json:"blocks"`
+}
+
+// NewExporter returns a new anchor graph exporter.
+func NewExporter(providers *Providers) *Exporter {
+	return &Exporter{
+		cas: providers.CAS,
+		walker: walker.New(&walker.Providers{
+			CAS:                    providers.CAS,
+			TxnGraph:               providers.TxnGraph,
+			ProtocolClientProvider: providers.ProtocolClientProvider,
+		}),
+	}
+}
+
+// Export writes every anchor that is reachable from the given head anchors to the given writer as a CARv1
+// archive (with the head anchors as roots). If includeBatchFiles is true then the Sidetree batch files that
+// are referenced by the anchors are also exported. The export fails if any of the anchors (or batch files)
+// can't be read from CAS. An error that wraps cas.ErrInvalidCID is returned if a head CID is invalid.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, heads []string,
+	includeBatchFiles bool) (*ExportResult, error) {
+	roots, err := e.roots(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+
+	car, err := cas.NewCARWriter(w, roots)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExportResult{}
+	written := make(map[string]bool)
+
+	err = e.walker.Walk(ctx, heads, func(items []*walker.Item) error {
+		for _, item := range items {
+			if item.Type != walker.TypeAnchor && !includeBatchFiles {
+				continue
+			}
+
+			n, err := e.export(ctx, car, item, written)
+			if err != nil {
+				return err
+			}
+
+			if item.Type == walker.TypeAnchor {
+				result.Anchors++
+			} else {
+				result.BatchFiles++
+			}
+
+			result.Blocks += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export anchor graph: %w", err)
+	}
+
+	logger.Infof("exported %d anchors and %d batch files (%d blocks) from heads %s",
+		result.Anchors, result.BatchFiles, result.Blocks, heads)
+
+	return result, nil
+}
+
+// roots returns the CIDs of the root blocks of the given head anchors.
+func (e *Exporter) roots(ctx context.Context, heads []string) ([]cid.Cid, error) {
+	var roots []cid.Cid
+
+	for _, head := range heads {
+		err := cas.ValidateCID(head)
+		if err != nil {
+			return nil, err
+		}
+
+		blocks, err := e.blocks(ctx, head, walker.TypeAnchor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read head anchor [%s]: %w", head, err)
+		}
+
+		// the root block of a file DAG is the last block
+		roots = append(roots, blocks[len(blocks)-1].CID)
+	}
+
+	return roots, nil
+}
+
+// export writes the blocks of the given item (that haven't been written yet) to the archive
+// and returns the number of blocks that were written.
+func (e *Exporter) export(ctx context.Context, car *cas.CARWriter, item *walker.Item,
+	written map[string]bool) (int, error) {
+	if item.Error != "" {
+		return 0, fmt.Errorf("%s [%s] of anchor [%s]: %s", item.Type, item.CID, item.Anchor, item.Error)
+	}
+
+	if written[item.CID] {
+		return 0, nil
+	}
+
+	written[item.CID] = true
+
+	blocks, err := e.blocks(ctx, item.CID, item.Type)
+	if err != nil {
+		return 0, fmt.Errorf("%s [%s]: %w", item.Type, item.CID, err)
+	}
+
+	n := 0
+
+	for _, b := range blocks {
+		if written[b.CID.KeyString()] {
+			continue
+		}
+
+		err = car.WriteBlock(b)
+		if err != nil {
+			return 0, err
+		}
+
+		written[b.CID.KeyString()] = true
+		n++
+	}
+
+	return n, nil
+}
+
+func (e *Exporter) blocks(ctx context.Context, id, itemType string) ([]*cas.Block, error) {
+	var content []byte
+
+	var err error
+
+	if itemType == walker.TypeAnchor && cas.IsDAGJSON(id) {
+		nr, ok := e.cas.(nodeReader)
+		if !ok {
+			return nil, fmt.Errorf("CAS client does not support reading IPLD anchor node [%s]", id)
+		}
+
+		content, err = nr.ReadNode(ctx, id)
+	} else {
+		content, err = cas.ReadWithContext(ctx, e.cas, id)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cas.Blocks(id, content)
+}
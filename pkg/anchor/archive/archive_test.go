@@ -0,0 +1,185 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprovider/models"
+
+	"github.com/trustbloc/orb/pkg/anchor/anchortest"
+	"github.com/trustbloc/orb/pkg/anchor/graph"
+	"github.com/trustbloc/orb/pkg/anchor/walker"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+func TestExporter_Export(t *testing.T) {
+	t.Run("success - anchors and batch files", func(t *testing.T) {
+		f := newFixture(t)
+
+		buf := &bytes.Buffer{}
+
+		result, err := f.exporter.Export(context.Background(), buf, []string{f.Anchor2}, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Anchors)
+		require.Equal(t, 6, result.BatchFiles)
+		require.Equal(t, 8, result.Blocks)
+
+		target := newLocal(t)
+
+		imported, err := cas.ImportCAR(context.Background(), target, buf)
+		require.NoError(t, err)
+		require.Equal(t, []string{f.Anchor2}, imported.Roots)
+		require.Len(t, imported.CIDs, 8)
+
+		// the anchor graph can be walked (and verified) in the target CAS
+		w := walker.New(&walker.Providers{
+			CAS:                    target,
+			TxnGraph:               graph.New(target, anchortest.PubKeyFetcher),
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider(),
+		})
+
+		var items []*walker.Item
+
+		err = w.Walk(context.Background(), []string{f.Anchor2}, func(anchorItems []*walker.Item) error {
+			items = append(items, anchorItems...)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 8)
+
+		for _, item := range items {
+			require.True(t, item.Verified, item.CID)
+		}
+	})
+
+	t.Run("success - anchors only", func(t *testing.T) {
+		f := newFixture(t)
+
+		buf := &bytes.Buffer{}
+
+		result, err := f.exporter.Export(context.Background(), buf, []string{f.Anchor2, f.Anchor1}, false)
+		require.NoError(t, err)
+		require.Equal(t, 2, result.Anchors)
+		require.Zero(t, result.BatchFiles)
+		require.Equal(t, 2, result.Blocks)
+
+		reader, err := cas.NewCARReader(buf)
+		require.NoError(t, err)
+		require.Len(t, reader.Roots, 2)
+		require.Equal(t, f.Anchor2, reader.Roots[0].String())
+		require.Equal(t, f.Anchor1, reader.Roots[1].String())
+	})
+
+	t.Run("success - legacy anchors", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+		txnGraph := graph.New(casClient, anchortest.PubKeyFetcher)
+
+		anchorCID := anchortest.AddAnchor(t, txnGraph, "1."+anchortest.WriteFile(t, casClient, &models.CoreIndexFile{}), nil)
+
+		exporter := NewExporter(&Providers{
+			CAS:                    casClient,
+			TxnGraph:               txnGraph,
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider(),
+		})
+
+		buf := &bytes.Buffer{}
+
+		result, err := exporter.Export(context.Background(), buf, []string{anchorCID}, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, result.Anchors)
+		require.Equal(t, 1, result.BatchFiles)
+
+		imported, err := cas.ImportCAR(context.Background(), newLocal(t), buf)
+		require.NoError(t, err)
+		require.Len(t, imported.Roots, 1)
+		require.Len(t, imported.CIDs, 2)
+	})
+
+	t.Run("error - invalid head CID", func(t *testing.T) {
+		f := newFixture(t)
+
+		result, err := f.exporter.Export(context.Background(), &bytes.Buffer{}, []string{"invalid"}, true)
+		require.True(t, errors.Is(err, cas.ErrInvalidCID))
+		require.Nil(t, result)
+	})
+
+	t.Run("error - head anchor not found", func(t *testing.T) {
+		f := newFixture(t)
+
+		result, err := f.exporter.Export(context.Background(), &bytes.Buffer{},
+			[]string{"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"}, true)
+		require.True(t, errors.Is(err, cas.ErrContentNotFound))
+		require.Contains(t, err.Error(), "failed to read head anchor")
+		require.Nil(t, result)
+	})
+
+	t.Run("error - missing batch file", func(t *testing.T) {
+		f := newFixture(t)
+
+		anchorCID := anchortest.AddAnchor(t, f.TxnGraph, "1.Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+			map[string]string{anchortest.DID: f.Anchor2})
+
+		result, err := f.exporter.Export(context.Background(), &bytes.Buffer{}, []string{anchorCID}, true)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to export anchor graph: coreIndex")
+		require.Nil(t, result)
+
+		// batch files aren't read if they aren't exported
+		result, err = f.exporter.Export(context.Background(), &bytes.Buffer{}, []string{anchorCID}, false)
+		require.NoError(t, err)
+		require.Equal(t, 3, result.Anchors)
+	})
+
+	t.Run("error - context cancelled", func(t *testing.T) {
+		f := newFixture(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		result, err := f.exporter.Export(ctx, &bytes.Buffer{}, []string{f.Anchor2}, true)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, result)
+	})
+}
+
+type fixture struct {
+	*anchortest.Fixture
+	exporter *Exporter
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	casClient := newLocal(t)
+	f := anchortest.NewFixture(t, casClient)
+
+	return &fixture{
+		Fixture: f,
+		exporter: NewExporter(&Providers{
+			CAS:                    casClient,
+			TxnGraph:               f.TxnGraph,
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider(),
+		}),
+	}
+}
+
+func newLocal(t *testing.T) *cas.LocalClient {
+	t.Helper()
+
+	c, err := cas.NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	return c
+}
@@ -0,0 +1,264 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package archiveresthandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/archive"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-archive-handler")
+
+const (
+	// Path is the path of the anchor archive endpoint.
+	Path = "/archive"
+
+	headParam       = "head"
+	batchFilesParam = "batchfiles"
+
+	carContentType  = "application/vnd.ipld.car"
+	jsonContentType = "application/json"
+
+	defaultMaxArchiveSize = 100 * 1024 * 1024
+)
+
+// errArchiveTooLarge is returned if an exported or imported archive exceeds the maximum archive size.
+var errArchiveTooLarge = errors.New("archive too large")
+
+type options struct {
+	maxArchiveSize int64
+}
+
+// Opt is an archive handler option.
+type Opt func(opts *options)
+
+// WithMaxArchiveSize sets the maximum size (in bytes) of an exported or imported archive. A request
+// that exceeds the maximum size fails with status 413 (Request Entity Too Large). Defaults to 100MB.
+func WithMaxArchiveSize(size int64) Opt {
+	return func(opts *options) {
+		opts.maxArchiveSize = size
+	}
+}
+
+func resolveOptions(opts []Opt) *options {
+	options := &options{maxArchiveSize: defaultMaxArchiveSize}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+type exporter interface {
+	Export(ctx context.Context, w io.Writer, heads []string, includeBatchFiles bool) (*archive.ExportResult, error)
+}
+
+// ExportHandler exports the anchor graph that starts at the given head anchors as a CARv1 archive, for example:
+// GET /archive?head=<cid>&head=<cid>&batchfiles=true.
+type ExportHandler struct {
+	exporter       exporter
+	maxArchiveSize int64
+}
+
+// NewExportHandler returns a new anchor graph export handler.
+func NewExportHandler(e exporter, opts ...Opt) *ExportHandler {
+	return &ExportHandler{
+		exporter:       e,
+		maxArchiveSize: resolveOptions(opts).maxArchiveSize,
+	}
+}
+
+// Path returns the HTTP REST endpoint for the export handler.
+func (h *ExportHandler) Path() string {
+	return Path
+}
+
+// Method returns the HTTP REST method for the export handler.
+func (h *ExportHandler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the export handler.
+func (h *ExportHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *ExportHandler) handle(w http.ResponseWriter, req *http.Request) {
+	heads := req.URL.Query()[headParam]
+	if len(heads) == 0 {
+		writeResponse(w, http.StatusBadRequest, "", []byte(fmt.Sprintf("at least one %s must be specified", headParam)))
+
+		return
+	}
+
+	includeBatchFiles := false
+
+	if value := req.URL.Query().Get(batchFilesParam); value != "" {
+		var err error
+
+		includeBatchFiles, err = strconv.ParseBool(value)
+		if err != nil {
+			writeResponse(w, http.StatusBadRequest, "", []byte(fmt.Sprintf("invalid %s: %s", batchFilesParam, value)))
+
+			return
+		}
+	}
+
+	// The archive is buffered so that an error status may be returned if the export fails. The size of the
+	// buffer is limited so that a large anchor graph can't exhaust memory.
+	buf := &limitedBuffer{maxSize: h.maxArchiveSize}
+
+	result, err := h.exporter.Export(req.Context(), buf, heads, includeBatchFiles)
+	if err != nil {
+		if errors.Is(err, cas.ErrInvalidCID) {
+			writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+			return
+		}
+
+		if errors.Is(err, errArchiveTooLarge) {
+			writeResponse(w, http.StatusRequestEntityTooLarge, "",
+				[]byte(fmt.Sprintf("archive exceeds the maximum size of %d bytes", h.maxArchiveSize)))
+
+			return
+		}
+
+		logger.Errorf("Error exporting anchor graph from %s: %s", heads, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	logger.Debugf("Exported anchor graph from %s: %+v", heads, result)
+
+	writeResponse(w, http.StatusOK, carContentType, buf.Bytes())
+}
+
+// ImportHandler imports the blocks of a CARv1 archive (in the request body) into CAS
+// and responds with the CIDs of the imported content.
+type ImportHandler struct {
+	cas            casapi.Client
+	maxArchiveSize int64
+}
+
+// NewImportHandler returns a new archive import handler.
+func NewImportHandler(c casapi.Client, opts ...Opt) *ImportHandler {
+	return &ImportHandler{
+		cas:            c,
+		maxArchiveSize: resolveOptions(opts).maxArchiveSize,
+	}
+}
+
+// Path returns the HTTP REST endpoint for the import handler.
+func (h *ImportHandler) Path() string {
+	return Path
+}
+
+// Method returns the HTTP REST method for the import handler.
+func (h *ImportHandler) Method() string {
+	return http.MethodPost
+}
+
+// Handler returns the HTTP REST handler for the import handler.
+func (h *ImportHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *ImportHandler) handle(w http.ResponseWriter, req *http.Request) {
+	body := &countingReader{r: http.MaxBytesReader(w, req.Body, h.maxArchiveSize)}
+
+	result, err := cas.ImportCAR(req.Context(), h.cas, body)
+	if err != nil {
+		// MaxBytesReader fails the read once the limit is reached.
+		if body.n >= h.maxArchiveSize {
+			writeResponse(w, http.StatusRequestEntityTooLarge, "",
+				[]byte(fmt.Sprintf("archive exceeds the maximum size of %d bytes", h.maxArchiveSize)))
+
+			return
+		}
+
+		if errors.Is(err, cas.ErrInvalidCAR) || errors.Is(err, cas.ErrContentMismatch) {
+			writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+			return
+		}
+
+		logger.Errorf("Error importing archive: %s", err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	resultBytes, err := json.Marshal(result)
+	if err != nil {
+		logger.Errorf("Error marshalling import result: %s", err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	logger.Infof("Imported archive with roots %s (%d CIDs)", result.Roots, len(result.CIDs))
+
+	writeResponse(w, http.StatusOK, jsonContentType, resultBytes)
+}
+
+// limitedBuffer is a buffer that fails with errArchiveTooLarge if more than the maximum size is written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	maxSize int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if int64(b.Len()+len(p)) > b.maxSize {
+		return 0, errArchiveTooLarge
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// countingReader counts the bytes that are read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+
+	r.n += int64(n)
+
+	return n, err
+}
+
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package archiveresthandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/archive"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+const headCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestExportHandler(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		e := &mockExporter{}
+
+		h := NewExportHandler(e)
+		require.Equal(t, Path, h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, http.MethodGet, "/archive?head="+headCID+"&head=cid2&batchfiles=true", nil)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, carContentType, rw.Header().Get("Content-Type"))
+		require.Equal(t, "archive", string(readBody(t, rw)))
+		require.Equal(t, []string{headCID, "cid2"}, e.heads)
+		require.True(t, e.includeBatchFiles)
+	})
+
+	t.Run("success - without batch files", func(t *testing.T) {
+		e := &mockExporter{}
+
+		rw := serve(NewExportHandler(e), http.MethodGet, "/archive?head="+headCID, nil)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.False(t, e.includeBatchFiles)
+	})
+
+	t.Run("error - no heads", func(t *testing.T) {
+		rw := serve(NewExportHandler(&mockExporter{}), http.MethodGet, "/archive", nil)
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, string(readBody(t, rw)), "at least one head must be specified")
+	})
+
+	t.Run("error - invalid batchfiles", func(t *testing.T) {
+		rw := serve(NewExportHandler(&mockExporter{}), http.MethodGet, "/archive?head="+headCID+"&batchfiles=xxx", nil)
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, string(readBody(t, rw)), "invalid batchfiles")
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		e := &mockExporter{err: fmt.Errorf("invalid head: %w", cas.ErrInvalidCID)}
+
+		rw := serve(NewExportHandler(e), http.MethodGet, "/archive?head=invalid", nil)
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("error - archive too large", func(t *testing.T) {
+		rw := serve(NewExportHandler(&mockExporter{}, WithMaxArchiveSize(5)), http.MethodGet,
+			"/archive?head="+headCID, nil)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		require.Contains(t, string(readBody(t, rw)), "archive exceeds the maximum size of 5 bytes")
+	})
+
+	t.Run("error - export error", func(t *testing.T) {
+		e := &mockExporter{err: errors.New("export error")}
+
+		rw := serve(NewExportHandler(e), http.MethodGet, "/archive?head="+headCID, nil)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), string(readBody(t, rw)))
+	})
+}
+
+func TestImportHandler(t *testing.T) {
+	blocks, err := cas.Blocks(headCID, []byte("hello world"))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+
+	w, err := cas.NewCARWriter(buf, []cid.Cid{blocks[0].CID})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteBlock(blocks[0]))
+
+	car := buf.Bytes()
+
+	t.Run("success", func(t *testing.T) {
+		c, err := cas.NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+
+		h := NewImportHandler(c)
+		require.Equal(t, Path, h.Path())
+		require.Equal(t, http.MethodPost, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, http.MethodPost, "/archive", car)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		result := &cas.CARImport{}
+		require.NoError(t, json.Unmarshal(readBody(t, rw), result))
+		require.Equal(t, []string{headCID}, result.Roots)
+		require.Equal(t, []string{headCID}, result.CIDs)
+
+		content, err := c.Read(headCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("error - invalid archive", func(t *testing.T) {
+		rw := serve(NewImportHandler(mocks.NewMockCasClient(nil)), http.MethodPost, "/archive", []byte("invalid"))
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("success - archive with the maximum size", func(t *testing.T) {
+		rw := serve(NewImportHandler(mocks.NewMockCasClient(nil), WithMaxArchiveSize(int64(len(car)))),
+			http.MethodPost, "/archive", car)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+	})
+
+	t.Run("error - archive too large", func(t *testing.T) {
+		rw := serve(NewImportHandler(mocks.NewMockCasClient(nil), WithMaxArchiveSize(int64(len(car)-1))),
+			http.MethodPost, "/archive", car)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		require.Contains(t, string(readBody(t, rw)), "archive exceeds the maximum size")
+	})
+
+	t.Run("error - CAS error", func(t *testing.T) {
+		rw := serve(NewImportHandler(mocks.NewMockCasClient(errors.New("CAS error"))), http.MethodPost,
+			"/archive", car)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+type handler interface {
+	Path() string
+	Method() string
+	Handler() common.HTTPRequestHandler
+}
+
+func serve(h handler, method, target string, body []byte) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(method, target, bytes.NewReader(body)))
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	require.NoError(t, rw.Result().Body.Close())
+
+	return body
+}
+
+type mockExporter struct {
+	heads             []string
+	includeBatchFiles bool
+	err               error
+}
+
+func (m *mockExporter) Export(_ context.Context, w io.Writer, heads []string,
+	includeBatchFiles bool) (*archive.ExportResult, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.heads = heads
+	m.includeBatchFiles = includeBatchFiles
+
+	if _, err := w.Write([]byte("archive")); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	return &archive.ExportResult{Anchors: 1}, nil
+}
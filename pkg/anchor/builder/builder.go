@@ -7,24 +7,39 @@ SPDX-License-Identifier: Apache-2.0
 package builder
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 
 	"github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/jsonld"
 )
 
 const (
 	defVCContext = "https://www.w3.org/2018/credentials/v1"
-	// TODO: Add context for anchor credential and define credential subject attributes there.
+	defVCType    = "VerifiableCredential"
+)
+
+const (
+	// FormatLDP is the JSON-LD anchor credential format (credential with embedded linked data proof).
+	FormatLDP = "ldp"
+	// FormatJWT is the JWT anchor credential format (credential with external JWS proof).
+	FormatJWT = "jwt"
 )
 
 // Params holds required parameters for building anchor credential.
 type Params struct {
 	Issuer string
+	// BaseURL is the URL under which anchor credentials are served. The ID of
+	// each credential is <BaseURL>/<uuid>.
+	BaseURL string
+	// Format is the format of the anchor credential (FormatLDP or FormatJWT). Defaults to FormatLDP.
+	Format string
 }
 
 // New returns new instance of anchor credential builder.
@@ -33,14 +48,27 @@ func New(signer vcSigner, params Params) (*Builder, error) {
 		return nil, fmt.Errorf("failed to verify builder parameters: %s", err.Error())
 	}
 
+	if params.Format == "" {
+		params.Format = FormatLDP
+	}
+
 	return &Builder{
 		signer: signer,
 		params: params,
 	}, nil
 }
 
+// Credential contains a signed anchor credential.
+type Credential struct {
+	// ID is the ID (URL) of the anchor credential.
+	ID string
+	// Bytes contains the signed anchor credential in the configured format.
+	Bytes []byte
+}
+
 type vcSigner interface {
 	Sign(vc *verifiable.Credential) (*verifiable.Credential, error)
+	SignJWT(vc *verifiable.Credential) (string, error)
 }
 
 // Builder implements building of anchor credential.
@@ -49,12 +77,13 @@ type Builder struct {
 	params Params
 }
 
-// Build will create and sign anchor credential.
-func (b *Builder) Build(subject *txn.Payload) (*verifiable.Credential, error) {
+// Build will create and sign anchor credential. The signed credential is returned in the
+// configured format: a JSON-LD document for FormatLDP or a compact JWS for FormatJWT.
+func (b *Builder) Build(subject *txn.Payload) (*Credential, error) {
 	vc := &verifiable.Credential{
-		// TODO: Add definition for "AnchorCredential"
-		Types:   []string{"VerifiableCredential"},
-		Context: []string{defVCContext},
+		ID:      fmt.Sprintf("%s/%s", b.params.BaseURL, uuid.New()),
+		Types:   []string{defVCType, jsonld.AnchorCredentialType},
+		Context: []string{defVCContext, jsonld.AnchorContextV1},
 		Subject: subject,
 		Issuer: verifiable.Issuer{
 			ID: b.params.Issuer,
@@ -62,12 +91,64 @@ func (b *Builder) Build(subject *txn.Payload) (*verifiable.Credential, error) {
 		Issued: &util.TimeWithTrailingZeroMsec{Time: time.Now()},
 	}
 
+	var (
+		vcBytes []byte
+		err     error
+	)
+
+	if b.params.Format == FormatJWT {
+		vcBytes, err = b.buildJWT(vc)
+	} else {
+		vcBytes, err = b.buildLDP(vc)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{ID: vc.ID, Bytes: vcBytes}, nil
+}
+
+func (b *Builder) buildLDP(vc *verifiable.Credential) ([]byte, error) {
 	signedVC, err := b.signer.Sign(vc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign credential: %s", err.Error())
 	}
 
-	return signedVC, nil
+	return signedVC.MarshalJSON()
+}
+
+func (b *Builder) buildJWT(vc *verifiable.Credential) ([]byte, error) {
+	// JWT claims are created from a single subject so the payload is converted to a subject with custom fields
+	subject, err := toSubject(vc.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert credential subject: %s", err.Error())
+	}
+
+	vc.Subject = subject
+
+	jws, err := b.signer.SignJWT(vc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign credential: %s", err.Error())
+	}
+
+	return []byte(jws), nil
+}
+
+func toSubject(payload interface{}) (verifiable.Subject, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return verifiable.Subject{}, err
+	}
+
+	var customFields map[string]interface{}
+
+	err = json.Unmarshal(payloadBytes, &customFields)
+	if err != nil {
+		return verifiable.Subject{}, err
+	}
+
+	return verifiable.Subject{CustomFields: customFields}, nil
 }
 
 func verifyBuilderParams(params Params) error {
@@ -75,5 +156,13 @@ func verifyBuilderParams(params Params) error {
 		return errors.New("missing issuer")
 	}
 
+	if params.BaseURL == "" {
+		return errors.New("missing base URL")
+	}
+
+	if params.Format != "" && params.Format != FormatLDP && params.Format != FormatJWT {
+		return fmt.Errorf("unsupported credential format: %s", params.Format)
+	}
+
 	return nil
 }
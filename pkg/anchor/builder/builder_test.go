@@ -8,18 +8,27 @@ package builder
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/stretchr/testify/require"
 
 	"github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/jsonld"
+)
+
+const (
+	testIssuer  = "http://peer1.com"
+	testBaseURL = "https://orb.domain.com/vc"
 )
 
 func TestSigner_New(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		builderParams := Params{
-			Issuer: "issuer",
+			Issuer:  "issuer",
+			BaseURL: testBaseURL,
 		}
 
 		b, err := New(&mockSigner{}, builderParams)
@@ -33,20 +42,88 @@ func TestSigner_New(t *testing.T) {
 		require.Nil(t, s)
 		require.Contains(t, err.Error(), "failed to verify builder parameters: missing issuer")
 	})
+
+	t.Run("error - missing base URL", func(t *testing.T) {
+		s, err := New(&mockSigner{}, Params{Issuer: "issuer"})
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Contains(t, err.Error(), "failed to verify builder parameters: missing base URL")
+	})
+
+	t.Run("error - unsupported format", func(t *testing.T) {
+		s, err := New(&mockSigner{}, Params{Issuer: "issuer", BaseURL: testBaseURL, Format: "invalid"})
+		require.Error(t, err)
+		require.Nil(t, s)
+		require.Contains(t, err.Error(), "unsupported credential format: invalid")
+	})
 }
 
 func TestBuilder_Build(t *testing.T) {
 	builderParams := Params{
-		Issuer: "issuer",
+		Issuer:  testIssuer,
+		BaseURL: testBaseURL,
 	}
 
 	t.Run("success", func(t *testing.T) {
 		b, err := New(&mockSigner{}, builderParams)
 		require.NoError(t, err)
 
-		vc, err := b.Build(&txn.Payload{})
+		cred, err := b.Build(&txn.Payload{})
+		require.NoError(t, err)
+		require.NotEmpty(t, cred.Bytes)
+		require.True(t, strings.HasPrefix(cred.ID, testBaseURL+"/"))
+
+		vc, err := verifiable.ParseCredential(cred.Bytes, verifiable.WithDisabledProofCheck(),
+			verifiable.WithJSONLDDocumentLoader(jsonld.NewDocumentLoader()))
+		require.NoError(t, err)
+		require.Equal(t, cred.ID, vc.ID)
+		require.Equal(t, []string{defVCType, jsonld.AnchorCredentialType}, vc.Types)
+		require.Equal(t, []string{defVCContext, jsonld.AnchorContextV1}, vc.Context)
+
+		cred2, err := b.Build(&txn.Payload{})
+		require.NoError(t, err)
+		require.NotEqual(t, cred.ID, cred2.ID)
+	})
+
+	t.Run("success - JWT", func(t *testing.T) {
+		b, err := New(&mockSigner{}, Params{Issuer: testIssuer, BaseURL: testBaseURL, Format: FormatJWT})
+		require.NoError(t, err)
+
+		cred, err := b.Build(&txn.Payload{
+			AnchorString:   "1.coreIndex",
+			Namespace:      "did:orb",
+			Version:        1,
+			OperationCount: 1,
+			OperationTypes: []string{"create"},
+			CoreIndex:      "coreIndex",
+		})
+		require.NoError(t, err)
+
+		vc, err := verifiable.ParseCredential(cred.Bytes, verifiable.WithDisabledProofCheck(),
+			verifiable.WithJSONLDDocumentLoader(jsonld.NewDocumentLoader()))
+		require.NoError(t, err)
+		require.Equal(t, cred.ID, vc.ID)
+		require.Equal(t, []string{defVCType, jsonld.AnchorCredentialType}, vc.Types)
+		require.Equal(t, testIssuer, vc.Issuer.ID)
+
+		payload, err := util.GetTransactionPayload(vc)
 		require.NoError(t, err)
-		require.NotEmpty(t, vc)
+		require.Equal(t, "1.coreIndex", payload.AnchorString)
+		require.Equal(t, "did:orb", payload.Namespace)
+		require.Equal(t, uint64(1), payload.Version)
+		require.Equal(t, uint64(1), payload.OperationCount)
+		require.Equal(t, []string{"create"}, payload.OperationTypes)
+		require.Equal(t, "coreIndex", payload.CoreIndex)
+	})
+
+	t.Run("error - error from JWT signer", func(t *testing.T) {
+		b, err := New(&mockSigner{Err: errors.New("signer error")}, Params{Issuer: testIssuer, BaseURL: testBaseURL, Format: FormatJWT})
+		require.NoError(t, err)
+
+		cred, err := b.Build(&txn.Payload{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to sign credential: signer error")
+		require.Nil(t, cred)
 	})
 
 	t.Run("error - error from signer", func(t *testing.T) {
@@ -54,17 +131,18 @@ func TestBuilder_Build(t *testing.T) {
 			builderParams)
 		require.NoError(t, err)
 
-		vc, err := b.Build(&txn.Payload{})
+		cred, err := b.Build(&txn.Payload{})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to sign credential: signer error")
-		require.Nil(t, vc)
+		require.Nil(t, cred)
 	})
 }
 
 func TestSigner_verifyBuilderParams(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		builderParams := Params{
-			Issuer: "issuer",
+			Issuer:  "issuer",
+			BaseURL: testBaseURL,
 		}
 
 		err := verifyBuilderParams(builderParams)
@@ -89,3 +167,16 @@ func (m *mockSigner) Sign(vc *verifiable.Credential) (*verifiable.Credential, er
 
 	return vc, nil
 }
+
+func (m *mockSigner) SignJWT(vc *verifiable.Credential) (string, error) {
+	if m.Err != nil {
+		return "", m.Err
+	}
+
+	claims, err := vc.JWTClaims(false)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.MarshalUnsecuredJWT()
+}
@@ -0,0 +1,79 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+)
+
+const nameSpace = "anchordids"
+
+// ErrNotFound is returned if the DIDs of an anchor are not found.
+var ErrNotFound = errors.New("anchor DIDs not found")
+
+// DID contains the unique suffix of a DID along with the type of the operation that was anchored for the DID.
+type DID struct {
+	Suffix string         `json:"suffix"`
+	Type   operation.Type `json:"type"`
+}
+
+// Store is a reverse index from the CID of an anchor to the DIDs that were anchored in the anchor.
+type Store struct {
+	store storage.Store
+}
+
+// New returns a new anchor DID index.
+func New(provider storage.Provider) (*Store, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open anchor DID store: %w", err)
+	}
+
+	return &Store{store: store}, nil
+}
+
+// Put saves the DIDs that were anchored in the anchor with the given CID. Any DIDs that were previously
+// saved for the anchor are replaced.
+func (s *Store) Put(cid string, dids []*DID) error {
+	didsBytes, err := json.Marshal(dids)
+	if err != nil {
+		return fmt.Errorf("failed to marshal DIDs of anchor [%s]: %w", cid, err)
+	}
+
+	err = s.store.Put(cid, didsBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store DIDs of anchor [%s]: %w", cid, err)
+	}
+
+	return nil
+}
+
+// Get returns the DIDs that were anchored in the anchor with the given CID.
+func (s *Store) Get(cid string) ([]*DID, error) {
+	didsBytes, err := s.store.Get(cid)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get DIDs of anchor [%s]: %w", cid, err)
+	}
+
+	var dids []*DID
+
+	err = json.Unmarshal(didsBytes, &dids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal DIDs of anchor [%s]: %w", cid, err)
+	}
+
+	return dids, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didindex
+
+import (
+	"errors"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+)
+
+const cid = "QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt"
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		s, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open anchor DID store: open error")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore_PutGet(t *testing.T) {
+	dids := []*DID{
+		{Suffix: "suffix1", Type: operation.TypeCreate},
+		{Suffix: "suffix2", Type: operation.TypeUpdate},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put(cid, dids))
+
+		value, err := s.Get(cid)
+		require.NoError(t, err)
+		require.Equal(t, dids, value)
+
+		// replaced
+		require.NoError(t, s.Put(cid, dids[:1]))
+
+		value, err = s.Get(cid)
+		require.NoError(t, err)
+		require.Equal(t, dids[:1], value)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		value, err := s.Get(cid)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, value)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		err = s.Put(cid, dids)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		value, err := s.Get(cid)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+		require.Nil(t, value)
+	})
+
+	t.Run("error - invalid stored value", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[cid] = []byte("invalid")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		value, err := s.Get(cid)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal DIDs of anchor")
+		require.Nil(t, value)
+	})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didindexresthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-did-handler")
+
+const (
+	// Path is the base path of the anchor endpoint.
+	Path = "/anchors"
+
+	cidPathVariable = "cid"
+
+	jsonContentType = "application/json"
+)
+
+type didIndex interface {
+	Get(cid string) ([]*didindex.DID, error)
+}
+
+// AnchorDIDs contains the DIDs that were anchored in an anchor.
+type AnchorDIDs struct {
+	Anchor string          `json:"anchor"`
+	DIDs   []*didindex.DID `json:"dids"`
+}
+
+// Handler serves the DIDs (unique suffixes and operation types) that were anchored in the anchor with a given CID.
+type Handler struct {
+	index didIndex
+}
+
+// New returns a new anchor DID handler.
+func New(index didIndex) *Handler {
+	return &Handler{index: index}
+}
+
+// Path returns the HTTP REST endpoint for the anchor DID handler.
+func (h *Handler) Path() string {
+	return fmt.Sprintf("%s/{%s}/dids", Path, cidPathVariable)
+}
+
+// Method returns the HTTP REST method for the anchor DID handler.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the anchor DID handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[cidPathVariable]
+
+	err := cas.ValidateCID(cid)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+		return
+	}
+
+	dids, err := h.index.Get(cid)
+	if err != nil {
+		if errors.Is(err, didindex.ErrNotFound) {
+			writeResponse(w, http.StatusNotFound, "", []byte(http.StatusText(http.StatusNotFound)))
+
+			return
+		}
+
+		logger.Errorf("Error retrieving DIDs of anchor [%s]: %s", cid, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	didsBytes, err := json.Marshal(&AnchorDIDs{Anchor: cid, DIDs: dids})
+	if err != nil {
+		logger.Errorf("Error marshalling DIDs of anchor [%s]: %s", cid, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	writeResponse(w, http.StatusOK, jsonContentType, didsBytes)
+}
+
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package didindexresthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+)
+
+const anchorCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestHandler(t *testing.T) {
+	index, err := didindex.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	dids := []*didindex.DID{
+		{Suffix: "suffix1", Type: operation.TypeCreate},
+		{Suffix: "suffix2", Type: operation.TypeUpdate},
+	}
+
+	require.NoError(t, index.Put(anchorCID, dids))
+
+	t.Run("success", func(t *testing.T) {
+		h := New(index)
+		require.Equal(t, "/anchors/{cid}/dids", h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, anchorCID)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		anchorDIDs := &AnchorDIDs{}
+		require.NoError(t, json.Unmarshal(readBody(t, rw), anchorDIDs))
+		require.Equal(t, anchorCID, anchorDIDs.Anchor)
+		require.Equal(t, dids, anchorDIDs.DIDs)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		emptyIndex, err := didindex.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		rw := serve(New(emptyIndex), anchorCID)
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		rw := serve(New(index), "invalid")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("error - index error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		errIndex, err := didindex.New(provider)
+		require.NoError(t, err)
+
+		rw := serve(New(errIndex), anchorCID)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), string(readBody(t, rw)))
+	})
+}
+
+func serve(h *Handler, cid string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(h.Method(), Path+"/"+cid+"/dids", nil))
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return body
+}
@@ -7,54 +7,302 @@ SPDX-License-Identifier: Apache-2.0
 package graph
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
-	"github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/piprate/json-gold/ld"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/canonicalizer"
 
 	"github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/context/cas"
+	"github.com/trustbloc/orb/pkg/jsonld"
 )
 
+// nodeClient is implemented by CAS clients that are able to store IPLD (dag-json) nodes.
+type nodeClient interface {
+	WriteNode(ctx context.Context, node []byte) (string, error)
+	ReadNode(ctx context.Context, cid string) ([]byte, error)
+}
+
 // Graph manages transaction graph.
 type Graph struct {
-	cas cas.Client
-	pkf verifiable.PublicKeyFetcher
+	cas            casapi.Client
+	nodes          nodeClient
+	pkf            verifiable.PublicKeyFetcher
+	documentLoader ld.DocumentLoader
+	algorithms     cas.MultihashAlgorithmsProvider
+}
+
+// Opt is a graph option.
+type Opt func(g *Graph)
+
+// WithDocumentLoader sets the JSON-LD document loader that is used for parsing anchor credentials.
+func WithDocumentLoader(loader ld.DocumentLoader) Opt {
+	return func(g *Graph) {
+		g.documentLoader = loader
+	}
+}
+
+// WithMultihashAlgorithms sets the multihash algorithms (i.e. the protocol's MultihashAlgorithms) that are
+// allowed in the CIDs of anchor credentials. Defaults to any algorithm that is supported for verification.
+func WithMultihashAlgorithms(algorithms []uint) Opt {
+	return func(g *Graph) {
+		g.algorithms = func() ([]uint, error) { return algorithms, nil }
+	}
+}
+
+// WithMultihashAlgorithmsProvider sets the provider of the multihash algorithms that are allowed in the CIDs of
+// anchor credentials. The provider is invoked for every read so that e.g. the algorithms of the current protocol
+// version are used.
+func WithMultihashAlgorithmsProvider(provider cas.MultihashAlgorithmsProvider) Opt {
+	return func(g *Graph) {
+		g.algorithms = provider
+	}
 }
 
 // New creates new graph manager.
-func New(c cas.Client, pkf verifiable.PublicKeyFetcher) *Graph {
-	return &Graph{cas: c, pkf: pkf}
+func New(c casapi.Client, pkf verifiable.PublicKeyFetcher, opts ...Opt) *Graph {
+	g := &Graph{cas: c, pkf: pkf}
+
+	if nc, ok := c.(nodeClient); ok {
+		g.nodes = nc
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	if g.documentLoader == nil {
+		g.documentLoader = jsonld.NewDocumentLoader()
+	}
+
+	return g
 }
 
-// Add adds orb transaction to the transaction graph.
+// Add adds orb transaction to the transaction graph. The anchor credential may be either
+// a JSON-LD document or a JWT. JSON-LD documents are written in JCS (RFC 8785) canonical form
+// so that semantically identical credentials result in the same CID.
+// If the CAS client supports IPLD nodes then the anchor credential is stored in a dag-json node
+// with the previous transactions as IPLD links, otherwise the anchor credential is stored as is.
+// The write to CAS is aborted if the given context is done.
 // Returns cid that contains orb transaction information.
-func (g *Graph) Add(vc *verifiable.Credential) (string, error) { //nolint:interfacer
-	// TODO: do we need canonical?
-	txnBytes, err := vc.MarshalJSON()
+func (g *Graph) Add(ctx context.Context, vcBytes []byte) (string, error) {
+	if isJSON(vcBytes) {
+		canonicalBytes, err := canonicalizer.MarshalCanonical(json.RawMessage(vcBytes))
+		if err != nil {
+			return "", fmt.Errorf("failed to canonicalize anchor credential: %w", err)
+		}
+
+		vcBytes = canonicalBytes
+	}
+
+	if g.nodes == nil {
+		return cas.WriteWithContext(ctx, g.cas, vcBytes)
+	}
+
+	vc, err := verifiable.ParseCredential(vcBytes,
+		verifiable.WithDisabledProofCheck(),
+		verifiable.WithJSONLDDocumentLoader(g.documentLoader),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse anchor credential: %w", err)
+	}
+
+	payload, err := util.GetTransactionPayload(vc)
 	if err != nil {
 		return "", err
 	}
 
-	return g.cas.Write(txnBytes)
+	nodeBytes, err := encodeNode(vcBytes, payload.PreviousTransactions)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode anchor node: %w", err)
+	}
+
+	return g.nodes.WriteNode(ctx, nodeBytes)
 }
 
-// Read reads orb transaction.
-func (g *Graph) Read(cid string) (*verifiable.Credential, error) {
-	nodeBytes, err := g.cas.Read(cid)
+// Read reads orb transaction. Both JSON-LD and JWT anchor credentials are supported as well as
+// both the legacy format (anchor credential stored as is) and IPLD anchor nodes.
+// The CID must be valid (an error that wraps cas.ErrInvalidCID is returned otherwise) and the content is
+// verified against the CID (an error that wraps cas.ErrContentMismatch is returned if it doesn't match) so that
+// forged anchors are rejected. JSON-LD documents must be in JCS canonical form, except for legacy anchor
+// credentials (i.e. credentials without an ID, which were written before anchor credentials were assigned IDs
+// and canonicalized) which are accepted as is.
+// For IPLD anchor nodes the links of the node must match the previous transactions of the anchor credential.
+// The read from CAS is aborted if the given context is done.
+func (g *Graph) Read(ctx context.Context, cid string) (*verifiable.Credential, error) {
+	vcBytes, links, err := g.read(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	vc, err := verifiable.ParseCredential(vcBytes,
+		verifiable.WithPublicKeyFetcher(g.pkf),
+		verifiable.WithJSONLDDocumentLoader(g.documentLoader),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return verifiable.ParseCredential(nodeBytes, verifiable.WithPublicKeyFetcher(g.pkf))
+	if links != nil {
+		err = verifyLinks(vc, links)
+		if err != nil {
+			return nil, fmt.Errorf("invalid anchor node [%s]: %w", cid, err)
+		}
+	}
+
+	return vc, nil
+}
+
+// ReadRaw returns the anchor credential (JSON-LD document or JWT) for the given CID as it was written.
+// The content is verified against the CID but the anchor credential itself is not verified.
+func (g *Graph) ReadRaw(ctx context.Context, cid string) ([]byte, error) {
+	vcBytes, _, err := g.read(ctx, cid)
+
+	return vcBytes, err
+}
+
+// read returns the anchor credential bytes along with the links of the anchor node.
+// The returned links are nil if the CID refers to a legacy (non-IPLD) anchor credential.
+func (g *Graph) read(ctx context.Context, cid string) ([]byte, map[string]string, error) {
+	algorithms, err := g.multihashAlgorithms()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = cas.ValidateCID(cid, algorithms...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cas.IsDAGJSON(cid) {
+		return g.readNode(ctx, cid, algorithms)
+	}
+
+	vcBytes, err := cas.ReadWithContext(ctx, g.cas, cid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = cas.VerifyCID(cid, vcBytes, algorithms...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify anchor credential [%s]: %w", cid, err)
+	}
+
+	if isJSON(vcBytes) {
+		err = verifyCanonical(vcBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid anchor credential [%s]: %w", cid, err)
+		}
+	}
+
+	return vcBytes, nil, nil
+}
+
+// verifyCanonical returns an error if the given JSON-LD anchor credential is not in JCS canonical form.
+// Legacy anchor credentials (without an ID) aren't required to be in canonical form.
+func verifyCanonical(vcBytes []byte) error {
+	var vc struct {
+		ID string `json:"id"`
+	}
+
+	err := json.Unmarshal(vcBytes, &vc)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal anchor credential: %w", err)
+	}
+
+	if vc.ID == "" {
+		return nil
+	}
+
+	canonicalBytes, err := canonicalizer.MarshalCanonical(json.RawMessage(vcBytes))
+	if err != nil {
+		return fmt.Errorf("failed to canonicalize anchor credential: %w", err)
+	}
+
+	if !bytes.Equal(canonicalBytes, vcBytes) {
+		return fmt.Errorf("anchor credential is not in canonical form")
+	}
+
+	return nil
+}
+
+// multihashAlgorithms returns the multihash algorithms that are allowed in CIDs (nil if any algorithm is allowed).
+func (g *Graph) multihashAlgorithms() ([]uint, error) {
+	if g.algorithms == nil {
+		return nil, nil
+	}
+
+	algorithms, err := g.algorithms()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multihash algorithms: %w", err)
+	}
+
+	return algorithms, nil
+}
+
+func (g *Graph) readNode(ctx context.Context, cid string, algorithms []uint) ([]byte, map[string]string, error) {
+	if g.nodes == nil {
+		return nil, nil, fmt.Errorf("CAS client does not support reading IPLD anchor node [%s]", cid)
+	}
+
+	nodeBytes, err := g.nodes.ReadNode(ctx, cid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = cas.VerifyCID(cid, nodeBytes, algorithms...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify anchor node [%s]: %w", cid, err)
+	}
+
+	vcBytes, links, err := decodeNode(nodeBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode anchor node [%s]: %w", cid, err)
+	}
+
+	return vcBytes, links, nil
+}
+
+// verifyLinks verifies that the links of an anchor node match the previous transactions of the anchor credential.
+func verifyLinks(vc *verifiable.Credential, links map[string]string) error {
+	payload, err := util.GetTransactionPayload(vc)
+	if err != nil {
+		return err
+	}
+
+	if len(links) != len(payload.PreviousTransactions) {
+		return fmt.Errorf("links do not match previous transactions of anchor credential")
+	}
+
+	for suffix, txnCID := range payload.PreviousTransactions {
+		if links[suffix] != txnCID {
+			return fmt.Errorf("link for [%s] does not match previous transaction of anchor credential", suffix)
+		}
+	}
+
+	return nil
+}
+
+func isJSON(content []byte) bool {
+	trimmed := bytes.TrimSpace(content)
+
+	return len(trimmed) > 0 && trimmed[0] == '{'
 }
 
 // GetDidTransactions returns all orb transactions that are referencing DID starting from cid.
-func (g *Graph) GetDidTransactions(cid, did string) ([]string, error) {
+func (g *Graph) GetDidTransactions(ctx context.Context, cid, did string) ([]string, error) {
 	var refs []string
 
 	cur := cid
 	ok := true
 
 	for ok {
-		node, err := g.Read(cur)
+		node, err := g.Read(ctx, cur)
 		if err != nil {
 			return nil, err
 		}
@@ -7,21 +7,57 @@ SPDX-License-Identifier: Apache-2.0
 package graph
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	ariesjsonld "github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util/signature"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/canonicalizer"
 	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
 
 	"github.com/trustbloc/orb/pkg/anchor/txn"
 	vcutil "github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/context/cas"
+	"github.com/trustbloc/orb/pkg/jsonld"
 )
 
 const testDID = "did:method:abc"
 
+// legacyAnchorCredential is an anchor credential in the format in which anchor credentials used to be written
+// (i.e. not in JCS canonical form).
+const legacyAnchorCredential = `{
+  "@context": [
+    "https://www.w3.org/2018/credentials/v1",
+    "https://trustbloc.github.io/Context/orb-v1.json"
+  ],
+  "type": ["VerifiableCredential", "AnchorCredential"],
+  "issuer": "http://peer1.com",
+  "issuanceDate": "2021-01-27T09:30:10Z",
+  "credentialSubject": {
+    "anchorString": "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt",
+    "namespace": "did:sidetree",
+    "version": 0,
+    "previousTransactions": {
+      "did:method:abc": "QmYPjqAMmYFpTJyNrFPMpKm3hUy2dN9hudpJcDRrzNWmhS"
+    }
+  }
+}`
+
 func TestNew(t *testing.T) {
 	graph := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
 	require.NotNil(t, graph)
@@ -37,7 +73,7 @@ func TestGraph_Add(t *testing.T) {
 			Version:      1,
 		}
 
-		cid, err := graph.Add(buildCredential(payload))
+		cid, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, cid)
 	})
@@ -53,11 +89,11 @@ func TestGraph_Read(t *testing.T) {
 			Version:      1,
 		}
 
-		txnCID, err := graph.Add(buildCredential(payload))
+		txnCID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, txnCID)
 
-		vc, err := graph.Read(txnCID)
+		vc, err := graph.Read(context.Background(), txnCID)
 		require.NoError(t, err)
 
 		payloadFromVC, err := vcutil.GetTransactionPayload(vc)
@@ -66,13 +102,461 @@ func TestGraph_Read(t *testing.T) {
 		require.Equal(t, payload.Namespace, payloadFromVC.Namespace)
 	})
 
+	t.Run("success - signed anchor credential", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		graph := New(mocks.NewMockCasClient(nil), func(_, _ string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		payload := txn.Payload{
+			AnchorString: "anchor",
+			Namespace:    "namespace",
+			Version:      1,
+		}
+
+		vcBytes := marshalCredential(t, signCredential(t, buildAnchorCredential(payload), privKey))
+
+		txnCID, err := graph.Add(context.Background(), vcBytes)
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Len(t, vc.Proofs, 1)
+	})
+
+	t.Run("error - anchor payload modified after signing", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, func(_, _ string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		payload := txn.Payload{
+			AnchorString: "anchor",
+			Namespace:    "namespace",
+			Version:      1,
+		}
+
+		vcBytes, err := signCredential(t, buildAnchorCredential(payload), privKey).MarshalJSON()
+		require.NoError(t, err)
+
+		txnCID, err := casClient.Write(bytes.Replace(vcBytes, []byte(`"anchor"`), []byte(`"forged"`), 1))
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "check linked data proof")
+		require.Nil(t, vc)
+	})
+
+	t.Run("success - JWT anchor credential", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		graph := New(mocks.NewMockCasClient(nil), func(_, keyID string) (*verifier.PublicKey, error) {
+			require.Equal(t, "did:web:abc#key1", keyID)
+
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		payload := txn.Payload{
+			AnchorString:         "anchor",
+			Namespace:            "namespace",
+			Version:              1,
+			PreviousTransactions: map[string]string{testDID: "cid"},
+		}
+
+		txnCID, err := graph.Add(context.Background(), signCredentialJWT(t, buildAnchorCredential(payload), privKey))
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Empty(t, vc.Proofs)
+
+		payloadFromVC, err := vcutil.GetTransactionPayload(vc)
+		require.NoError(t, err)
+		require.Equal(t, payload, *payloadFromVC)
+	})
+
+	t.Run("error - JWT anchor credential signed with another key", func(t *testing.T) {
+		pubKey, _, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		_, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		graph := New(mocks.NewMockCasClient(nil), func(_, _ string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		jwt := signCredentialJWT(t, buildAnchorCredential(txn.Payload{AnchorString: "anchor"}), privKey)
+
+		txnCID, err := graph.Add(context.Background(), jwt)
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "JWS decoding")
+		require.Nil(t, vc)
+	})
+
+	t.Run("success - semantically identical credentials have the same CID", func(t *testing.T) {
+		graph := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		vcBytes := marshalCredential(t, buildCredential(txn.Payload{AnchorString: "anchor"}))
+
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(vcBytes, &vcMap))
+
+		indentedBytes, err := json.MarshalIndent(vcMap, "", "  ")
+		require.NoError(t, err)
+
+		cid1, err := graph.Add(context.Background(), vcBytes)
+		require.NoError(t, err)
+
+		cid2, err := graph.Add(context.Background(), indentedBytes)
+		require.NoError(t, err)
+
+		require.Equal(t, cid1, cid2)
+	})
+
+	t.Run("success - legacy (non-canonical) anchor credential", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		canonicalBytes, err := canonicalizer.MarshalCanonical(json.RawMessage(legacyAnchorCredential))
+		require.NoError(t, err)
+		require.NotEqual(t, legacyAnchorCredential, string(canonicalBytes))
+
+		txnCID, err := casClient.Write([]byte(legacyAnchorCredential))
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+
+		payload, err := vcutil.GetTransactionPayload(vc)
+		require.NoError(t, err)
+		require.Equal(t, "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt", payload.AnchorString)
+		require.Equal(t, map[string]string{testDID: "QmYPjqAMmYFpTJyNrFPMpKm3hUy2dN9hudpJcDRrzNWmhS"},
+			payload.PreviousTransactions)
+
+		rawBytes, err := graph.ReadRaw(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Equal(t, legacyAnchorCredential, string(rawBytes))
+	})
+
+	t.Run("success - legacy signed anchor credential", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, func(_, _ string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		vcBytes, err := signCredential(t, buildAnchorCredential(txn.Payload{AnchorString: "anchor"}), privKey).MarshalJSON()
+		require.NoError(t, err)
+
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(vcBytes, &vcMap))
+
+		indentedBytes, err := json.MarshalIndent(vcMap, "", "  ")
+		require.NoError(t, err)
+
+		txnCID, err := casClient.Write(indentedBytes)
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Len(t, vc.Proofs, 1)
+	})
+
+	t.Run("error - anchor credential is not canonical", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		vc := buildAnchorCredential(txn.Payload{AnchorString: "anchor"})
+		vc.ID = "https://orb.domain.com/vc/1d9cf79c-7a1d-4bb5-b41b-2c8b2b3ae1c6"
+
+		var vcMap map[string]interface{}
+		require.NoError(t, json.Unmarshal(marshalCredential(t, vc), &vcMap))
+
+		indentedBytes, err := json.MarshalIndent(vcMap, "", "  ")
+		require.NoError(t, err)
+
+		txnCID, err := casClient.Write(indentedBytes)
+		require.NoError(t, err)
+
+		readVC, err := graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "anchor credential is not in canonical form")
+		require.Nil(t, readVC)
+
+		_, err = graph.ReadRaw(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "anchor credential is not in canonical form")
+	})
+
+	t.Run("error - invalid JSON anchor credential", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		txnCID, err := casClient.Write([]byte(`{"id":`))
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal anchor credential")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - content does not match CID", func(t *testing.T) {
+		graph := New(&mockCAS{content: []byte(`{"forged":true}`)}, pubKeyFetcherFnc)
+
+		vc, err := graph.Read(context.Background(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, cas.ErrContentMismatch))
+		require.Nil(t, vc)
+	})
+
 	t.Run("error - transaction (cid) not found", func(t *testing.T) {
 		graph := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
 
-		txnNode, err := graph.Read("non-existent")
+		txnNode, err := graph.Read(context.Background(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
 		require.Error(t, err)
 		require.Nil(t, txnNode)
 	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		txnNode, err := graph.Read(context.Background(), "non-existent")
+		require.True(t, errors.Is(err, cas.ErrInvalidCID))
+		require.Nil(t, txnNode)
+
+		txnCID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(txn.Payload{})))
+		require.NoError(t, err)
+
+		txnNode, err = New(casClient, pubKeyFetcherFnc, WithMultihashAlgorithms([]uint{multihash.SHA2_512})).
+			Read(context.Background(), txnCID)
+		require.True(t, errors.Is(err, cas.ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported hash type")
+		require.Nil(t, txnNode)
+	})
+
+	t.Run("multihash algorithms provider", func(t *testing.T) {
+		casClient := mocks.NewMockCasClient(nil)
+
+		txnCID, err := New(casClient, pubKeyFetcherFnc).
+			Add(context.Background(), marshalCredential(t, buildCredential(txn.Payload{})))
+		require.NoError(t, err)
+
+		graph := New(casClient, pubKeyFetcherFnc, WithMultihashAlgorithmsProvider(func() ([]uint, error) {
+			return []uint{multihash.SHA2_256}, nil
+		}))
+
+		txnNode, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.NotNil(t, txnNode)
+
+		graph = New(casClient, pubKeyFetcherFnc, WithMultihashAlgorithmsProvider(func() ([]uint, error) {
+			return nil, errors.New("protocol error")
+		}))
+
+		txnNode, err = graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get multihash algorithms: protocol error")
+		require.Nil(t, txnNode)
+	})
+
+	t.Run("error - context cancelled", func(t *testing.T) {
+		graph := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		txnNode, err := graph.Read(ctx, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, txnNode)
+	})
+}
+
+func TestGraph_IPLD(t *testing.T) {
+	t.Run("success - anchor nodes are linked", func(t *testing.T) {
+		casClient := newMockNodeCAS()
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		txn1CID, err := graph.Add(context.Background(),
+			marshalCredential(t, buildCredential(txn.Payload{AnchorString: "anchor-1"})))
+		require.NoError(t, err)
+		require.True(t, cas.IsDAGJSON(txn1CID))
+
+		payload := txn.Payload{
+			AnchorString:         "anchor-2",
+			PreviousTransactions: map[string]string{testDID: txn1CID, "did:method:xyz": "non-cid"},
+		}
+
+		txn2CID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
+		require.NoError(t, err)
+
+		nodeBytes, err := casClient.ReadNode(context.Background(), txn2CID)
+		require.NoError(t, err)
+		require.Contains(t, string(nodeBytes), `"did:method:abc":{"/":"`+txn1CID+`"}`)
+		require.Contains(t, string(nodeBytes), `"did:method:xyz":"non-cid"`)
+
+		vc, err := graph.Read(context.Background(), txn2CID)
+		require.NoError(t, err)
+
+		payloadFromVC, err := vcutil.GetTransactionPayload(vc)
+		require.NoError(t, err)
+		require.Equal(t, payload, *payloadFromVC)
+
+		didTxns, err := graph.GetDidTransactions(context.Background(), txn2CID, testDID)
+		require.NoError(t, err)
+		require.Equal(t, []string{txn1CID}, didTxns)
+	})
+
+	t.Run("success - legacy anchor credential", func(t *testing.T) {
+		casClient := newMockNodeCAS()
+
+		vcBytes, err := canonicalizer.MarshalCanonical(
+			json.RawMessage(marshalCredential(t, buildCredential(txn.Payload{AnchorString: "anchor"}))))
+		require.NoError(t, err)
+
+		txnCID, err := casClient.Write(vcBytes)
+		require.NoError(t, err)
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.NotNil(t, vc)
+
+		rawBytes, err := graph.ReadRaw(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Equal(t, vcBytes, rawBytes)
+	})
+
+	t.Run("success - JWT anchor credential", func(t *testing.T) {
+		pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		graph := New(newMockNodeCAS(), func(_, _ string) (*verifier.PublicKey, error) {
+			return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+		})
+
+		jwt := signCredentialJWT(t, buildAnchorCredential(txn.Payload{AnchorString: "anchor"}), privKey)
+
+		txnCID, err := graph.Add(context.Background(), jwt)
+		require.NoError(t, err)
+		require.True(t, cas.IsDAGJSON(txnCID))
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.NotNil(t, vc)
+
+		rawBytes, err := graph.ReadRaw(context.Background(), txnCID)
+		require.NoError(t, err)
+		require.Equal(t, jwt, rawBytes)
+	})
+
+	t.Run("error - links do not match anchor credential", func(t *testing.T) {
+		casClient := newMockNodeCAS()
+
+		vcBytes := marshalCredential(t, buildCredential(txn.Payload{
+			AnchorString:         "anchor",
+			PreviousTransactions: map[string]string{testDID: "cid-1"},
+		}))
+
+		graph := New(casClient, pubKeyFetcherFnc)
+
+		nodeBytes, err := encodeNode(vcBytes, map[string]string{testDID: "cid-2"})
+		require.NoError(t, err)
+
+		txnCID, err := casClient.WriteNode(context.Background(), nodeBytes)
+		require.NoError(t, err)
+
+		vc, err := graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not match previous transaction")
+		require.Nil(t, vc)
+
+		nodeBytes, err = encodeNode(vcBytes, nil)
+		require.NoError(t, err)
+
+		txnCID, err = casClient.WriteNode(context.Background(), nodeBytes)
+		require.NoError(t, err)
+
+		vc, err = graph.Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "links do not match previous transactions")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - anchor node does not match CID", func(t *testing.T) {
+		casClient := newMockNodeCAS()
+
+		txnCID, err := cas.GetDAGJSONCID([]byte(`{"anchorCredential":"jwt"}`))
+		require.NoError(t, err)
+
+		casClient.nodes[txnCID] = []byte(`{"anchorCredential":"forged"}`)
+
+		vc, err := New(casClient, pubKeyFetcherFnc).Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, cas.ErrContentMismatch))
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - invalid anchor node", func(t *testing.T) {
+		casClient := newMockNodeCAS()
+
+		txnCID, err := casClient.WriteNode(context.Background(), []byte(`{}`))
+		require.NoError(t, err)
+
+		vc, err := New(casClient, pubKeyFetcherFnc).Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to decode anchor node")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - anchor node not found", func(t *testing.T) {
+		txnCID, err := cas.GetDAGJSONCID([]byte(`{}`))
+		require.NoError(t, err)
+
+		vc, err := New(newMockNodeCAS(), pubKeyFetcherFnc).Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - CAS client does not support IPLD nodes", func(t *testing.T) {
+		txnCID, err := cas.GetDAGJSONCID([]byte(`{}`))
+		require.NoError(t, err)
+
+		vc, err := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc).Read(context.Background(), txnCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not support reading IPLD anchor node")
+		require.Nil(t, vc)
+	})
+
+	t.Run("error - invalid anchor credential", func(t *testing.T) {
+		txnCID, err := New(newMockNodeCAS(), pubKeyFetcherFnc).Add(context.Background(), []byte(`{"id":"invalid"}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse anchor credential")
+		require.Empty(t, txnCID)
+	})
 }
 
 func TestGraph_GetDidTransactions(t *testing.T) {
@@ -85,11 +569,11 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 			Version:      1,
 		}
 
-		txnCID, err := graph.Add(buildCredential(payload))
+		txnCID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, txnCID)
 
-		didTxns, err := graph.GetDidTransactions(txnCID, testDID)
+		didTxns, err := graph.GetDidTransactions(context.Background(), txnCID, testDID)
 		require.NoError(t, err)
 		require.Equal(t, 0, len(didTxns))
 	})
@@ -103,7 +587,7 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 			Version:      1,
 		}
 
-		txn1CID, err := graph.Add(buildCredential(payload))
+		txn1CID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, txn1CID)
 
@@ -119,11 +603,11 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 			PreviousTransactions: previousDIDTxns,
 		}
 
-		txnCID, err := graph.Add(buildCredential(payload))
+		txnCID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, txnCID)
 
-		didTxns, err := graph.GetDidTransactions(txnCID, testDID)
+		didTxns, err := graph.GetDidTransactions(context.Background(), txnCID, testDID)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(didTxns))
 		require.Equal(t, txn1CID, didTxns[0])
@@ -135,7 +619,7 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 		testDID := "did:method:abc"
 
 		previousDIDTxns := make(map[string]string)
-		previousDIDTxns[testDID] = "non-existent"
+		previousDIDTxns[testDID] = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
 
 		payload := txn.Payload{
 			AnchorString:         "anchor-2",
@@ -144,11 +628,11 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 			PreviousTransactions: previousDIDTxns,
 		}
 
-		txnCID, err := graph.Add(buildCredential(payload))
+		txnCID, err := graph.Add(context.Background(), marshalCredential(t, buildCredential(payload)))
 		require.NoError(t, err)
 		require.NotNil(t, txnCID)
 
-		didTxns, err := graph.GetDidTransactions(txnCID, testDID)
+		didTxns, err := graph.GetDidTransactions(context.Background(), txnCID, testDID)
 		require.Error(t, err)
 		require.Nil(t, didTxns)
 		require.Contains(t, err.Error(), "not found")
@@ -157,7 +641,8 @@ func TestGraph_GetDidTransactions(t *testing.T) {
 	t.Run("error - head cid not found", func(t *testing.T) {
 		graph := New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
 
-		txnNode, err := graph.GetDidTransactions("non-existent", "did")
+		txnNode, err := graph.GetDidTransactions(context.Background(),
+			"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", "did")
 		require.Error(t, err)
 		require.Nil(t, txnNode)
 		require.Contains(t, err.Error(), "not found")
@@ -180,6 +665,105 @@ func buildCredential(payload txn.Payload) *verifiable.Credential {
 	return vc
 }
 
+func buildAnchorCredential(payload txn.Payload) *verifiable.Credential {
+	vc := buildCredential(payload)
+
+	vc.Types = append(vc.Types, jsonld.AnchorCredentialType)
+	vc.Context = append(vc.Context, jsonld.AnchorContextV1)
+
+	return vc
+}
+
+func signCredential(t *testing.T, vc *verifiable.Credential, privKey ed25519.PrivateKey) *verifiable.Credential {
+	t.Helper()
+
+	err := vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+		SignatureType:           "Ed25519Signature2018",
+		Suite:                   ed25519signature2018.New(suite.WithSigner(signature.GetEd25519Signer(privKey, nil))),
+		SignatureRepresentation: verifiable.SignatureJWS,
+		VerificationMethod:      "did:web:abc#key1",
+	}, ariesjsonld.WithDocumentLoader(jsonld.NewDocumentLoader()))
+	require.NoError(t, err)
+
+	return vc
+}
+
+func signCredentialJWT(t *testing.T, vc *verifiable.Credential, privKey ed25519.PrivateKey) []byte {
+	t.Helper()
+
+	subject, ok := vc.Subject.(txn.Payload)
+	require.True(t, ok)
+
+	payloadBytes, err := json.Marshal(subject)
+	require.NoError(t, err)
+
+	var customFields map[string]interface{}
+	require.NoError(t, json.Unmarshal(payloadBytes, &customFields))
+
+	vc.Subject = verifiable.Subject{CustomFields: customFields}
+
+	claims, err := vc.JWTClaims(false)
+	require.NoError(t, err)
+
+	jws, err := claims.MarshalJWS(verifiable.EdDSA, signature.GetEd25519Signer(privKey, nil), "did:web:abc#key1")
+	require.NoError(t, err)
+
+	return []byte(jws)
+}
+
+func marshalCredential(t *testing.T, vc *verifiable.Credential) []byte {
+	t.Helper()
+
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	return vcBytes
+}
+
+type mockCAS struct {
+	content []byte
+}
+
+func (m *mockCAS) Write(content []byte) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (m *mockCAS) Read(string) ([]byte, error) {
+	return m.content, nil
+}
+
+type mockNodeCAS struct {
+	*mocks.MockCasClient
+	nodes map[string][]byte
+}
+
+func newMockNodeCAS() *mockNodeCAS {
+	return &mockNodeCAS{
+		MockCasClient: mocks.NewMockCasClient(nil),
+		nodes:         make(map[string][]byte),
+	}
+}
+
+func (m *mockNodeCAS) WriteNode(_ context.Context, node []byte) (string, error) {
+	id, err := cas.GetDAGJSONCID(node)
+	if err != nil {
+		return "", err
+	}
+
+	m.nodes[id] = node
+
+	return id, nil
+}
+
+func (m *mockNodeCAS) ReadNode(_ context.Context, id string) ([]byte, error) {
+	node, ok := m.nodes[id]
+	if !ok {
+		return nil, fmt.Errorf("node [%s] not found", id)
+	}
+
+	return node, nil
+}
+
 var pubKeyFetcherFnc = func(issuerID, keyID string) (*verifier.PublicKey, error) {
 	return nil, nil
 }
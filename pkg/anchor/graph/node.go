@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/trustbloc/sidetree-core-go/pkg/canonicalizer"
+)
+
+// anchorNode is the IPLD (dag-json) node of the anchor graph. The anchor credential is embedded
+// in the node (either as a JSON object or as a JWT string) and the previous transactions are
+// expressed as IPLD links so that IPFS tooling is able to traverse the graph.
+type anchorNode struct {
+	AnchorCredential     json.RawMessage            `json:"anchorCredential"`
+	PreviousTransactions map[string]json.RawMessage `json:"previousTransactions,omitempty"`
+}
+
+// link is a dag-json link.
+type link struct {
+	CID string `json:"/"`
+}
+
+// encodeNode returns the dag-json encoded anchor node for the given anchor credential. The node is
+// encoded in JCS canonical form which (for the content of anchor nodes) is also the canonical dag-json form.
+// Previous transactions that are not valid CIDs (e.g. Sidetree multihash addresses) are stored as strings.
+func encodeNode(vcBytes []byte, previousTxns map[string]string) ([]byte, error) {
+	node := &anchorNode{}
+
+	if isJSON(vcBytes) {
+		node.AnchorCredential = vcBytes
+	} else {
+		jwtBytes, err := json.Marshal(string(vcBytes))
+		if err != nil {
+			return nil, err
+		}
+
+		node.AnchorCredential = jwtBytes
+	}
+
+	if len(previousTxns) > 0 {
+		node.PreviousTransactions = make(map[string]json.RawMessage)
+
+		for suffix, txnCID := range previousTxns {
+			var value interface{} = txnCID
+
+			if _, err := cid.Decode(txnCID); err == nil {
+				value = &link{CID: txnCID}
+			}
+
+			valueBytes, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			node.PreviousTransactions[suffix] = valueBytes
+		}
+	}
+
+	return canonicalizer.MarshalCanonical(node)
+}
+
+// decodeNode decodes the given dag-json encoded anchor node and returns the anchor credential
+// (JSON-LD document or JWT) along with the previous transactions.
+func decodeNode(nodeBytes []byte) ([]byte, map[string]string, error) {
+	canonicalBytes, err := canonicalizer.MarshalCanonical(json.RawMessage(nodeBytes))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to canonicalize anchor node: %w", err)
+	}
+
+	if !bytes.Equal(canonicalBytes, nodeBytes) {
+		return nil, nil, fmt.Errorf("anchor node is not in canonical form")
+	}
+
+	node := &anchorNode{}
+
+	err = json.Unmarshal(nodeBytes, node)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal anchor node: %w", err)
+	}
+
+	if len(node.AnchorCredential) == 0 {
+		return nil, nil, fmt.Errorf("anchor node is missing anchor credential")
+	}
+
+	vcBytes := []byte(node.AnchorCredential)
+
+	if !isJSON(vcBytes) {
+		var jwt string
+
+		err = json.Unmarshal(vcBytes, &jwt)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid anchor credential in anchor node: %w", err)
+		}
+
+		vcBytes = []byte(jwt)
+	}
+
+	previousTxns := make(map[string]string)
+
+	for suffix, value := range node.PreviousTransactions {
+		txnCID, err := decodeLink(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid previous transaction for [%s]: %w", suffix, err)
+		}
+
+		previousTxns[suffix] = txnCID
+	}
+
+	return vcBytes, previousTxns, nil
+}
+
+func decodeLink(value json.RawMessage) (string, error) {
+	if isJSON(value) {
+		l := &link{}
+
+		err := json.Unmarshal(value, l)
+		if err != nil {
+			return "", err
+		}
+
+		return l.CID, nil
+	}
+
+	var txnCID string
+
+	err := json.Unmarshal(value, &txnCID)
+	if err != nil {
+		return "", err
+	}
+
+	return txnCID, nil
+}
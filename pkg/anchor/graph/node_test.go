@@ -0,0 +1,83 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestEncodeNode(t *testing.T) {
+	t.Run("JSON-LD anchor credential", func(t *testing.T) {
+		nodeBytes, err := encodeNode([]byte(`{"id":"vc"}`), map[string]string{"suffix-2": "address", "suffix-1": testCID})
+		require.NoError(t, err)
+		require.Equal(t,
+			`{"anchorCredential":{"id":"vc"},"previousTransactions":{"suffix-1":{"/":"`+testCID+`"},"suffix-2":"address"}}`,
+			string(nodeBytes))
+
+		vcBytes, previousTxns, err := decodeNode(nodeBytes)
+		require.NoError(t, err)
+		require.Equal(t, `{"id":"vc"}`, string(vcBytes))
+		require.Equal(t, map[string]string{"suffix-2": "address", "suffix-1": testCID}, previousTxns)
+	})
+
+	t.Run("JWT anchor credential", func(t *testing.T) {
+		nodeBytes, err := encodeNode([]byte("header.payload.signature"), nil)
+		require.NoError(t, err)
+		require.Equal(t, `{"anchorCredential":"header.payload.signature"}`, string(nodeBytes))
+
+		vcBytes, previousTxns, err := decodeNode(nodeBytes)
+		require.NoError(t, err)
+		require.Equal(t, "header.payload.signature", string(vcBytes))
+		require.Empty(t, previousTxns)
+	})
+}
+
+func TestDecodeNode(t *testing.T) {
+	t.Run("error - not in canonical form", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`{ "anchorCredential":"jwt"}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not in canonical form")
+	})
+
+	t.Run("error - invalid JSON", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`{`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to canonicalize anchor node")
+	})
+
+	t.Run("error - unexpected node", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`[]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal anchor node")
+	})
+
+	t.Run("error - missing anchor credential", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`{}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing anchor credential")
+	})
+
+	t.Run("error - invalid anchor credential", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`{"anchorCredential":1}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid anchor credential")
+	})
+
+	t.Run("error - invalid link", func(t *testing.T) {
+		_, _, err := decodeNode([]byte(`{"anchorCredential":"jwt","previousTransactions":{"suffix":{"/":1}}}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid previous transaction for [suffix]")
+
+		_, _, err = decodeNode([]byte(`{"anchorCredential":"jwt","previousTransactions":{"suffix":1}}`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid previous transaction for [suffix]")
+	})
+}
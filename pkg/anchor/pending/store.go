@@ -0,0 +1,169 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pending
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+	"github.com/trustbloc/orb/pkg/anchor/txn"
+)
+
+const (
+	nameSpace = "pendinganchors"
+	keyPrefix = "anchor_"
+)
+
+// ErrNotFound is returned if a pending anchor is not found.
+var ErrNotFound = errors.New("pending anchor not found")
+
+// State is the state of a pending anchor in the writer pipeline.
+type State string
+
+const (
+	// StateBuilt means that the anchor credential subject has been built.
+	StateBuilt State = "built"
+	// StateSigned means that the anchor credential has been signed.
+	StateSigned State = "signed"
+	// StateWitnessed means that the anchor credential has been witnessed.
+	StateWitnessed State = "witnessed"
+	// StateStored means that the anchor credential has been added to the anchor graph.
+	StateStored State = "stored"
+	// StateIndexed means that the DIDs of the anchor have been indexed.
+	StateIndexed State = "indexed"
+	// StateAnnounced means that the anchor has been announced to the observer.
+	StateAnnounced State = "announced"
+)
+
+// Anchor is an anchor that is being written by the writer. The fields are populated as the anchor
+// progresses through the states.
+type Anchor struct {
+	// ID is the Sidetree anchor string (which uniquely identifies a batch).
+	ID string `json:"id"`
+	// State is the last state that the anchor reached.
+	State State `json:"state"`
+	// Payload is the anchor credential subject.
+	Payload *txn.Payload `json:"payload"`
+	// DIDs are the DIDs that are anchored in the anchor.
+	DIDs []*didindex.DID `json:"dids"`
+	// VCID is the ID of the anchor credential.
+	VCID string `json:"vcId,omitempty"`
+	// VC is the signed anchor credential.
+	VC []byte `json:"vc,omitempty"`
+	// CID is the CID of the anchor credential in the anchor graph.
+	CID string `json:"cid,omitempty"`
+	// Conflicts is the number of times that the anchor was rebuilt because the transaction references
+	// of its DIDs were updated by another anchor.
+	Conflicts int `json:"conflicts,omitempty"`
+	// Error is the last error that occurred while processing the anchor.
+	Error string `json:"error,omitempty"`
+	// Failures is the number of times that processing of the anchor failed.
+	Failures int `json:"failures,omitempty"`
+	// NextRetry is the time after which the anchor is processed again (set when processing of the anchor failed).
+	NextRetry *time.Time `json:"nextRetry,omitempty"`
+}
+
+// Store persists the anchors that are being written so that anchors which were interrupted (e.g. by a crash)
+// may be resumed at startup.
+type Store struct {
+	store storage.Store
+}
+
+// New returns a new pending anchor store.
+func New(provider storage.Provider) (*Store, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pending anchor store: %w", err)
+	}
+
+	return &Store{store: store}, nil
+}
+
+// Put saves the given pending anchor.
+func (s *Store) Put(anchor *Anchor) error {
+	anchorBytes, err := json.Marshal(anchor)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pending anchor [%s]: %w", anchor.ID, err)
+	}
+
+	err = s.store.Put(keyPrefix+anchor.ID, anchorBytes)
+	if err != nil {
+		return fmt.Errorf("failed to store pending anchor [%s]: %w", anchor.ID, err)
+	}
+
+	return nil
+}
+
+// Get returns the pending anchor with the given ID.
+func (s *Store) Get(id string) (*Anchor, error) {
+	anchorBytes, err := s.store.Get(keyPrefix + id)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get pending anchor [%s]: %w", id, err)
+	}
+
+	anchor := &Anchor{}
+
+	err = json.Unmarshal(anchorBytes, anchor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pending anchor [%s]: %w", id, err)
+	}
+
+	return anchor, nil
+}
+
+// Delete removes the pending anchor with the given ID.
+func (s *Store) Delete(id string) error {
+	err := s.store.Delete(keyPrefix + id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending anchor [%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// GetAll returns all pending anchors.
+func (s *Store) GetAll() ([]*Anchor, error) {
+	var values [][]byte
+
+	it := s.store.Iterator(keyPrefix, keyPrefix+storage.EndKeySuffix)
+
+	for it.Next() {
+		values = append(values, it.Value())
+	}
+
+	err := it.Error()
+
+	it.Release()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate pending anchors: %w", err)
+	}
+
+	anchors := make([]*Anchor, len(values))
+
+	for i, value := range values {
+		anchor := &Anchor{}
+
+		err = json.Unmarshal(value, anchor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal pending anchor: %w", err)
+		}
+
+		anchors[i] = anchor
+	}
+
+	return anchors, nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pending
+
+import (
+	"errors"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+	"github.com/trustbloc/orb/pkg/anchor/txn"
+)
+
+const anchorID = "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt"
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		s, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open pending anchor store: open error")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore(t *testing.T) {
+	anchor := &Anchor{
+		ID:    anchorID,
+		State: StateSigned,
+		Payload: &txn.Payload{
+			AnchorString:         anchorID,
+			PreviousTransactions: map[string]string{"suffix1": "prev"},
+		},
+		DIDs: []*didindex.DID{{Suffix: "suffix1", Type: operation.TypeUpdate}},
+		VCID: "https://orb.domain.com/vc/1234",
+		VC:   []byte(`{"id":"https://orb.domain.com/vc/1234"}`),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		anchors, err := s.GetAll()
+		require.NoError(t, err)
+		require.Empty(t, anchors)
+
+		require.NoError(t, s.Put(anchor))
+
+		anchors, err = s.GetAll()
+		require.NoError(t, err)
+		require.Equal(t, []*Anchor{anchor}, anchors)
+
+		value, err := s.Get(anchorID)
+		require.NoError(t, err)
+		require.Equal(t, anchor, value)
+
+		require.NoError(t, s.Delete(anchorID))
+
+		anchors, err = s.GetAll()
+		require.NoError(t, err)
+		require.Empty(t, anchors)
+
+		value, err = s.Get(anchorID)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, value)
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		value, err := s.Get(anchorID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get pending anchor")
+		require.Nil(t, value)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		err = s.Put(anchor)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to store pending anchor")
+	})
+
+	t.Run("error - delete", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrDelete = errors.New("delete error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		err = s.Delete(anchorID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to delete pending anchor")
+	})
+
+	t.Run("error - iterator", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrItr = errors.New("iterator error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		anchors, err := s.GetAll()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to iterate pending anchors")
+		require.Nil(t, anchors)
+	})
+
+	t.Run("error - invalid stored value", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[keyPrefix+anchorID] = []byte("invalid")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		value, err := s.Get(anchorID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal pending anchor")
+		require.Nil(t, value)
+
+		anchors, err := s.GetAll()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal pending anchor")
+		require.Nil(t, anchors)
+	})
+}
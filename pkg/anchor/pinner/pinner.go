@@ -0,0 +1,173 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/trustbloc/edge-core/pkg/log"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+
+	"github.com/trustbloc/orb/pkg/anchor/walker"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-pinner")
+
+type txnGraph interface {
+	Read(ctx context.Context, cid string) (*verifiable.Credential, error)
+}
+
+// Providers contains the providers required by the pinner.
+type Providers struct {
+	CAS                    casapi.Client
+	Pins                   cas.Pinner
+	TxnGraph               txnGraph
+	ProtocolClientProvider protocol.ClientProvider
+}
+
+// Pinner pins anchors along with the Sidetree batch files (core index, core proof, provisional index,
+// provisional proof and chunk files) that are referenced by the anchors, so that the anchor history
+// is retained by CAS (i.e. not removed by IPFS garbage collection).
+type Pinner struct {
+	pins   cas.Pinner
+	walker *walker.Walker
+}
+
+// Status is the pin status of an anchor or of a batch file.
+type Status struct {
+	walker.Item
+	Pinned bool `json:"pinned"`
+}
+
+// Report contains the pin status of all of the anchors (and their batch files) of the anchor graph
+// that starts at the head anchor.
+type Report struct {
+	Head string `json:"head"`
+	// Complete indicates that all of the anchors and batch files are pinned and verified.
+	Complete bool      `json:"complete"`
+	Items    []*Status `json:"items"`
+}
+
+// New returns a new anchor pinner.
+func New(providers *Providers) *Pinner {
+	return &Pinner{
+		pins: providers.Pins,
+		walker: walker.New(&walker.Providers{
+			CAS:                    providers.CAS,
+			TxnGraph:               providers.TxnGraph,
+			ProtocolClientProvider: providers.ProtocolClientProvider,
+		}),
+	}
+}
+
+// PinAnchor pins the anchor with the given CID along with the batch files that it references.
+func (p *Pinner) PinAnchor(ctx context.Context, anchorCID string) error {
+	items, _ := p.walker.Anchor(ctx, anchorCID, false)
+
+	if !items[0].Verified {
+		return fmt.Errorf("failed to read anchor [%s]: %s", anchorCID, items[0].Error)
+	}
+
+	// Pin whatever is known even if some of the batch files couldn't be resolved.
+	var errs []string
+
+	for _, item := range items {
+		if item.CID != "" {
+			err := p.pins.Pin(ctx, item.CID)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("failed to pin %s [%s]: %s", item.Type, item.CID, err))
+			}
+		}
+
+		if item.Error != "" {
+			errs = append(errs, fmt.Sprintf("failed to get %s file [%s]: %s", item.Type, item.CID, item.Error))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to pin anchor [%s]: %s", anchorCID, strings.Join(errs, "; "))
+	}
+
+	logger.Debugf("pinned anchor [%s] and %d batch files", anchorCID, len(items)-1)
+
+	return nil
+}
+
+// Check walks the anchor graph starting at the given head anchor and reports the pin status of every anchor
+// and batch file. Every anchor and batch file is read from CAS and verified against its CID.
+func (p *Pinner) Check(ctx context.Context, head string) (*Report, error) {
+	return p.walk(ctx, head, false)
+}
+
+// Repin walks the anchor graph starting at the given head anchor and (re-)pins every anchor and batch file.
+// Returns the pin status of every anchor and batch file after pinning.
+func (p *Pinner) Repin(ctx context.Context, head string) (*Report, error) {
+	return p.walk(ctx, head, true)
+}
+
+func (p *Pinner) walk(ctx context.Context, head string, repin bool) (*Report, error) {
+	err := cas.ValidateCID(head)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Head: head, Complete: true}
+
+	err = p.walker.Walk(ctx, []string{head}, func(items []*walker.Item) error {
+		for _, item := range items {
+			status := &Status{Item: *item}
+
+			p.pin(ctx, status, repin)
+
+			if !status.Pinned || !status.Verified {
+				report.Complete = false
+			}
+
+			report.Items = append(report.Items, status)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (p *Pinner) pin(ctx context.Context, item *Status, repin bool) {
+	if item.CID == "" {
+		return
+	}
+
+	if repin {
+		err := p.pins.Pin(ctx, item.CID)
+		if err != nil {
+			logger.Warnf("failed to pin %s [%s]: %s", item.Type, item.CID, err)
+
+			if item.Error == "" {
+				item.Error = err.Error()
+			}
+		}
+	}
+
+	pinned, err := p.pins.IsPinned(ctx, item.CID)
+	if err != nil {
+		if item.Error == "" {
+			item.Error = err.Error()
+		}
+
+		return
+	}
+
+	item.Pinned = pinned
+}
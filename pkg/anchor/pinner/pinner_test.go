@@ -0,0 +1,213 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/pkg/anchor/anchortest"
+	"github.com/trustbloc/orb/pkg/anchor/walker"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+func TestPinner_PinAnchor(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+
+		require.NoError(t, f.pinner.PinAnchor(context.Background(), f.Anchor1))
+
+		require.Len(t, f.pins.pinned, 6)
+
+		for _, id := range f.Anchor1Files {
+			require.True(t, f.pins.pinned[id], id)
+		}
+	})
+
+	t.Run("error - anchor not found", func(t *testing.T) {
+		f := newFixture(t)
+
+		err := f.pinner.PinAnchor(context.Background(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read anchor")
+	})
+
+	t.Run("error - batch file not found", func(t *testing.T) {
+		f := newFixture(t)
+
+		anchorCID := anchortest.AddAnchor(t, f.TxnGraph, "1.Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", nil)
+
+		err := f.pinner.PinAnchor(context.Background(), anchorCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get coreIndex file")
+
+		// the anchor is pinned anyway
+		require.True(t, f.pins.pinned[anchorCID])
+	})
+
+	t.Run("error - pin error", func(t *testing.T) {
+		f := newFixture(t)
+		f.pins.err = errors.New("pin error")
+
+		err := f.pinner.PinAnchor(context.Background(), f.Anchor1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "pin error")
+	})
+}
+
+func TestPinner_CheckAndRepin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+
+		require.NoError(t, f.pinner.PinAnchor(context.Background(), f.Anchor2))
+
+		report, err := f.pinner.Check(context.Background(), f.Anchor2)
+		require.NoError(t, err)
+		require.Equal(t, f.Anchor2, report.Head)
+		require.False(t, report.Complete)
+		require.Len(t, report.Items, 8)
+
+		require.Equal(t, f.Anchor2, report.Items[0].CID)
+		require.Equal(t, walker.TypeAnchor, report.Items[0].Type)
+		require.True(t, report.Items[0].Pinned)
+		require.Equal(t, walker.TypeCoreIndex, report.Items[1].Type)
+		require.Equal(t, f.Anchor2, report.Items[1].Anchor)
+		require.True(t, report.Items[1].Pinned)
+		require.Equal(t, f.Anchor1, report.Items[2].CID)
+		require.False(t, report.Items[2].Pinned)
+
+		for _, item := range report.Items {
+			require.True(t, item.Verified, item.CID)
+			require.Empty(t, item.Error)
+		}
+
+		report, err = f.pinner.Repin(context.Background(), f.Anchor2)
+		require.NoError(t, err)
+		require.True(t, report.Complete)
+
+		for _, item := range report.Items {
+			require.True(t, item.Pinned, item.CID)
+		}
+	})
+
+	t.Run("missing batch file", func(t *testing.T) {
+		f := newFixture(t)
+
+		anchorCID := anchortest.AddAnchor(t, f.TxnGraph, "1.Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD",
+			map[string]string{anchortest.DID: f.Anchor2})
+
+		report, err := f.pinner.Repin(context.Background(), anchorCID)
+		require.NoError(t, err)
+		require.False(t, report.Complete)
+		require.Len(t, report.Items, 10)
+
+		require.Equal(t, walker.TypeCoreIndex, report.Items[1].Type)
+		require.False(t, report.Items[1].Verified)
+		require.Contains(t, report.Items[1].Error, "not found")
+	})
+
+	t.Run("missing anchor", func(t *testing.T) {
+		f := newFixture(t)
+
+		report, err := f.pinner.Check(context.Background(), "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.NoError(t, err)
+		require.False(t, report.Complete)
+		require.Len(t, report.Items, 1)
+		require.NotEmpty(t, report.Items[0].Error)
+	})
+
+	t.Run("error - invalid head CID", func(t *testing.T) {
+		f := newFixture(t)
+
+		report, err := f.pinner.Check(context.Background(), "invalid")
+		require.True(t, errors.Is(err, cas.ErrInvalidCID))
+		require.Nil(t, report)
+	})
+
+	t.Run("error - pin error", func(t *testing.T) {
+		f := newFixture(t)
+		f.pins.err = errors.New("pin error")
+
+		report, err := f.pinner.Repin(context.Background(), f.Anchor1)
+		require.NoError(t, err)
+		require.False(t, report.Complete)
+
+		for _, item := range report.Items {
+			require.Contains(t, item.Error, "pin error")
+		}
+	})
+
+	t.Run("error - context cancelled", func(t *testing.T) {
+		f := newFixture(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		report, err := f.pinner.Check(ctx, f.Anchor2)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, report)
+	})
+}
+
+type fixture struct {
+	*anchortest.Fixture
+	pinner    *Pinner
+	pins      *mockPinner
+	casClient *mocks.MockCasClient
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	casClient := mocks.NewMockCasClient(nil)
+	pins := &mockPinner{pinned: make(map[string]bool)}
+
+	f := anchortest.NewFixture(t, casClient)
+
+	return &fixture{
+		Fixture: f,
+		pinner: New(&Providers{
+			CAS:                    casClient,
+			Pins:                   pins,
+			TxnGraph:               f.TxnGraph,
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider(),
+		}),
+		pins:      pins,
+		casClient: casClient,
+	}
+}
+
+type mockPinner struct {
+	mutex  sync.Mutex
+	pinned map[string]bool
+	err    error
+}
+
+func (m *mockPinner) Pin(_ context.Context, id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+
+	m.pinned[id] = true
+
+	return nil
+}
+
+func (m *mockPinner) IsPinned(_ context.Context, id string) (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.pinned[id], nil
+}
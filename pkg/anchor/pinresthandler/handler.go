@@ -0,0 +1,115 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinresthandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/pinner"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-pin-handler")
+
+const (
+	// Path is the base path of the anchor pin endpoint.
+	Path = "/pins"
+
+	cidPathVariable = "cid"
+
+	jsonContentType = "application/json"
+)
+
+type anchorPinner interface {
+	Check(ctx context.Context, head string) (*pinner.Report, error)
+	Repin(ctx context.Context, head string) (*pinner.Report, error)
+}
+
+type walkFunc func(ctx context.Context, head string) (*pinner.Report, error)
+
+// Handler reports (GET) or re-pins (POST) the anchors and batch files of the anchor graph
+// that starts at the head anchor with the given CID.
+type Handler struct {
+	method string
+	walk   walkFunc
+}
+
+// NewCheckHandler returns a new handler that lists and verifies the anchors and batch files of the
+// anchor graph along with their pin status.
+func NewCheckHandler(p anchorPinner) *Handler {
+	return &Handler{method: http.MethodGet, walk: p.Check}
+}
+
+// NewRepinHandler returns a new handler that (re-)pins the anchors and batch files of the anchor graph.
+func NewRepinHandler(p anchorPinner) *Handler {
+	return &Handler{method: http.MethodPost, walk: p.Repin}
+}
+
+// Path returns the HTTP REST endpoint for the pin handler.
+func (h *Handler) Path() string {
+	return fmt.Sprintf("%s/{%s}", Path, cidPathVariable)
+}
+
+// Method returns the HTTP REST method for the pin handler.
+func (h *Handler) Method() string {
+	return h.method
+}
+
+// Handler returns the HTTP REST handler for the pin handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, req *http.Request) {
+	head := mux.Vars(req)[cidPathVariable]
+
+	report, err := h.walk(req.Context(), head)
+	if err != nil {
+		if errors.Is(err, cas.ErrInvalidCID) {
+			writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+			return
+		}
+
+		logger.Errorf("Error walking anchor graph from [%s]: %s", head, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	reportBytes, err := json.Marshal(report)
+	if err != nil {
+		logger.Errorf("Error marshalling pin report for [%s]: %s", head, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	writeResponse(w, http.StatusOK, jsonContentType, reportBytes)
+}
+
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pinresthandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/anchor/pinner"
+	"github.com/trustbloc/orb/pkg/anchor/walker"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+const headCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestHandler(t *testing.T) {
+	p := &mockPinner{}
+
+	t.Run("check", func(t *testing.T) {
+		h := NewCheckHandler(p)
+		require.Equal(t, "/pins/{cid}", h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, headCID)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		report := &pinner.Report{}
+		require.NoError(t, json.Unmarshal(readBody(t, rw), report))
+		require.Equal(t, headCID, report.Head)
+		require.False(t, report.Complete)
+		require.Len(t, report.Items, 1)
+		require.False(t, report.Items[0].Pinned)
+	})
+
+	t.Run("repin", func(t *testing.T) {
+		h := NewRepinHandler(p)
+		require.Equal(t, "/pins/{cid}", h.Path())
+		require.Equal(t, http.MethodPost, h.Method())
+
+		rw := serve(h, headCID)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		report := &pinner.Report{}
+		require.NoError(t, json.Unmarshal(readBody(t, rw), report))
+		require.True(t, report.Complete)
+		require.True(t, report.Items[0].Pinned)
+	})
+
+	t.Run("invalid CID", func(t *testing.T) {
+		rw := serve(NewCheckHandler(&mockPinner{err: fmt.Errorf("%w [xxx]", cas.ErrInvalidCID)}), "xxx")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, string(readBody(t, rw)), "invalid CID")
+	})
+
+	t.Run("pinner error", func(t *testing.T) {
+		rw := serve(NewRepinHandler(&mockPinner{err: errors.New("injected error")}), headCID)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+type mockPinner struct {
+	err error
+}
+
+func (m *mockPinner) Check(_ context.Context, head string) (*pinner.Report, error) {
+	return m.report(head, false)
+}
+
+func (m *mockPinner) Repin(_ context.Context, head string) (*pinner.Report, error) {
+	return m.report(head, true)
+}
+
+func (m *mockPinner) report(head string, pinned bool) (*pinner.Report, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &pinner.Report{
+		Head:     head,
+		Complete: pinned,
+		Items: []*pinner.Status{
+			{Item: walker.Item{CID: head, Type: walker.TypeAnchor, Verified: true}, Pinned: pinned},
+		},
+	}, nil
+}
+
+func serve(h *Handler, cid string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(h.Method(), Path+"/"+cid, nil))
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return body
+}
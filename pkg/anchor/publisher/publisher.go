@@ -0,0 +1,321 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+var logger = log.New("anchor-publisher")
+
+const (
+	defaultBufferSize   = 100
+	defaultRetryBackoff = time.Second
+)
+
+// ErrStopped is returned by Publish if the publisher was stopped while waiting for space in the buffer.
+var ErrStopped = errors.New("publisher stopped")
+
+// ErrBufferFull is returned by Publish if the buffer is full and the overflow policy is OverflowError.
+var ErrBufferFull = errors.New("publisher buffer is full")
+
+// OverflowPolicy determines what happens to an anchor that's published while the buffer of the
+// in-memory publisher is full (i.e. the subscriber has stalled).
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the publisher until there's space in the buffer (or until the context is done).
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowSpill persists the anchor in a queue from which it's delivered (in order) once the subscriber
+	// catches up.
+	OverflowSpill OverflowPolicy = "spill"
+	// OverflowError returns ErrBufferFull to the caller, which remains responsible for the anchor (e.g. the
+	// writer keeps the anchor pending and publishes it again after a backoff).
+	OverflowError OverflowPolicy = "error"
+)
+
+// Publisher publishes the CIDs of the anchors that are written by this node to the subscriber (i.e. the observer).
+// The in-memory publisher (New) delivers anchors through a buffered channel and applies the overflow policy when
+// the buffer is full. The durable publisher (NewDurable) persists each anchor before it's delivered, so that
+// anchors that haven't been handed to the subscriber are delivered after a restart.
+type Publisher struct {
+	ch           chan []string
+	policy       OverflowPolicy
+	queue        *queue
+	durable      bool
+	bufferSize   int
+	retryBackoff time.Duration
+	mutex        sync.Mutex
+	notify       chan struct{}
+	done         chan struct{}
+	stopOnce     sync.Once
+	provider     storage.Provider
+}
+
+// Opt is a publisher option.
+type Opt func(p *Publisher)
+
+// WithBufferSize sets the size of the in-memory buffer. The durable publisher defaults to an unbuffered channel
+// so that an anchor is only removed from the queue once it's received by the subscriber.
+func WithBufferSize(size int) Opt {
+	return func(p *Publisher) {
+		p.bufferSize = size
+	}
+}
+
+// WithOverflowPolicy sets the policy that's applied when the in-memory buffer is full. The policy is ignored by
+// the durable publisher (which never overflows).
+func WithOverflowPolicy(policy OverflowPolicy) Opt {
+	return func(p *Publisher) {
+		p.policy = policy
+	}
+}
+
+// WithSpillStore sets the storage provider of the queue to which anchors are spilled. It's required by the
+// spill overflow policy.
+func WithSpillStore(provider storage.Provider) Opt {
+	return func(p *Publisher) {
+		p.provider = provider
+	}
+}
+
+// WithRetryBackoff sets the time to wait before retrying to read from the queue after an error.
+func WithRetryBackoff(backoff time.Duration) Opt {
+	return func(p *Publisher) {
+		p.retryBackoff = backoff
+	}
+}
+
+// New returns a new in-memory publisher.
+func New(opts ...Opt) (*Publisher, error) {
+	p := newPublisher(defaultBufferSize, opts...)
+
+	switch p.policy {
+	case OverflowBlock, OverflowError:
+		return p, nil
+	case OverflowSpill:
+		if p.provider == nil {
+			return nil, errors.New("a spill store is required for overflow policy [spill]")
+		}
+
+		var err error
+
+		p.queue, err = newQueue(p.provider)
+		if err != nil {
+			return nil, err
+		}
+
+		return p, nil
+	default:
+		return nil, fmt.Errorf("unsupported overflow policy [%s]", p.policy)
+	}
+}
+
+// NewDurable returns a new publisher that persists anchors (using the given storage provider) until they're
+// received by the subscriber.
+func NewDurable(provider storage.Provider, opts ...Opt) (*Publisher, error) {
+	p := newPublisher(0, opts...)
+
+	q, err := newQueue(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	p.queue = q
+	p.durable = true
+
+	return p, nil
+}
+
+func newPublisher(bufferSize int, opts ...Opt) *Publisher {
+	p := &Publisher{
+		policy:       OverflowBlock,
+		bufferSize:   bufferSize,
+		retryBackoff: defaultRetryBackoff,
+		notify:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.ch = make(chan []string, p.bufferSize)
+
+	return p
+}
+
+// Start starts delivering queued anchors (including anchors that were queued before the last shutdown).
+func (p *Publisher) Start() {
+	if p.queue != nil {
+		go p.deliver()
+	}
+}
+
+// Stop stops the publisher. Publish calls that are blocked return ErrStopped.
+func (p *Publisher) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// RegisterForOrbTxn returns the channel over which anchor CIDs are delivered.
+func (p *Publisher) RegisterForOrbTxn() <-chan []string {
+	return p.ch
+}
+
+// QueueDepth returns the number of anchors that were published but not yet received by the subscriber.
+func (p *Publisher) QueueDepth() int {
+	depth := len(p.ch)
+
+	if p.queue != nil {
+		depth += p.queue.len()
+	}
+
+	return depth
+}
+
+// Publish publishes the CID of an anchor.
+func (p *Publisher) Publish(ctx context.Context, cid string) error {
+	if p.durable {
+		return p.enqueue(cid)
+	}
+
+	published, err := p.tryPublish(cid)
+	if err != nil || published {
+		return err
+	}
+
+	switch p.policy {
+	case OverflowError:
+		logger.Debugf("Buffer is full; rejecting anchor [%s]", cid)
+
+		return fmt.Errorf("%w: anchor [%s]", ErrBufferFull, cid)
+	default:
+		logger.Debugf("Buffer is full; waiting to publish anchor [%s]", cid)
+
+		select {
+		case p.ch <- []string{cid}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.done:
+			return ErrStopped
+		}
+	}
+}
+
+// tryPublish publishes the anchor without blocking and returns false if the buffer is full (unless the anchor was
+// spilled). Anchors are spilled while the queue isn't empty so that they're delivered in order.
+func (p *Publisher) tryPublish(cid string) (bool, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.queue != nil && p.queue.len() > 0 {
+		return true, p.enqueue(cid)
+	}
+
+	select {
+	case p.ch <- []string{cid}:
+		return true, nil
+	default:
+	}
+
+	if p.policy == OverflowSpill {
+		logger.Infof("Buffer is full; spilling anchor [%s]", cid)
+
+		return true, p.enqueue(cid)
+	}
+
+	return false, nil
+}
+
+func (p *Publisher) enqueue(cid string) error {
+	err := p.queue.push(cid)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// deliver sends the queued anchors to the subscriber (in order). An anchor is removed from the queue once it has
+// been sent, so an anchor may be delivered twice if the publisher is stopped before the anchor is removed.
+func (p *Publisher) deliver() {
+	for {
+		key, cid, err := p.queue.peek()
+		if err != nil {
+			if !errors.Is(err, errQueueEmpty) {
+				logger.Errorf("Error reading publisher queue: %s", err)
+			}
+
+			if !p.wait(err) {
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case p.ch <- []string{cid}:
+		case <-p.done:
+			return
+		}
+
+		if !p.remove(key, cid) {
+			return
+		}
+	}
+}
+
+// remove removes the delivered anchor from the queue, retrying on failure, and returns false if the publisher was
+// stopped (in which case the anchor is delivered again after a restart).
+func (p *Publisher) remove(key, cid string) bool {
+	for {
+		err := p.queue.remove(key)
+		if err == nil {
+			return true
+		}
+
+		logger.Errorf("Error removing delivered anchor [%s] from publisher queue: %s", cid, err)
+
+		if !p.wait(err) {
+			return false
+		}
+	}
+}
+
+// wait waits until an anchor is queued (or until the retry backoff has elapsed after an error) and returns
+// false if the publisher was stopped.
+func (p *Publisher) wait(err error) bool {
+	var retry <-chan time.Time
+
+	if !errors.Is(err, errQueueEmpty) {
+		retry = time.After(p.retryBackoff)
+	}
+
+	select {
+	case <-p.notify:
+	case <-retry:
+	case <-p.done:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,260 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cid1 = "bafkreiarkubvukdidicmqynkyls3iqawdqvthi7e6mbky2amuw3inxsi3y"
+	cid2 = "bafkreibmrmenuxhgaomod2luojctmz7ngwuz7sgafhevnhbx3hhtecqs3e"
+	cid3 = "bafkreihwsnuregceqh263vgdathcprnbvatyat6h6mu7ipjhhodcdbyhoy"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := New()
+		require.NoError(t, err)
+		require.Equal(t, OverflowBlock, p.policy)
+		require.Equal(t, defaultBufferSize, cap(p.ch))
+		require.Nil(t, p.queue)
+
+		p, err = New(WithOverflowPolicy(OverflowError), WithBufferSize(10), WithRetryBackoff(time.Millisecond))
+		require.NoError(t, err)
+		require.Equal(t, OverflowError, p.policy)
+		require.Equal(t, 10, cap(p.ch))
+		require.Equal(t, time.Millisecond, p.retryBackoff)
+
+		p, err = New(WithOverflowPolicy(OverflowSpill), WithSpillStore(mem.NewProvider()))
+		require.NoError(t, err)
+		require.NotNil(t, p.queue)
+	})
+
+	t.Run("error - spill store not set", func(t *testing.T) {
+		p, err := New(WithOverflowPolicy(OverflowSpill))
+		require.EqualError(t, err, "a spill store is required for overflow policy [spill]")
+		require.Nil(t, p)
+	})
+
+	t.Run("error - unsupported policy", func(t *testing.T) {
+		p, err := New(WithOverflowPolicy("unknown"))
+		require.EqualError(t, err, "unsupported overflow policy [unknown]")
+		require.Nil(t, p)
+	})
+
+	t.Run("error - open spill store", func(t *testing.T) {
+		p, err := New(WithOverflowPolicy(OverflowSpill),
+			WithSpillStore(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")}))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open publisher queue store: open error")
+		require.Nil(t, p)
+	})
+}
+
+func TestNewDurable(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := NewDurable(mem.NewProvider())
+		require.NoError(t, err)
+		require.True(t, p.durable)
+		require.Equal(t, 0, cap(p.ch))
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		p, err := NewDurable(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open publisher queue store: open error")
+		require.Nil(t, p)
+	})
+
+	t.Run("error - iterator", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrItr = errors.New("iterator error")
+
+		p, err := NewDurable(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to iterate publisher queue: iterator error")
+		require.Nil(t, p)
+	})
+
+	t.Run("error - invalid key", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[keyPrefix+"invalid"] = []byte(cid1)
+
+		p, err := NewDurable(provider)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid publisher queue key")
+		require.Nil(t, p)
+	})
+}
+
+func TestPublisher_Block(t *testing.T) {
+	p, err := New(WithBufferSize(1))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Publish(context.Background(), cid1))
+	require.Equal(t, 1, p.QueueDepth())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = p.Publish(ctx, cid2)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+
+		<-p.RegisterForOrbTxn()
+	}()
+
+	// blocks until the first anchor is received
+	require.NoError(t, p.Publish(context.Background(), cid2))
+	require.Equal(t, []string{cid2}, receive(t, p))
+
+	require.NoError(t, p.Publish(context.Background(), cid3))
+
+	p.Stop()
+
+	err = p.Publish(context.Background(), cid1)
+	require.True(t, errors.Is(err, ErrStopped))
+}
+
+func TestPublisher_Error(t *testing.T) {
+	p, err := New(WithBufferSize(1), WithOverflowPolicy(OverflowError))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Publish(context.Background(), cid1))
+
+	err = p.Publish(context.Background(), cid2)
+	require.True(t, errors.Is(err, ErrBufferFull))
+	require.Contains(t, err.Error(), cid2)
+
+	require.Equal(t, 1, p.QueueDepth())
+
+	require.Equal(t, []string{cid1}, receive(t, p))
+	require.Empty(t, p.RegisterForOrbTxn())
+
+	// published once the subscriber has caught up
+	require.NoError(t, p.Publish(context.Background(), cid2))
+	require.Equal(t, []string{cid2}, receive(t, p))
+}
+
+func TestPublisher_Spill(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		p, err := New(WithBufferSize(1), WithOverflowPolicy(OverflowSpill), WithSpillStore(mem.NewProvider()))
+		require.NoError(t, err)
+
+		p.Start()
+		defer p.Stop()
+
+		require.NoError(t, p.Publish(context.Background(), cid1))
+		require.NoError(t, p.Publish(context.Background(), cid2))
+		require.NoError(t, p.Publish(context.Background(), cid3))
+
+		// the first anchor is in the buffer and the delivery of the second anchor (from the queue) is blocked
+		require.Equal(t, 3, p.QueueDepth())
+
+		require.Equal(t, []string{cid1}, receive(t, p))
+		require.Equal(t, []string{cid2}, receive(t, p))
+		require.Equal(t, []string{cid3}, receive(t, p))
+
+		require.Eventually(t, func() bool {
+			return p.queue.len() == 0
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, p.Publish(context.Background(), cid1))
+		require.Equal(t, []string{cid1}, receive(t, p))
+	})
+
+	t.Run("error - store error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		p, err := New(WithBufferSize(1), WithOverflowPolicy(OverflowSpill), WithSpillStore(provider))
+		require.NoError(t, err)
+
+		require.NoError(t, p.Publish(context.Background(), cid1))
+
+		err = p.Publish(context.Background(), cid2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to add anchor")
+	})
+}
+
+func TestPublisher_Durable(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		p, err := NewDurable(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, p.Publish(context.Background(), cid1))
+		require.NoError(t, p.Publish(context.Background(), cid2))
+		require.Equal(t, 2, p.QueueDepth())
+
+		// restart
+		p.Stop()
+
+		p, err = NewDurable(provider)
+		require.NoError(t, err)
+		require.Equal(t, 2, p.QueueDepth())
+
+		p.Start()
+		defer p.Stop()
+
+		require.NoError(t, p.Publish(context.Background(), cid3))
+
+		require.Equal(t, []string{cid1}, receive(t, p))
+		require.Equal(t, []string{cid2}, receive(t, p))
+		require.Equal(t, []string{cid3}, receive(t, p))
+
+		require.Eventually(t, func() bool {
+			return p.QueueDepth() == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("error - remove error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrDelete = errors.New("delete error")
+
+		p, err := NewDurable(provider, WithRetryBackoff(time.Millisecond))
+		require.NoError(t, err)
+
+		require.NoError(t, p.Publish(context.Background(), cid1))
+
+		p.Start()
+
+		require.Equal(t, []string{cid1}, receive(t, p))
+
+		time.Sleep(10 * time.Millisecond)
+
+		p.Stop()
+
+		// the anchor remains in the queue
+		require.Equal(t, 1, p.QueueDepth())
+	})
+}
+
+func receive(t *testing.T, p *Publisher) []string {
+	t.Helper()
+
+	select {
+	case cids := <-p.RegisterForOrbTxn():
+		return cids
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for anchor")
+
+		return nil
+	}
+}
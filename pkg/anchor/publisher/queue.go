@@ -0,0 +1,140 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package publisher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	nameSpace = "anchorpublisher"
+	keyPrefix = "cid_"
+
+	// the sequence number is zero-padded so that the keys are iterated in insertion order
+	keyFormat = keyPrefix + "%020d"
+)
+
+var errQueueEmpty = errors.New("queue is empty")
+
+// queue is a persistent FIFO queue of anchor CIDs.
+type queue struct {
+	store  storage.Store
+	mutex  sync.Mutex
+	next   uint64
+	length int
+}
+
+func newQueue(provider storage.Provider) (*queue, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open publisher queue store: %w", err)
+	}
+
+	q := &queue{store: store}
+
+	// anchors that were queued before the last shutdown are delivered first
+	keys, err := q.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	q.length = len(keys)
+
+	if q.length > 0 {
+		var last uint64
+
+		_, err = fmt.Sscanf(keys[q.length-1], keyFormat, &last)
+		if err != nil {
+			return nil, fmt.Errorf("invalid publisher queue key [%s]: %w", keys[q.length-1], err)
+		}
+
+		q.next = last + 1
+	}
+
+	return q, nil
+}
+
+// push adds the given CID to the end of the queue.
+func (q *queue) push(cid string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	key := fmt.Sprintf(keyFormat, q.next)
+
+	err := q.store.Put(key, []byte(cid))
+	if err != nil {
+		return fmt.Errorf("failed to add anchor [%s] to publisher queue: %w", cid, err)
+	}
+
+	q.next++
+	q.length++
+
+	return nil
+}
+
+// peek returns the key and CID at the front of the queue or errQueueEmpty if the queue is empty.
+func (q *queue) peek() (string, string, error) {
+	it := q.store.Iterator(keyPrefix, keyPrefix+storage.EndKeySuffix)
+	defer it.Release()
+
+	if !it.Next() {
+		if err := it.Error(); err != nil {
+			return "", "", fmt.Errorf("failed to iterate publisher queue: %w", err)
+		}
+
+		return "", "", errQueueEmpty
+	}
+
+	return string(it.Key()), string(it.Value()), nil
+}
+
+// remove removes the entry with the given key (returned by peek) from the queue.
+func (q *queue) remove(key string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	err := q.store.Delete(key)
+	if err != nil {
+		return fmt.Errorf("failed to remove [%s] from publisher queue: %w", key, err)
+	}
+
+	q.length--
+
+	return nil
+}
+
+// len returns the number of entries in the queue.
+func (q *queue) len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.length
+}
+
+func (q *queue) keys() ([]string, error) {
+	var keys []string
+
+	it := q.store.Iterator(keyPrefix, keyPrefix+storage.EndKeySuffix)
+
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+
+	err := it.Error()
+
+	it.Release()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate publisher queue: %w", err)
+	}
+
+	return keys, nil
+}
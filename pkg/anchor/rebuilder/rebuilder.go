@@ -0,0 +1,444 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rebuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	txnapi "github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/didtxnref"
+	"github.com/trustbloc/orb/pkg/txnprocessor"
+)
+
+var logger = log.New("anchor-rebuilder")
+
+const (
+	nameSpace = "rebuildindex"
+
+	// checkpointPrefix is the prefix of the keys of the anchors that have been indexed.
+	checkpointPrefix = "anchor_"
+
+	// maxUpdateAttempts is the number of times that the references of a DID are updated if they were
+	// concurrently modified (e.g. by the writer or the observer).
+	maxUpdateAttempts = 5
+)
+
+type txnGraph interface {
+	Read(ctx context.Context, cid string) (*verifiable.Credential, error)
+}
+
+type didTxns interface {
+	Get(did string) ([]string, error)
+	ReplaceIfUnchanged(did string, expected, cids []string) error
+}
+
+type operationStore interface {
+	Get(suffix string) ([]*operation.AnchoredOperation, error)
+}
+
+// Providers contains the providers required by the rebuilder.
+type Providers struct {
+	TxnGraph               txnGraph
+	DidTxns                didTxns
+	OpStore                operationStore
+	ProtocolClientProvider protocol.ClientProvider
+	// Store is used to persist the anchors that have been indexed so that an interrupted rebuild may be resumed.
+	Store storage.Provider
+}
+
+// Progress contains the progress of a rebuild.
+type Progress struct {
+	// Total is the total number of anchors that are reachable from the head anchors.
+	Total int
+	// Indexed is the number of anchors that were indexed by this rebuild.
+	Indexed int
+	// Skipped is the number of anchors that were already indexed by a previous (interrupted) rebuild.
+	Skipped int
+	// Current is the CID of the anchor that was just indexed (or skipped).
+	Current string
+}
+
+// Rebuilder rebuilds the DID/transaction references and the operation store from the anchor graph.
+type Rebuilder struct {
+	*Providers
+	checkpoints storage.Store
+	onProgress  func(progress Progress)
+}
+
+// Opt is a rebuilder option.
+type Opt func(r *Rebuilder)
+
+// WithProgressHandler sets a handler that is invoked after every anchor with the progress of the rebuild.
+func WithProgressHandler(handler func(progress Progress)) Opt {
+	return func(r *Rebuilder) {
+		r.onProgress = handler
+	}
+}
+
+// New returns a new rebuilder.
+func New(providers *Providers, opts ...Opt) (*Rebuilder, error) {
+	checkpoints, err := providers.Store.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rebuild checkpoint store: %w", err)
+	}
+
+	r := &Rebuilder{
+		Providers:   providers,
+		checkpoints: checkpoints,
+		onProgress:  func(Progress) {},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r, nil
+}
+
+// Rebuild indexes every anchor that is reachable from the given head anchors in causal order, i.e. an anchor is
+// only indexed after all of its previous anchors have been indexed. The operations of each anchor are added to
+// the operation store and the anchor is added to the DID/transaction references of every DID in the anchor.
+// The anchor is inserted into the references of a DID in causal order, i.e. before any references that were
+// already stored and that are causally after the anchor. Stored references that are not reachable from the heads
+// (e.g. anchors that were written after the rebuild was started) are considered to be after all rebuilt anchors.
+// Anchors that were indexed by a previous rebuild are skipped so an interrupted rebuild may be resumed by
+// invoking Rebuild again (call Reset to start from scratch). Indexing an anchor is idempotent, so anchors that
+// were already indexed by the observer or the writer are not indexed twice.
+// The rebuild stops if the given context is done.
+func (r *Rebuilder) Rebuild(ctx context.Context, heads []string) (*Progress, error) {
+	anchors, payloads, err := r.order(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("rebuilding index from %d anchors that are reachable from heads %s", len(anchors), heads)
+
+	progress := &Progress{Total: len(anchors)}
+
+	positions := make(map[string]int, len(anchors))
+
+	for i, anchorCID := range anchors {
+		positions[anchorCID] = i
+	}
+
+	for _, anchorCID := range anchors {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		progress.Current = anchorCID
+
+		done, err := r.isIndexed(anchorCID)
+		if err != nil {
+			return nil, err
+		}
+
+		if done {
+			progress.Skipped++
+
+			r.onProgress(*progress)
+
+			continue
+		}
+
+		err = r.index(ctx, anchorCID, payloads[anchorCID], positions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to index anchor [%s]: %w", anchorCID, err)
+		}
+
+		err = r.checkpoints.Put(checkpointPrefix+anchorCID, []byte(time.Now().UTC().Format(time.RFC3339)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to store checkpoint for anchor [%s]: %w", anchorCID, err)
+		}
+
+		progress.Indexed++
+
+		r.onProgress(*progress)
+	}
+
+	logger.Infof("rebuilt index from %d anchors (%d indexed, %d already indexed)",
+		progress.Total, progress.Indexed, progress.Skipped)
+
+	return progress, nil
+}
+
+// Reset deletes the checkpoints of previous rebuilds so that the next rebuild indexes every anchor.
+func (r *Rebuilder) Reset() error {
+	var keys []string
+
+	it := r.checkpoints.Iterator(checkpointPrefix, checkpointPrefix+storage.EndKeySuffix)
+
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+
+	err := it.Error()
+
+	it.Release()
+
+	if err != nil {
+		return fmt.Errorf("failed to iterate rebuild checkpoints: %w", err)
+	}
+
+	for _, key := range keys {
+		err = r.checkpoints.Delete(key)
+		if err != nil {
+			return fmt.Errorf("failed to delete rebuild checkpoint [%s]: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// frame is an anchor on the depth-first traversal stack. The anchor is added to the ordered list of anchors
+// once all of its previous anchors have been added (i.e. when the anchor is popped after being expanded).
+type frame struct {
+	cid      string
+	expanded bool
+}
+
+// order returns the anchors that are reachable from the given heads in causal order (ancestors first) along
+// with their payloads.
+func (r *Rebuilder) order(ctx context.Context, heads []string) ([]string, map[string]*txn.Payload, error) {
+	payloads := make(map[string]*txn.Payload)
+	ordered := make(map[string]bool)
+
+	var anchors []string
+
+	var stack []*frame
+
+	for i := len(heads) - 1; i >= 0; i-- {
+		stack = append(stack, &frame{cid: heads[i]})
+	}
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+
+		if ordered[top.cid] {
+			stack = stack[:len(stack)-1]
+
+			continue
+		}
+
+		if top.expanded {
+			stack = stack[:len(stack)-1]
+			ordered[top.cid] = true
+			anchors = append(anchors, top.cid)
+
+			continue
+		}
+
+		payload, err := r.read(ctx, top.cid)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		payloads[top.cid] = payload
+		top.expanded = true
+
+		previous := previousAnchors(payload)
+
+		for i := len(previous) - 1; i >= 0; i-- {
+			if _, ok := payloads[previous[i]]; !ok {
+				stack = append(stack, &frame{cid: previous[i]})
+			}
+		}
+	}
+
+	return anchors, payloads, nil
+}
+
+func (r *Rebuilder) read(ctx context.Context, anchorCID string) (*txn.Payload, error) {
+	vc, err := r.TxnGraph.Read(ctx, anchorCID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anchor [%s]: %w", anchorCID, err)
+	}
+
+	payload, err := util.GetTransactionPayload(vc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payload of anchor [%s]: %w", anchorCID, err)
+	}
+
+	return payload, nil
+}
+
+// index adds the operations of the given anchor to the operation store (unless they were already added) and
+// adds the anchor to the DID/transaction references of the DIDs in the anchor.
+func (r *Rebuilder) index(ctx context.Context, anchorCID string, payload *txn.Payload,
+	positions map[string]int) error {
+	pc, err := r.ProtocolClientProvider.ForNamespace(payload.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get protocol client for namespace [%s]: %w", payload.Namespace, err)
+	}
+
+	v, err := pc.Get(payload.Version)
+	if err != nil {
+		return fmt.Errorf("failed to get protocol version [%d]: %w", payload.Version, err)
+	}
+
+	sidetreeTxn := txnapi.SidetreeTxn{
+		AnchorString:        payload.AnchorString,
+		Namespace:           payload.Namespace,
+		ProtocolGenesisTime: payload.Version,
+		Reference:           anchorCID,
+	}
+
+	ops, err := v.OperationProvider().GetTxnOperations(&sidetreeTxn)
+	if err != nil {
+		return fmt.Errorf("failed to get operations: %w", err)
+	}
+
+	stored, err := r.isStored(anchorCID, ops)
+	if err != nil {
+		return err
+	}
+
+	if !stored {
+		err = txnprocessor.ProcessWithContext(ctx, v.TransactionProcessor(), sidetreeTxn)
+		if err != nil {
+			return fmt.Errorf("failed to process operations: %w", err)
+		}
+	}
+
+	for _, op := range ops {
+		err = r.addDidTxn(op.UniqueSuffix, anchorCID, positions)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("indexed anchor [%s] with %d operations", anchorCID, len(ops))
+
+	return nil
+}
+
+// isStored returns true if the operations of the given anchor are already in the operation store.
+func (r *Rebuilder) isStored(anchorCID string, ops []*operation.AnchoredOperation) (bool, error) {
+	for _, op := range ops {
+		opsSoFar, err := r.OpStore.Get(op.UniqueSuffix)
+		if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+			return false, fmt.Errorf("failed to get operations for suffix [%s]: %w", op.UniqueSuffix, err)
+		}
+
+		if !containsReference(opsSoFar, anchorCID) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// addDidTxn inserts the anchor into the references of the given suffix in causal order (using the given positions
+// of the rebuilt anchors) and replaces the stored references. The update is attempted again if the references
+// were modified concurrently.
+func (r *Rebuilder) addDidTxn(suffix, anchorCID string, positions map[string]int) error {
+	var err error
+
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		refs, e := r.DidTxns.Get(suffix)
+		if e != nil && !errors.Is(e, didtxnref.ErrDidTransactionsNotFound) {
+			return fmt.Errorf("failed to get did transaction references for suffix [%s]: %w", suffix, e)
+		}
+
+		updated, ok := insertReference(refs, anchorCID, positions)
+		if !ok {
+			return nil
+		}
+
+		err = r.DidTxns.ReplaceIfUnchanged(suffix, refs, updated)
+		if !errors.Is(err, didtxnref.ErrDidTransactionConflict) {
+			break
+		}
+
+		logger.Debugf("did transaction references for suffix [%s] were modified concurrently (attempt %d): %s",
+			suffix, attempt, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to add did transaction reference for suffix [%s]: %w", suffix, err)
+	}
+
+	return nil
+}
+
+func (r *Rebuilder) isIndexed(anchorCID string) (bool, error) {
+	_, err := r.checkpoints.Get(checkpointPrefix + anchorCID)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get checkpoint for anchor [%s]: %w", anchorCID, err)
+	}
+
+	return true, nil
+}
+
+// previousAnchors returns the distinct (sorted) previous anchors of the given payload.
+func previousAnchors(payload *txn.Payload) []string {
+	prevMap := make(map[string]struct{})
+
+	for _, prev := range payload.PreviousTransactions {
+		prevMap[prev] = struct{}{}
+	}
+
+	var previous []string
+
+	for prev := range prevMap {
+		previous = append(previous, prev)
+	}
+
+	sort.Strings(previous)
+
+	return previous
+}
+
+// insertReference returns a copy of refs with anchorCID inserted before the first reference that is causally after
+// the anchor, i.e. a rebuilt anchor with a greater position or a reference that isn't a rebuilt anchor. False is
+// returned if refs already contains anchorCID.
+func insertReference(refs []string, anchorCID string, positions map[string]int) ([]string, bool) {
+	i := len(refs)
+
+	for j, ref := range refs {
+		if ref == anchorCID {
+			return nil, false
+		}
+
+		pos, ok := positions[ref]
+		if i == len(refs) && (!ok || pos > positions[anchorCID]) {
+			i = j
+		}
+	}
+
+	updated := make([]string, 0, len(refs)+1)
+	updated = append(updated, refs[:i]...)
+	updated = append(updated, anchorCID)
+
+	return append(updated, refs[i:]...), true
+}
+
+func containsReference(ops []*operation.AnchoredOperation, ref string) bool {
+	for _, op := range ops {
+		if op.Reference == ref {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,407 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rebuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/pkg/anchor/graph"
+	orbtxn "github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/didtxnref"
+	"github.com/trustbloc/orb/pkg/didtxnref/memdidtxnref"
+	orbmocks "github.com/trustbloc/orb/pkg/mocks"
+	"github.com/trustbloc/orb/pkg/txnprocessor"
+)
+
+const (
+	did1 = "did1"
+	did2 = "did2"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r, err := New(&Providers{Store: mem.NewProvider()})
+		require.NoError(t, err)
+		require.NotNil(t, r)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		r, err := New(&Providers{Store: &mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")}})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open rebuild checkpoint store: open error")
+		require.Nil(t, r)
+	})
+}
+
+func TestRebuilder_Rebuild(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+
+		var reported []Progress
+
+		r := f.rebuilder(t, WithProgressHandler(func(p Progress) {
+			reported = append(reported, p)
+		}))
+
+		progress, err := r.Rebuild(context.Background(), []string{f.anchor3})
+		require.NoError(t, err)
+		require.Equal(t, &Progress{Total: 3, Indexed: 3, Current: f.anchor3}, progress)
+
+		// anchors are indexed in causal order
+		require.Len(t, reported, 3)
+		require.Equal(t, f.anchor1, reported[0].Current)
+		require.Equal(t, f.anchor2, reported[1].Current)
+		require.Equal(t, f.anchor3, reported[2].Current)
+
+		f.requireIndexed(t)
+	})
+
+	t.Run("success - resume", func(t *testing.T) {
+		f := newFixture(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r := f.rebuilder(t, WithProgressHandler(func(p Progress) {
+			cancel()
+		}))
+
+		progress, err := r.Rebuild(ctx, []string{f.anchor3})
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, progress)
+
+		progress, err = f.rebuilder(t).Rebuild(context.Background(), []string{f.anchor3})
+		require.NoError(t, err)
+		require.Equal(t, 3, progress.Total)
+		require.Equal(t, 2, progress.Indexed)
+		require.Equal(t, 1, progress.Skipped)
+
+		f.requireIndexed(t)
+	})
+
+	t.Run("success - already indexed anchors are not indexed twice", func(t *testing.T) {
+		f := newFixture(t)
+
+		// the writer has already added the first anchor
+		require.NoError(t, f.didTxns.Add(did1, f.anchor1))
+
+		r := f.rebuilder(t)
+
+		_, err := r.Rebuild(context.Background(), []string{f.anchor3, f.anchor2})
+		require.NoError(t, err)
+
+		f.requireIndexed(t)
+
+		// rebuild from scratch
+		require.NoError(t, r.Reset())
+
+		progress, err := r.Rebuild(context.Background(), []string{f.anchor3})
+		require.NoError(t, err)
+		require.Equal(t, 3, progress.Indexed)
+
+		f.requireIndexed(t)
+	})
+
+	t.Run("success - rebuilt anchors are inserted before later anchors", func(t *testing.T) {
+		f := newFixture(t)
+
+		// the writer has already added a later anchor (which isn't reachable from the heads of the rebuild)
+		anchor4 := f.addAnchor(t, "2.anchor4", map[string]string{did1: f.anchor3, did2: f.anchor3}, did1, did2)
+
+		require.NoError(t, f.didTxns.Add(did1, f.anchor2))
+		require.NoError(t, f.didTxns.Add(did1, anchor4))
+		require.NoError(t, f.didTxns.Add(did2, anchor4))
+
+		_, err := f.rebuilder(t).Rebuild(context.Background(), []string{f.anchor3})
+		require.NoError(t, err)
+
+		refs, err := f.didTxns.Get(did1)
+		require.NoError(t, err)
+		require.Equal(t, []string{f.anchor1, f.anchor2, f.anchor3, anchor4}, refs)
+
+		last, err := f.didTxns.Last(did2)
+		require.NoError(t, err)
+		require.Equal(t, anchor4, last)
+	})
+
+	t.Run("success - references modified concurrently", func(t *testing.T) {
+		f := newFixture(t)
+
+		didTxns := &concurrentDidTxns{MemDidTxnRef: f.didTxns, conflicts: 2}
+
+		r, err := New(&Providers{
+			TxnGraph:               f.txnGraph,
+			DidTxns:                didTxns,
+			OpStore:                f.opStore,
+			ProtocolClientProvider: f.pcp,
+			Store:                  f.store,
+		})
+		require.NoError(t, err)
+
+		_, err = r.Rebuild(context.Background(), []string{f.anchor3})
+		require.NoError(t, err)
+
+		f.requireIndexed(t)
+	})
+
+	t.Run("error - references modified concurrently too many times", func(t *testing.T) {
+		f := newFixture(t)
+
+		r, err := New(&Providers{
+			TxnGraph:               f.txnGraph,
+			DidTxns:                &concurrentDidTxns{MemDidTxnRef: f.didTxns, conflicts: maxUpdateAttempts},
+			OpStore:                f.opStore,
+			ProtocolClientProvider: f.pcp,
+			Store:                  f.store,
+		})
+		require.NoError(t, err)
+
+		progress, err := r.Rebuild(context.Background(), []string{f.anchor3})
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+		require.Contains(t, err.Error(), "failed to add did transaction reference for suffix [did1]")
+		require.Nil(t, progress)
+	})
+
+	t.Run("error - anchor not found", func(t *testing.T) {
+		f := newFixture(t)
+
+		progress, err := f.rebuilder(t).Rebuild(context.Background(),
+			[]string{"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read anchor")
+		require.Nil(t, progress)
+	})
+
+	t.Run("error - operation provider error", func(t *testing.T) {
+		f := newFixture(t)
+		f.ops.err = errors.New("operation provider error")
+
+		progress, err := f.rebuilder(t).Rebuild(context.Background(), []string{f.anchor3})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "operation provider error")
+		require.Nil(t, progress)
+	})
+
+	t.Run("error - operation store error", func(t *testing.T) {
+		f := newFixture(t)
+
+		r, err := New(&Providers{
+			TxnGraph:               f.txnGraph,
+			DidTxns:                f.didTxns,
+			OpStore:                &errOperationStore{err: errors.New("get error")},
+			ProtocolClientProvider: f.pcp,
+			Store:                  f.store,
+		})
+		require.NoError(t, err)
+
+		progress, err := r.Rebuild(context.Background(), []string{f.anchor3})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get operations for suffix [did1]: get error")
+		require.Nil(t, progress)
+	})
+
+	t.Run("error - checkpoint store error", func(t *testing.T) {
+		f := newFixture(t)
+
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		r, err := New(&Providers{
+			TxnGraph:               f.txnGraph,
+			DidTxns:                f.didTxns,
+			OpStore:                f.opStore,
+			ProtocolClientProvider: f.pcp,
+			Store:                  provider,
+		})
+		require.NoError(t, err)
+
+		progress, err := r.Rebuild(context.Background(), []string{f.anchor3})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+		require.Nil(t, progress)
+	})
+}
+
+type fixture struct {
+	txnGraph *graph.Graph
+	didTxns  *memdidtxnref.MemDidTxnRef
+	opStore  *orbmocks.MockOperationStore
+	ops      *mockOperationProvider
+	pcp      *mocks.MockProtocolClientProvider
+	store    *mem.Provider
+	anchor1  string
+	anchor2  string
+	anchor3  string
+}
+
+// newFixture creates an anchor graph with three anchors: did1 is created in the first anchor, did1 is updated and
+// did2 is created in the second anchor and both DIDs are updated in the third anchor.
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	txnGraph := graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+	opStore := orbmocks.NewMockOperationStore()
+	ops := &mockOperationProvider{ops: make(map[string][]*operation.AnchoredOperation)}
+
+	pc := mocks.NewMockProtocolClient()
+	pc.Versions[0].OperationProviderReturns(ops)
+	pc.Versions[0].TransactionProcessorReturns(txnprocessor.New(&txnprocessor.Providers{
+		OpStore:                   opStore,
+		OperationProtocolProvider: ops,
+		TxnGraph:                  txnGraph,
+	}))
+
+	f := &fixture{
+		txnGraph: txnGraph,
+		didTxns:  memdidtxnref.New(),
+		opStore:  opStore,
+		ops:      ops,
+		pcp:      mocks.NewMockProtocolClientProvider().WithProtocolClient(mocks.DefaultNS, pc),
+		store:    mem.NewProvider(),
+	}
+
+	f.anchor1 = f.addAnchor(t, "1.anchor1", nil, did1)
+	f.anchor2 = f.addAnchor(t, "2.anchor2", map[string]string{did1: f.anchor1}, did1, did2)
+	f.anchor3 = f.addAnchor(t, "2.anchor3", map[string]string{did1: f.anchor2, did2: f.anchor2}, did1, did2)
+
+	return f
+}
+
+func (f *fixture) rebuilder(t *testing.T, opts ...Opt) *Rebuilder {
+	t.Helper()
+
+	r, err := New(&Providers{
+		TxnGraph:               f.txnGraph,
+		DidTxns:                f.didTxns,
+		OpStore:                f.opStore,
+		ProtocolClientProvider: f.pcp,
+		Store:                  f.store,
+	}, opts...)
+	require.NoError(t, err)
+
+	return r
+}
+
+func (f *fixture) addAnchor(t *testing.T, anchorString string, previous map[string]string, suffixes ...string) string {
+	t.Helper()
+
+	for _, suffix := range suffixes {
+		f.ops.ops[anchorString] = append(f.ops.ops[anchorString], &operation.AnchoredOperation{
+			Type:         operation.TypeUpdate,
+			UniqueSuffix: suffix,
+		})
+	}
+
+	vc := &verifiable.Credential{
+		Types:   []string{"VerifiableCredential"},
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		Subject: &orbtxn.Payload{
+			AnchorString:         anchorString,
+			Namespace:            mocks.DefaultNS,
+			PreviousTransactions: previous,
+		},
+		Issuer: verifiable.Issuer{ID: "http://orb.domain.com"},
+		Issued: &util.TimeWithTrailingZeroMsec{Time: time.Now()},
+	}
+
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	id, err := f.txnGraph.Add(context.Background(), vcBytes)
+	require.NoError(t, err)
+
+	return id
+}
+
+func (f *fixture) requireIndexed(t *testing.T) {
+	t.Helper()
+
+	refs, err := f.didTxns.Get(did1)
+	require.NoError(t, err)
+	require.Equal(t, []string{f.anchor1, f.anchor2, f.anchor3}, refs)
+
+	refs, err = f.didTxns.Get(did2)
+	require.NoError(t, err)
+	require.Equal(t, []string{f.anchor2, f.anchor3}, refs)
+
+	ops, err := f.opStore.Get(did1)
+	require.NoError(t, err)
+	require.Len(t, ops, 3)
+
+	for i, op := range ops {
+		require.Equal(t, uint64(i), op.TransactionTime)
+	}
+
+	require.Equal(t, f.anchor3, ops[2].Reference)
+
+	ops, err = f.opStore.Get(did2)
+	require.NoError(t, err)
+	require.Len(t, ops, 2)
+}
+
+// concurrentDidTxns fails the given number of conditional updates with a conflict.
+type concurrentDidTxns struct {
+	*memdidtxnref.MemDidTxnRef
+	conflicts int
+}
+
+func (m *concurrentDidTxns) ReplaceIfUnchanged(did string, expected, cids []string) error {
+	if m.conflicts > 0 {
+		m.conflicts--
+
+		return fmt.Errorf("%w: concurrent update", didtxnref.ErrDidTransactionConflict)
+	}
+
+	return m.MemDidTxnRef.ReplaceIfUnchanged(did, expected, cids)
+}
+
+type errOperationStore struct {
+	err error
+}
+
+func (m *errOperationStore) Get(string) ([]*operation.AnchoredOperation, error) {
+	return nil, m.err
+}
+
+// mockOperationProvider returns (copies of) the operations of an anchor string.
+type mockOperationProvider struct {
+	ops map[string][]*operation.AnchoredOperation
+	err error
+}
+
+func (m *mockOperationProvider) GetTxnOperations(sidetreeTxn *txn.SidetreeTxn) ([]*operation.AnchoredOperation, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var ops []*operation.AnchoredOperation
+
+	for _, op := range m.ops[sidetreeTxn.AnchorString] {
+		opCopy := *op
+		ops = append(ops, &opCopy)
+	}
+
+	return ops, nil
+}
+
+var pubKeyFetcherFnc = func(issuerID, keyID string) (*verifier.PublicKey, error) {
+	return nil, fmt.Errorf("not expected")
+}
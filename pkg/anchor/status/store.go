@@ -0,0 +1,333 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const (
+	nameSpace = "anchorstatus"
+
+	anchorKeyPrefix = "anchor_"
+	cidKeyPrefix    = "cid_"
+	didKeyPrefix    = "did_"
+)
+
+// ErrNotFound is returned if the status of an anchor is not found.
+var ErrNotFound = errors.New("anchor status not found")
+
+// State is the lifecycle state of an anchor.
+type State string
+
+const (
+	// StateBuilding means that the anchor credential is being built and signed.
+	StateBuilding State = "building"
+	// StateAwaitingWitnesses means that the anchor credential has been signed and is waiting for witness proofs.
+	StateAwaitingWitnesses State = "awaiting-witnesses"
+	// StateStored means that the anchor credential has been stored in CAS (i.e. it has a CID).
+	StateStored State = "stored"
+	// StateAnnounced means that the anchor has been announced to the observer.
+	StateAnnounced State = "announced"
+	// StateObserved means that the operations of the anchor have been processed by the local observer, i.e.
+	// the DIDs in the anchor may be resolved with the anchored operations.
+	StateObserved State = "observed"
+)
+
+// Transition is a state that an anchor reached at a given time.
+type Transition struct {
+	State State     `json:"state"`
+	Time  time.Time `json:"time"`
+}
+
+// Status contains the lifecycle status of an anchor.
+type Status struct {
+	// Anchor is the Sidetree anchor string.
+	Anchor string `json:"anchor"`
+	// CID is the CID of the anchor credential (set once the anchor is stored).
+	CID string `json:"cid,omitempty"`
+	// DIDs contains the unique suffixes of the DIDs in the anchor.
+	DIDs []string `json:"dids,omitempty"`
+	// State is the current state of the anchor.
+	State State `json:"state"`
+	// History contains all of the states that the anchor reached (in order).
+	History []*Transition `json:"history"`
+	// Error is the last error that occurred while processing the anchor. It's cleared on the next transition.
+	Error string `json:"error,omitempty"`
+	// ErrorTime is the time at which the error occurred.
+	ErrorTime *time.Time `json:"errorTime,omitempty"`
+	// Updated is the time of the last update.
+	Updated time.Time `json:"updated"`
+}
+
+// Store tracks the lifecycle status of anchors. The status may be retrieved by anchor string, by the CID of the
+// anchor credential or by the unique suffix of a DID in the anchor.
+type Store struct {
+	store storage.Store
+	mutex sync.Mutex
+	now   func() time.Time
+}
+
+// New returns a new anchor status store.
+func New(provider storage.Provider) (*Store, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open anchor status store: %w", err)
+	}
+
+	return &Store{store: store, now: time.Now}, nil
+}
+
+// Building records that the given anchor (which contains the DIDs with the given unique suffixes) is being built.
+// If the anchor is being rebuilt (e.g. after a conflict) then the CID of the abandoned anchor credential is
+// removed.
+func (s *Store) Building(anchor string, suffixes []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st, err := s.get(anchor)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	if st == nil {
+		st = &Status{Anchor: anchor}
+	}
+
+	err = s.removeCID(st)
+	if err != nil {
+		return err
+	}
+
+	for _, suffix := range suffixes {
+		err = s.addSuffix(suffix, anchor)
+		if err != nil {
+			return err
+		}
+	}
+
+	st.DIDs = suffixes
+
+	return s.transition(st, StateBuilding)
+}
+
+// Transition records that the given anchor reached the given state. The CID is optional (it's only known once the
+// anchor has been stored). Transitions of an anchor that has already been observed are ignored (since the writer
+// may record that the anchor was announced after the observer has already processed it).
+func (s *Store) Transition(anchor string, state State, cid string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st, err := s.get(anchor)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		// e.g. an anchor that was written by another node
+		st = &Status{Anchor: anchor}
+	}
+
+	if st.State == StateObserved && state != StateObserved {
+		return nil
+	}
+
+	if cid != "" && cid != st.CID {
+		err = s.removeCID(st)
+		if err != nil {
+			return err
+		}
+
+		err = s.put(cidKeyPrefix+cid, []byte(anchor))
+		if err != nil {
+			return err
+		}
+
+		st.CID = cid
+	}
+
+	return s.transition(st, state)
+}
+
+// Observed records that the anchor with the given CID was processed by the local observer.
+func (s *Store) Observed(anchor, cid string) error {
+	return s.Transition(anchor, StateObserved, cid)
+}
+
+// Fail records the given error for the given anchor. The state of the anchor is unchanged.
+func (s *Store) Fail(anchor string, cause error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	st, err := s.get(anchor)
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+
+	st.Error = cause.Error()
+	st.ErrorTime = &now
+	st.Updated = now
+
+	return s.putStatus(st)
+}
+
+// Get returns the status of the given anchor.
+func (s *Store) Get(anchor string) (*Status, error) {
+	return s.get(anchor)
+}
+
+// GetByCID returns the status of the anchor with the given CID.
+func (s *Store) GetByCID(cid string) (*Status, error) {
+	anchorBytes, err := s.store.Get(cidKeyPrefix + cid)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get anchor for CID [%s]: %w", cid, err)
+	}
+
+	return s.get(string(anchorBytes))
+}
+
+// GetBySuffix returns the status of all of the anchors that contain the DID with the given unique suffix
+// (in the order in which the anchors were built).
+func (s *Store) GetBySuffix(suffix string) ([]*Status, error) {
+	anchors, err := s.getAnchors(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(anchors) == 0 {
+		return nil, ErrNotFound
+	}
+
+	statuses := make([]*Status, len(anchors))
+
+	for i, anchor := range anchors {
+		statuses[i], err = s.get(anchor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return statuses, nil
+}
+
+func (s *Store) transition(st *Status, state State) error {
+	now := s.now()
+
+	st.State = state
+	st.History = append(st.History, &Transition{State: state, Time: now})
+	st.Error = ""
+	st.ErrorTime = nil
+	st.Updated = now
+
+	return s.putStatus(st)
+}
+
+// removeCID removes the mapping from the CID of the given anchor to the anchor and clears the CID.
+func (s *Store) removeCID(st *Status) error {
+	if st.CID == "" {
+		return nil
+	}
+
+	err := s.store.Delete(cidKeyPrefix + st.CID)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return fmt.Errorf("failed to delete anchor for CID [%s]: %w", st.CID, err)
+	}
+
+	st.CID = ""
+
+	return nil
+}
+
+func (s *Store) addSuffix(suffix, anchor string) error {
+	anchors, err := s.getAnchors(suffix)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range anchors {
+		if a == anchor {
+			return nil
+		}
+	}
+
+	anchorsBytes, err := json.Marshal(append(anchors, anchor))
+	if err != nil {
+		return fmt.Errorf("failed to marshal anchors for suffix [%s]: %w", suffix, err)
+	}
+
+	return s.put(didKeyPrefix+suffix, anchorsBytes)
+}
+
+func (s *Store) getAnchors(suffix string) ([]string, error) {
+	anchorsBytes, err := s.store.Get(didKeyPrefix + suffix)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get anchors for suffix [%s]: %w", suffix, err)
+	}
+
+	var anchors []string
+
+	err = json.Unmarshal(anchorsBytes, &anchors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal anchors for suffix [%s]: %w", suffix, err)
+	}
+
+	return anchors, nil
+}
+
+func (s *Store) get(anchor string) (*Status, error) {
+	statusBytes, err := s.store.Get(anchorKeyPrefix + anchor)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, ErrNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get status of anchor [%s]: %w", anchor, err)
+	}
+
+	st := &Status{}
+
+	err = json.Unmarshal(statusBytes, st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal status of anchor [%s]: %w", anchor, err)
+	}
+
+	return st, nil
+}
+
+func (s *Store) putStatus(st *Status) error {
+	statusBytes, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status of anchor [%s]: %w", st.Anchor, err)
+	}
+
+	return s.put(anchorKeyPrefix+st.Anchor, statusBytes)
+}
+
+func (s *Store) put(key string, value []byte) error {
+	err := s.store.Put(key, value)
+	if err != nil {
+		return fmt.Errorf("failed to store anchor status [%s]: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package status
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	anchor1 = "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt"
+	anchor2 = "2.QmVf3bUCAqpZVFRMSxrJX6QnVHY4bv7ZUMWvcG1AS8jKZa"
+	cid1    = "bafkreiarkubvukdidicmqynkyls3iqawdqvthi7e6mbky2amuw3inxsi3y"
+	cid2    = "bafkreibmrmenuxhgaomod2luojctmz7ngwuz7sgafhevnhbx3hhtecqs3e"
+	suffix1 = "suffix1"
+	suffix2 = "suffix2"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		s, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open anchor status store: open error")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		now := time.Now().UTC()
+		s.now = func() time.Time {
+			now = now.Add(time.Second)
+
+			return now
+		}
+
+		require.NoError(t, s.Building(anchor1, []string{suffix1, suffix2}))
+		require.NoError(t, s.Building(anchor2, []string{suffix1}))
+
+		st, err := s.Get(anchor1)
+		require.NoError(t, err)
+		require.Equal(t, anchor1, st.Anchor)
+		require.Equal(t, StateBuilding, st.State)
+		require.Equal(t, []string{suffix1, suffix2}, st.DIDs)
+		require.Empty(t, st.CID)
+
+		require.NoError(t, s.Transition(anchor1, StateAwaitingWitnesses, ""))
+
+		require.NoError(t, s.Fail(anchor1, errors.New("injected error")))
+
+		st, err = s.Get(anchor1)
+		require.NoError(t, err)
+		require.Equal(t, StateAwaitingWitnesses, st.State)
+		require.Equal(t, "injected error", st.Error)
+		require.NotNil(t, st.ErrorTime)
+		require.Equal(t, *st.ErrorTime, st.Updated)
+
+		require.NoError(t, s.Transition(anchor1, StateStored, cid1))
+		require.NoError(t, s.Observed(anchor1, cid1))
+
+		// The writer may record the announcement after the anchor has been observed.
+		require.NoError(t, s.Transition(anchor1, StateAnnounced, cid1))
+
+		st, err = s.GetByCID(cid1)
+		require.NoError(t, err)
+		require.Equal(t, anchor1, st.Anchor)
+		require.Equal(t, cid1, st.CID)
+		require.Equal(t, StateObserved, st.State)
+		require.Empty(t, st.Error)
+		require.Nil(t, st.ErrorTime)
+		require.Len(t, st.History, 4)
+		require.Equal(t, StateBuilding, st.History[0].State)
+		require.Equal(t, StateAwaitingWitnesses, st.History[1].State)
+		require.Equal(t, StateStored, st.History[2].State)
+		require.Equal(t, StateObserved, st.History[3].State)
+		require.True(t, st.History[0].Time.Before(st.History[3].Time))
+		require.Equal(t, st.History[3].Time, st.Updated)
+
+		statuses, err := s.GetBySuffix(suffix1)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+		require.Equal(t, anchor1, statuses[0].Anchor)
+		require.Equal(t, anchor2, statuses[1].Anchor)
+
+		statuses, err = s.GetBySuffix(suffix2)
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		require.Equal(t, anchor1, statuses[0].Anchor)
+
+		// Rebuilding an anchor (e.g. after a conflict) doesn't duplicate the suffix index.
+		require.NoError(t, s.Building(anchor2, []string{suffix1}))
+
+		statuses, err = s.GetBySuffix(suffix1)
+		require.NoError(t, err)
+		require.Len(t, statuses, 2)
+	})
+
+	t.Run("rebuilt after conflict", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, s.Building(anchor1, []string{suffix1}))
+		require.NoError(t, s.Transition(anchor1, StateStored, cid1))
+
+		// the anchor credential with CID 1 is abandoned and the anchor is rebuilt
+		require.NoError(t, s.Building(anchor1, []string{suffix1}))
+
+		st, err := s.Get(anchor1)
+		require.NoError(t, err)
+		require.Equal(t, StateBuilding, st.State)
+		require.Empty(t, st.CID)
+
+		_, err = s.GetByCID(cid1)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		require.NoError(t, s.Transition(anchor1, StateStored, cid2))
+
+		st, err = s.GetByCID(cid2)
+		require.NoError(t, err)
+		require.Equal(t, anchor1, st.Anchor)
+
+		// a different CID replaces the previous CID
+		require.NoError(t, s.Observed(anchor1, cid1))
+
+		_, err = s.GetByCID(cid2)
+		require.True(t, errors.Is(err, ErrNotFound))
+
+		st, err = s.GetByCID(cid1)
+		require.NoError(t, err)
+		require.Equal(t, StateObserved, st.State)
+	})
+
+	t.Run("observed by another node", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, s.Observed(anchor1, cid1))
+
+		st, err := s.GetByCID(cid1)
+		require.NoError(t, err)
+		require.Equal(t, StateObserved, st.State)
+		require.Empty(t, st.DIDs)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		st, err := s.Get(anchor1)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, st)
+
+		st, err = s.GetByCID(cid1)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, st)
+
+		statuses, err := s.GetBySuffix(suffix1)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Nil(t, statuses)
+
+		err = s.Fail(anchor1, errors.New("injected error"))
+		require.True(t, errors.Is(err, ErrNotFound))
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Get(anchor1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get status of anchor")
+
+		_, err = s.GetByCID(cid1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get anchor for CID")
+
+		_, err = s.GetBySuffix(suffix1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get anchors for suffix")
+
+		err = s.Building(anchor1, []string{suffix1})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+
+		err = s.Transition(anchor1, StateStored, cid1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		err = s.Building(anchor1, []string{suffix1})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to store anchor status")
+
+		err = s.Building(anchor1, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to store anchor status")
+
+		err = s.Transition(anchor1, StateStored, cid1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to store anchor status")
+	})
+
+	t.Run("error - delete", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, s.Building(anchor1, []string{suffix1}))
+		require.NoError(t, s.Transition(anchor1, StateStored, cid1))
+
+		provider.Store.ErrDelete = errors.New("delete error")
+
+		err = s.Building(anchor1, []string{suffix1})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to delete anchor for CID")
+
+		err = s.Transition(anchor1, StateStored, cid2)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to delete anchor for CID")
+	})
+
+	t.Run("error - invalid stored value", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store[anchorKeyPrefix+anchor1] = []byte("invalid")
+		provider.Store.Store[didKeyPrefix+suffix1] = []byte("invalid")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		_, err = s.Get(anchor1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal status of anchor")
+
+		_, err = s.GetBySuffix(suffix1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal anchors for suffix")
+
+		err = s.Building(anchor2, []string{suffix1})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal anchors for suffix")
+	})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package statusresthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/status"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("anchor-status-handler")
+
+const (
+	// Path is the base path of the anchor status endpoints.
+	Path = "/status"
+
+	cidPathVariable    = "cid"
+	suffixPathVariable = "suffix"
+
+	jsonContentType = "application/json"
+)
+
+type anchorStatus interface {
+	GetByCID(cid string) (*status.Status, error)
+	GetBySuffix(suffix string) ([]*status.Status, error)
+}
+
+// AnchorHandler serves the lifecycle status of the anchor with a given CID.
+type AnchorHandler struct {
+	status anchorStatus
+}
+
+// NewAnchorHandler returns a new anchor status handler.
+func NewAnchorHandler(s anchorStatus) *AnchorHandler {
+	return &AnchorHandler{status: s}
+}
+
+// Path returns the HTTP REST endpoint for the anchor status handler.
+func (h *AnchorHandler) Path() string {
+	return fmt.Sprintf("%s/anchors/{%s}", Path, cidPathVariable)
+}
+
+// Method returns the HTTP REST method for the anchor status handler.
+func (h *AnchorHandler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the anchor status handler.
+func (h *AnchorHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *AnchorHandler) handle(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[cidPathVariable]
+
+	err := cas.ValidateCID(cid)
+	if err != nil {
+		writeResponse(w, http.StatusBadRequest, "", []byte(err.Error()))
+
+		return
+	}
+
+	st, err := h.status.GetByCID(cid)
+	if err != nil {
+		writeError(w, err, fmt.Sprintf("anchor [%s]", cid))
+
+		return
+	}
+
+	writeJSON(w, st, fmt.Sprintf("anchor [%s]", cid))
+}
+
+// DIDHandler serves the lifecycle status of all of the anchors that contain the DID with a given unique suffix.
+type DIDHandler struct {
+	status anchorStatus
+}
+
+// NewDIDHandler returns a new DID anchor status handler.
+func NewDIDHandler(s anchorStatus) *DIDHandler {
+	return &DIDHandler{status: s}
+}
+
+// Path returns the HTTP REST endpoint for the DID anchor status handler.
+func (h *DIDHandler) Path() string {
+	return fmt.Sprintf("%s/dids/{%s}", Path, suffixPathVariable)
+}
+
+// Method returns the HTTP REST method for the DID anchor status handler.
+func (h *DIDHandler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the DID anchor status handler.
+func (h *DIDHandler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *DIDHandler) handle(w http.ResponseWriter, req *http.Request) {
+	suffix := mux.Vars(req)[suffixPathVariable]
+
+	statuses, err := h.status.GetBySuffix(suffix)
+	if err != nil {
+		writeError(w, err, fmt.Sprintf("anchors of suffix [%s]", suffix))
+
+		return
+	}
+
+	writeJSON(w, statuses, fmt.Sprintf("anchors of suffix [%s]", suffix))
+}
+
+func writeError(w http.ResponseWriter, err error, desc string) {
+	if errors.Is(err, status.ErrNotFound) {
+		writeResponse(w, http.StatusNotFound, "", []byte(http.StatusText(http.StatusNotFound)))
+
+		return
+	}
+
+	logger.Errorf("Error retrieving status of %s: %s", desc, err)
+
+	writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}, desc string) {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		logger.Errorf("Error marshalling status of %s: %s", desc, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	writeResponse(w, http.StatusOK, jsonContentType, valueBytes)
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(statusCode)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package statusresthandler
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/status"
+)
+
+const (
+	anchor    = "1.Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+	anchorCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+	suffix    = "suffix1"
+)
+
+type handler interface {
+	Path() string
+	Method() string
+	Handler() common.HTTPRequestHandler
+}
+
+func TestAnchorHandler(t *testing.T) {
+	store := newStatusStore(t)
+
+	t.Run("success", func(t *testing.T) {
+		h := NewAnchorHandler(store)
+		require.Equal(t, "/status/anchors/{cid}", h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, Path+"/anchors/"+anchorCID)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		st := &status.Status{}
+		require.NoError(t, json.Unmarshal(readBody(t, rw), st))
+		require.Equal(t, anchor, st.Anchor)
+		require.Equal(t, anchorCID, st.CID)
+		require.Equal(t, status.StateStored, st.State)
+		require.Equal(t, []string{suffix}, st.DIDs)
+		require.Len(t, st.History, 2)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rw := serve(NewAnchorHandler(newStatusStore(t)), Path+"/anchors/QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt")
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		rw := serve(NewAnchorHandler(store), Path+"/anchors/invalid")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("error - store error", func(t *testing.T) {
+		rw := serve(NewAnchorHandler(newErrStatusStore(t)), Path+"/anchors/"+anchorCID)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), string(readBody(t, rw)))
+	})
+}
+
+func TestDIDHandler(t *testing.T) {
+	store := newStatusStore(t)
+
+	t.Run("success", func(t *testing.T) {
+		h := NewDIDHandler(store)
+		require.Equal(t, "/status/dids/{suffix}", h.Path())
+		require.Equal(t, http.MethodGet, h.Method())
+		require.NotNil(t, h.Handler())
+
+		rw := serve(h, Path+"/dids/"+suffix)
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+		var statuses []*status.Status
+		require.NoError(t, json.Unmarshal(readBody(t, rw), &statuses))
+		require.Len(t, statuses, 1)
+		require.Equal(t, anchor, statuses[0].Anchor)
+		require.Equal(t, anchorCID, statuses[0].CID)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rw := serve(NewDIDHandler(store), Path+"/dids/unknown")
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("error - store error", func(t *testing.T) {
+		rw := serve(NewDIDHandler(newErrStatusStore(t)), Path+"/dids/"+suffix)
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+		require.Equal(t, http.StatusText(http.StatusInternalServerError), string(readBody(t, rw)))
+	})
+}
+
+func newStatusStore(t *testing.T) *status.Store {
+	t.Helper()
+
+	store, err := status.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Building(anchor, []string{suffix}))
+	require.NoError(t, store.Transition(anchor, status.StateStored, anchorCID))
+
+	return store
+}
+
+func newErrStatusStore(t *testing.T) *status.Store {
+	t.Helper()
+
+	provider := mockstore.NewMockStoreProvider()
+	provider.Store.ErrGet = errors.New("get error")
+
+	store, err := status.New(provider)
+	require.NoError(t, err)
+
+	return store
+}
+
+func serve(h handler, path string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(h.Method(), path, nil))
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) []byte {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return body
+}
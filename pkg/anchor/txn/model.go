@@ -12,4 +12,11 @@ type Payload struct {
 	Namespace            string            `json:"namespace"`
 	Version              uint64            `json:"version"`
 	PreviousTransactions map[string]string `json:"previousTransactions,omitempty"`
+
+	// OperationCount is the number of operations in the anchored batch.
+	OperationCount uint64 `json:"operationCount,omitempty"`
+	// OperationTypes contains the distinct types of operations in the anchored batch.
+	OperationTypes []string `json:"operationTypes,omitempty"`
+	// CoreIndex is the CID of the core index file of the anchored batch.
+	CoreIndex string `json:"coreIndex,omitempty"`
 }
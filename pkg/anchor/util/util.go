@@ -43,12 +43,24 @@ func getCredentialSubjectCustomFields(node *verifiable.Credential) (map[string]i
 		return nil, fmt.Errorf("missing credential subject")
 	}
 
+	// parsed credentials (JSON-LD or JWT) have a list of subjects; credentials created for
+	// JWT signing have a single subject
 	switch t := subject.(type) {
 	case []verifiable.Subject:
 		subjects, _ := subject.([]verifiable.Subject) //nolint: errcheck
 
+		if len(subjects) == 0 {
+			return nil, fmt.Errorf("missing credential subject")
+		}
+
 		return subjects[0].CustomFields, nil
 
+	case verifiable.Subject:
+		return t.CustomFields, nil
+
+	case map[string]interface{}:
+		return t, nil
+
 	default:
 		return nil, fmt.Errorf("unexpected interface for credential subject: %s", t)
 	}
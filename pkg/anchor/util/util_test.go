@@ -53,6 +53,47 @@ func TestGetTransactionPayload(t *testing.T) {
 		require.Equal(t, txnInfo.PreviousTransactions, txnInfoFromVC.PreviousTransactions)
 	})
 
+	t.Run("success - JWT", func(t *testing.T) {
+		txnInfo := &txn.Payload{
+			AnchorString:         "anchor",
+			Namespace:            "namespace",
+			Version:              1,
+			PreviousTransactions: map[string]string{"suffix": "cid"},
+		}
+
+		vc := &verifiable.Credential{
+			Types:   []string{"VerifiableCredential"},
+			Context: []string{defVCContext},
+			Subject: verifiable.Subject{CustomFields: map[string]interface{}{
+				"anchorString":         txnInfo.AnchorString,
+				"namespace":            txnInfo.Namespace,
+				"version":              txnInfo.Version,
+				"previousTransactions": txnInfo.PreviousTransactions,
+			}},
+			Issuer: verifiable.Issuer{
+				ID: "http://peer1.com",
+			},
+			Issued: &util.TimeWithTrailingZeroMsec{Time: time.Now()},
+		}
+
+		txnInfoFromVC, err := GetTransactionPayload(vc)
+		require.NoError(t, err)
+		require.Equal(t, txnInfo, txnInfoFromVC)
+
+		claims, err := vc.JWTClaims(false)
+		require.NoError(t, err)
+
+		jwt, err := claims.MarshalUnsecuredJWT()
+		require.NoError(t, err)
+
+		parsedVC, err := verifiable.ParseCredential([]byte(jwt))
+		require.NoError(t, err)
+
+		txnInfoFromVC, err = GetTransactionPayload(parsedVC)
+		require.NoError(t, err)
+		require.Equal(t, txnInfo, txnInfoFromVC)
+	})
+
 	t.Run("error - no credential subject", func(t *testing.T) {
 		vc := &verifiable.Credential{
 			Types:   []string{"VerifiableCredential"},
@@ -74,7 +115,7 @@ func TestGetTransactionPayload(t *testing.T) {
 		vc := &verifiable.Credential{
 			Types:   []string{"VerifiableCredential"},
 			Context: []string{defVCContext},
-			Subject: verifiable.Subject{},
+			Subject: "did:example:123",
 			Issuer: verifiable.Issuer{
 				ID: "http://peer1.com",
 			},
@@ -0,0 +1,124 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcresthandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/anchor/vcstore"
+)
+
+var logger = log.New("anchor-credential-handler")
+
+const (
+	// Path is the base path of the anchor credential endpoint.
+	Path = "/vc"
+
+	idPathVariable = "id"
+
+	ldpContentType = "application/vc+ld+json"
+	jwtContentType = "application/vc+jwt"
+)
+
+type credentialStore interface {
+	Get(id string) (string, error)
+}
+
+type credentialReader interface {
+	ReadRaw(ctx context.Context, cid string) ([]byte, error)
+}
+
+// Handler serves anchor credentials by ID.
+type Handler struct {
+	baseURL string
+	store   credentialStore
+	reader  credentialReader
+}
+
+// New returns a new anchor credential handler. The baseURL is the URL under which
+// anchor credentials are served (i.e. the ID of a credential is <baseURL>/<id>).
+func New(baseURL string, store credentialStore, reader credentialReader) *Handler {
+	return &Handler{
+		baseURL: baseURL,
+		store:   store,
+		reader:  reader,
+	}
+}
+
+// Path returns the HTTP REST endpoint for the anchor credential handler.
+func (h *Handler) Path() string {
+	return fmt.Sprintf("%s/{%s}", Path, idPathVariable)
+}
+
+// Method returns the HTTP REST method for the anchor credential handler.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the anchor credential handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, req *http.Request) {
+	id := fmt.Sprintf("%s/%s", h.baseURL, mux.Vars(req)[idPathVariable])
+
+	cid, err := h.store.Get(id)
+	if err != nil {
+		if errors.Is(err, vcstore.ErrNotFound) {
+			writeResponse(w, http.StatusNotFound, "", []byte(http.StatusText(http.StatusNotFound)))
+
+			return
+		}
+
+		logger.Errorf("Error retrieving CID for anchor credential [%s]: %s", id, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	vcBytes, err := h.reader.ReadRaw(req.Context(), cid)
+	if err != nil {
+		logger.Errorf("Error reading anchor credential [%s] from CAS [%s]: %s", id, cid, err)
+
+		writeResponse(w, http.StatusInternalServerError, "", []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	writeResponse(w, http.StatusOK, contentType(vcBytes), vcBytes)
+}
+
+// contentType returns the content type of the anchor credential which is either
+// a JSON-LD document or a JWT.
+func contentType(vcBytes []byte) string {
+	if len(vcBytes) > 0 && vcBytes[0] == '{' {
+		return ldpContentType
+	}
+
+	return jwtContentType
+}
+
+func writeResponse(w http.ResponseWriter, status int, contentType string, body []byte) {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcresthandler
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/pkg/anchor/graph"
+	"github.com/trustbloc/orb/pkg/anchor/vcstore"
+)
+
+const baseURL = "https://orb.domain.com/vc"
+
+func TestHandler(t *testing.T) {
+	casClient := mocks.NewMockCasClient(nil)
+
+	store, err := vcstore.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	ldpCID, err := casClient.Write([]byte(`{"id":"https://orb.domain.com/vc/ldp"}`))
+	require.NoError(t, err)
+	require.NoError(t, store.Put(baseURL+"/ldp", ldpCID))
+
+	jwtCID, err := casClient.Write([]byte(`eyJhbGciOiJFZERTQSJ9.eyJ2YyI6e319.c2ln`))
+	require.NoError(t, err)
+	require.NoError(t, store.Put(baseURL+"/jwt", jwtCID))
+
+	require.NoError(t, store.Put(baseURL+"/missing", "QmMissing"))
+
+	txnGraph := graph.New(casClient, nil)
+
+	h := New(baseURL, store, txnGraph)
+	require.Equal(t, "/vc/{id}", h.Path())
+	require.Equal(t, http.MethodGet, h.Method())
+	require.NotNil(t, h.Handler())
+
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	t.Run("JSON-LD credential", func(t *testing.T) {
+		rw := serve(router, "/vc/ldp")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, ldpContentType, rw.Header().Get("Content-Type"))
+		require.Equal(t, `{"id":"https://orb.domain.com/vc/ldp"}`, readBody(t, rw))
+	})
+
+	t.Run("JWT credential", func(t *testing.T) {
+		rw := serve(router, "/vc/jwt")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jwtContentType, rw.Header().Get("Content-Type"))
+		require.Equal(t, `eyJhbGciOiJFZERTQSJ9.eyJ2YyI6e319.c2ln`, readBody(t, rw))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rw := serve(router, "/vc/unknown")
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("CAS error", func(t *testing.T) {
+		rw := serve(router, "/vc/missing")
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		errRouter := mux.NewRouter()
+
+		errHandler := New(baseURL, &mockStore{err: errors.New("store error")}, txnGraph)
+		errRouter.HandleFunc(errHandler.Path(), errHandler.Handler()).Methods(errHandler.Method())
+
+		rw := serve(errRouter, "/vc/ldp")
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+}
+
+func serve(router *mux.Router, path string) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+type mockStore struct {
+	err error
+}
+
+func (m *mockStore) Get(string) (string, error) {
+	return "", m.err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcstore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+)
+
+const nameSpace = "anchorcredential"
+
+// ErrNotFound is returned if the anchor credential is not found.
+var ErrNotFound = errors.New("anchor credential not found")
+
+// Store maps anchor credential IDs to the CIDs under which the credentials are stored.
+type Store struct {
+	store storage.Store
+}
+
+// New returns a new anchor credential ID store.
+func New(provider storage.Provider) (*Store, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open anchor credential store: %w", err)
+	}
+
+	return &Store{store: store}, nil
+}
+
+// Put saves the CID of the anchor credential with the given ID.
+func (s *Store) Put(id, cid string) error {
+	err := s.store.Put(id, []byte(cid))
+	if err != nil {
+		return fmt.Errorf("failed to store anchor credential ID [%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+// Get returns the CID of the anchor credential with the given ID.
+func (s *Store) Get(id string) (string, error) {
+	cidBytes, err := s.store.Get(id)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return "", ErrNotFound
+		}
+
+		return "", fmt.Errorf("failed to get anchor credential ID [%s]: %w", id, err)
+	}
+
+	return string(cidBytes), nil
+}
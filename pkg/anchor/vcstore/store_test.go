@@ -0,0 +1,83 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package vcstore
+
+import (
+	"errors"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	vcID = "https://orb.domain.com/vc/1234"
+	cid  = "QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, s)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		s, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open anchor credential store: open error")
+		require.Nil(t, s)
+	})
+}
+
+func TestStore_PutGet(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, s.Put(vcID, cid))
+
+		value, err := s.Get(vcID)
+		require.NoError(t, err)
+		require.Equal(t, cid, value)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		value, err := s.Get(vcID)
+		require.True(t, errors.Is(err, ErrNotFound))
+		require.Empty(t, value)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		err = s.Put(vcID, cid)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		s, err := New(provider)
+		require.NoError(t, err)
+
+		value, err := s.Get(vcID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+		require.Empty(t, value)
+	})
+}
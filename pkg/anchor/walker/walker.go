@@ -0,0 +1,266 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package walker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
+	"github.com/trustbloc/sidetree-core-go/pkg/compression"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprovider"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprovider/models"
+
+	"github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+// Types of the items of the anchor graph.
+const (
+	TypeAnchor           = "anchor"
+	TypeCoreIndex        = "coreIndex"
+	TypeCoreProof        = "coreProof"
+	TypeProvisionalIndex = "provisionalIndex"
+	TypeProvisionalProof = "provisionalProof"
+	TypeChunk            = "chunk"
+)
+
+type txnGraph interface {
+	Read(ctx context.Context, cid string) (*verifiable.Credential, error)
+}
+
+// Providers contains the providers required by the walker.
+type Providers struct {
+	CAS                    casapi.Client
+	TxnGraph               txnGraph
+	ProtocolClientProvider protocol.ClientProvider
+}
+
+// Walker walks the anchor graph, i.e. the anchors along with the Sidetree batch files (core index,
+// core proof, provisional index, provisional proof and chunk files) that are referenced by the anchors.
+type Walker struct {
+	*Providers
+	compression *compression.Registry
+}
+
+// Item is an anchor or a batch file of the anchor graph.
+type Item struct {
+	CID  string `json:"cid"`
+	Type string `json:"type"`
+	// Anchor is the CID of the anchor that references the batch file.
+	Anchor string `json:"anchor,omitempty"`
+	// Verified indicates that the content was read from CAS and that it matches the CID.
+	Verified bool   `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// New returns a new anchor graph walker.
+func New(providers *Providers) *Walker {
+	return &Walker{
+		Providers:   providers,
+		compression: compression.New(compression.WithDefaultAlgorithms()),
+	}
+}
+
+// Walk walks the anchor graph starting at the given head anchors (breadth first) and invokes visit with the
+// items of every anchor, i.e. the anchor followed by the batch files that it references. Every anchor and batch
+// file is read from CAS and verified against its CID. Errors for individual items are set in the items.
+// Walking stops if visit returns an error or if the given context is done.
+func (w *Walker) Walk(ctx context.Context, heads []string, visit func(items []*Item) error) error {
+	visited := make(map[string]bool)
+
+	var queue []string
+
+	for _, head := range heads {
+		if !visited[head] {
+			visited[head] = true
+			queue = append(queue, head)
+		}
+	}
+
+	for len(queue) > 0 {
+		anchorCID := queue[0]
+		queue = queue[1:]
+
+		items, previous := w.Anchor(ctx, anchorCID, true)
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := visit(items)
+		if err != nil {
+			return err
+		}
+
+		for _, prev := range previous {
+			if !visited[prev] {
+				visited[prev] = true
+				queue = append(queue, prev)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Anchor returns the given anchor (the first item) and the batch files that it references along with the
+// CIDs of the previous anchors. If the anchor couldn't be read then only the anchor is returned (with the
+// error set). Index files are always read (and verified) since they reference the other files. Proof files and
+// chunk files are only read if verify is true.
+func (w *Walker) Anchor(ctx context.Context, anchorCID string, verify bool) ([]*Item, []string) {
+	anchor := &Item{CID: anchorCID, Type: TypeAnchor}
+
+	vc, err := w.TxnGraph.Read(ctx, anchorCID)
+	if err != nil {
+		anchor.Error = err.Error()
+
+		return []*Item{anchor}, nil
+	}
+
+	payload, err := util.GetTransactionPayload(vc)
+	if err != nil {
+		anchor.Error = err.Error()
+
+		return []*Item{anchor}, nil
+	}
+
+	anchor.Verified = true
+
+	var previous []string
+
+	for _, prev := range payload.PreviousTransactions {
+		previous = append(previous, prev)
+	}
+
+	sort.Strings(previous)
+
+	return append([]*Item{anchor}, w.batchFiles(ctx, anchorCID, payload, verify)...), previous
+}
+
+func (w *Walker) batchFiles(ctx context.Context, anchorCID string, payload *txn.Payload, verify bool) []*Item {
+	ad, err := txnprovider.ParseAnchorData(payload.AnchorString)
+	if err != nil {
+		return []*Item{{Type: TypeCoreIndex, Anchor: anchorCID, Error: err.Error()}}
+	}
+
+	alg, err := w.compressionAlgorithm(payload)
+	if err != nil {
+		return []*Item{{CID: ad.CoreIndexFileURI, Type: TypeCoreIndex, Anchor: anchorCID, Error: err.Error()}}
+	}
+
+	coreIndex := &Item{CID: ad.CoreIndexFileURI, Type: TypeCoreIndex, Anchor: anchorCID}
+	items := []*Item{coreIndex}
+
+	cif := &models.CoreIndexFile{}
+	if !w.readIndexFile(ctx, coreIndex, alg, cif) {
+		return items
+	}
+
+	if cif.CoreProofFileURI != "" {
+		items = append(items, w.file(ctx, cif.CoreProofFileURI, TypeCoreProof, anchorCID, verify))
+	}
+
+	if cif.ProvisionalIndexFileURI == "" {
+		return items
+	}
+
+	provisionalIndex := &Item{CID: cif.ProvisionalIndexFileURI, Type: TypeProvisionalIndex, Anchor: anchorCID}
+	items = append(items, provisionalIndex)
+
+	pif := &models.ProvisionalIndexFile{}
+	if !w.readIndexFile(ctx, provisionalIndex, alg, pif) {
+		return items
+	}
+
+	if pif.ProvisionalProofFileURI != "" {
+		items = append(items, w.file(ctx, pif.ProvisionalProofFileURI, TypeProvisionalProof, anchorCID, verify))
+	}
+
+	for _, chunk := range pif.Chunks {
+		items = append(items, w.file(ctx, chunk.ChunkFileURI, TypeChunk, anchorCID, verify))
+	}
+
+	return items
+}
+
+func (w *Walker) file(ctx context.Context, id, fileType, anchorCID string, verify bool) *Item {
+	item := &Item{CID: id, Type: fileType, Anchor: anchorCID}
+
+	if verify {
+		_, err := w.read(ctx, id)
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			item.Verified = true
+		}
+	}
+
+	return item
+}
+
+// readIndexFile reads, verifies and decompresses the given index file into the given model.
+// Returns false if the file couldn't be read (the error is set in the item).
+func (w *Walker) readIndexFile(ctx context.Context, item *Item, alg string, model interface{}) bool {
+	content, err := w.read(ctx, item.CID)
+	if err != nil {
+		item.Error = err.Error()
+
+		return false
+	}
+
+	item.Verified = true
+
+	content, err = w.compression.Decompress(alg, content)
+	if err != nil {
+		item.Error = fmt.Sprintf("failed to decompress: %s", err)
+
+		return false
+	}
+
+	err = json.Unmarshal(content, model)
+	if err != nil {
+		item.Error = fmt.Sprintf("failed to parse: %s", err)
+
+		return false
+	}
+
+	return true
+}
+
+func (w *Walker) read(ctx context.Context, id string) ([]byte, error) {
+	content, err := cas.ReadWithContext(ctx, w.CAS, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cas.VerifyCID(id, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func (w *Walker) compressionAlgorithm(payload *txn.Payload) (string, error) {
+	pc, err := w.ProtocolClientProvider.ForNamespace(payload.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := pc.Get(payload.Version)
+	if err != nil {
+		return "", err
+	}
+
+	return v.Protocol().CompressionAlgorithm, nil
+}
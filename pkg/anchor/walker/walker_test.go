@@ -0,0 +1,172 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package walker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/pkg/anchor/anchortest"
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+const absent = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestWalker_Walk(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := newFixture(t)
+
+		var items []*Item
+
+		err := f.walker.Walk(context.Background(), []string{f.Anchor2, f.Anchor1}, func(anchorItems []*Item) error {
+			items = append(items, anchorItems...)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 8)
+
+		require.Equal(t, f.Anchor2, items[0].CID)
+		require.Equal(t, TypeAnchor, items[0].Type)
+		require.Equal(t, TypeCoreIndex, items[1].Type)
+		require.Equal(t, f.Anchor2, items[1].Anchor)
+
+		// the first anchor is only visited once
+		require.Equal(t, f.Anchor1, items[2].CID)
+
+		var types []string
+
+		for _, item := range items[3:] {
+			require.Equal(t, f.Anchor1, item.Anchor)
+			types = append(types, item.Type)
+		}
+
+		require.Equal(t, []string{TypeCoreIndex, TypeCoreProof, TypeProvisionalIndex, TypeProvisionalProof,
+			TypeChunk}, types)
+
+		for _, item := range items {
+			require.True(t, item.Verified, item.CID)
+			require.Empty(t, item.Error)
+		}
+	})
+
+	t.Run("missing anchor", func(t *testing.T) {
+		f := newFixture(t)
+
+		var items []*Item
+
+		err := f.walker.Walk(context.Background(), []string{absent}, func(anchorItems []*Item) error {
+			items = append(items, anchorItems...)
+
+			return nil
+		})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		require.False(t, items[0].Verified)
+		require.NotEmpty(t, items[0].Error)
+	})
+
+	t.Run("error - visit error", func(t *testing.T) {
+		f := newFixture(t)
+
+		err := f.walker.Walk(context.Background(), []string{f.Anchor2}, func([]*Item) error {
+			return errors.New("visit error")
+		})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "visit error")
+	})
+
+	t.Run("error - context cancelled", func(t *testing.T) {
+		f := newFixture(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := f.walker.Walk(ctx, []string{f.Anchor2}, func([]*Item) error {
+			return nil
+		})
+		require.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestWalker_Anchor(t *testing.T) {
+	t.Run("success - not verified", func(t *testing.T) {
+		f := newFixture(t)
+
+		items, previous := f.walker.Anchor(context.Background(), f.Anchor1, false)
+		require.Len(t, items, 6)
+		require.Empty(t, previous)
+
+		require.True(t, items[0].Verified)
+		require.True(t, items[1].Verified)
+
+		// proof and chunk files are only read if verify is true
+		require.Equal(t, TypeCoreProof, items[2].Type)
+		require.False(t, items[2].Verified)
+		require.Empty(t, items[2].Error)
+	})
+
+	t.Run("success - previous anchors", func(t *testing.T) {
+		f := newFixture(t)
+
+		items, previous := f.walker.Anchor(context.Background(), f.Anchor2, true)
+		require.Len(t, items, 2)
+		require.Equal(t, []string{f.Anchor1}, previous)
+	})
+
+	t.Run("missing batch file", func(t *testing.T) {
+		f := newFixture(t)
+
+		anchorCID := anchortest.AddAnchor(t, f.TxnGraph, "1."+absent, nil)
+
+		items, _ := f.walker.Anchor(context.Background(), anchorCID, true)
+		require.Len(t, items, 2)
+		require.True(t, items[0].Verified)
+		require.False(t, items[1].Verified)
+		require.Equal(t, TypeCoreIndex, items[1].Type)
+		require.Contains(t, items[1].Error, "not found")
+	})
+
+	t.Run("invalid anchor string", func(t *testing.T) {
+		f := newFixture(t)
+
+		anchorCID := anchortest.AddAnchor(t, f.TxnGraph, "invalid", nil)
+
+		items, _ := f.walker.Anchor(context.Background(), anchorCID, true)
+		require.Len(t, items, 2)
+		require.True(t, items[0].Verified)
+		require.Contains(t, items[1].Error, "parse anchor data[invalid] failed")
+	})
+}
+
+type fixture struct {
+	*anchortest.Fixture
+	walker *Walker
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+
+	casClient, err := cas.NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	f := anchortest.NewFixture(t, casClient)
+
+	return &fixture{
+		Fixture: f,
+		walker: New(&Providers{
+			CAS:                    casClient,
+			TxnGraph:               f.TxnGraph,
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider(),
+		}),
+	}
+}
@@ -7,24 +7,62 @@ SPDX-License-Identifier: Apache-2.0
 package writer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
-	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/trustbloc/edge-core/pkg/log"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	txnapi "github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprovider"
 
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+	"github.com/trustbloc/orb/pkg/anchor/builder"
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
+	"github.com/trustbloc/orb/pkg/anchor/pending"
+	"github.com/trustbloc/orb/pkg/anchor/status"
 	"github.com/trustbloc/orb/pkg/anchor/txn"
 	"github.com/trustbloc/orb/pkg/didtxnref"
 )
 
 var logger = log.New("txn-client")
 
-// Writer implements writing orb transactions.
+const (
+	defaultMaxConflictRetries = 3
+	defaultMaxRetries         = 5
+	defaultRetryBackoff       = time.Second
+	defaultRedriveInterval    = 10 * time.Second
+	defaultMaxRedriveBackoff  = 10 * time.Minute
+	queueSize                 = 100
+
+	// Read reads the requested entry plus one more entry in order to determine whether there are more entries
+	readLimit = 2
+)
+
+// Writer implements writing orb transactions. An anchor is written in the following stages, each of which is
+// persisted (in the pending anchor store) once it completes:
+//
+// built -> signed -> witnessed -> stored -> indexed -> announced
+//
+// WriteAnchor builds the anchor credential subject and persists the anchor, after which the remaining stages are
+// processed in the background. A failed stage is retried. Anchors that failed all retries are processed again
+// periodically (with a backoff that doubles with each failure of the anchor) and anchors that are still
+// incomplete when the writer is stopped are resumed when the writer is started again.
 type Writer struct {
 	*Providers
-	namespace string
-	txnCh     chan []string
+	namespace          string
+	queue              chan string
+	notify             chan struct{}
+	ctx                context.Context
+	cancel             context.CancelFunc
+	maxConflictRetries int
+	maxRetries         int
+	retryBackoff       time.Duration
+	redriveInterval    time.Duration
+	maxRedriveBackoff  time.Duration
+	now                func() time.Time
 }
 
 // Providers contains all of the providers required by the client.
@@ -32,75 +70,694 @@ type Providers struct {
 	TxnGraph   txnGraph
 	DidTxns    didTxns
 	TxnBuilder txnBuilder
+	VCStore    vcStore
+	// DIDIndex is optional. If set then the DIDs of each anchor are added to the reverse (anchor to DIDs) index.
+	DIDIndex didIndex
+	// PendingStore persists the anchors that are being written.
+	PendingStore pendingStore
+	// AnchorStatus is optional. If set then the lifecycle status of each anchor is tracked.
+	AnchorStatus anchorStatus
+	// Publisher publishes the written anchors to the observer.
+	Publisher anchorPublisher
+	// AnchorLog is optional. If set then written anchors are added to the anchor log (which is read by Read).
+	AnchorLog anchorLog
 }
 
 type txnGraph interface {
-	Add(txn *verifiable.Credential) (string, error)
+	Add(ctx context.Context, vcBytes []byte) (string, error)
 }
 
 type txnBuilder interface {
-	Build(subject *txn.Payload) (*verifiable.Credential, error)
+	Build(subject *txn.Payload) (*builder.Credential, error)
+}
+
+type vcStore interface {
+	Put(id, cid string) error
+}
+
+type didIndex interface {
+	Put(cid string, dids []*didindex.DID) error
+}
+
+type pendingStore interface {
+	Put(anchor *pending.Anchor) error
+	Get(id string) (*pending.Anchor, error)
+	Delete(id string) error
+	GetAll() ([]*pending.Anchor, error)
+}
+
+type anchorPublisher interface {
+	Publish(ctx context.Context, cid string) error
+}
+
+type anchorLog interface {
+	Append(entry *anchorlog.Entry) (uint64, error)
+	List(from uint64, limit int) ([]*anchorlog.Entry, error)
+}
+
+type anchorStatus interface {
+	Building(anchor string, suffixes []string) error
+	Transition(anchor string, state status.State, cid string) error
+	Fail(anchor string, cause error) error
 }
 
 type didTxns interface {
-	Add(did, cid string) error
+	AddIfLast(did, expectedLast, cid string) error
+	RemoveIfLast(did, cid string) error
+	Get(did string) ([]string, error)
 	Last(did string) (string, error)
 }
 
+// Opt is a writer option.
+type Opt func(w *Writer)
+
+// WithMaxConflictRetries sets the maximum number of times that an anchor is rebuilt if the transaction
+// references of its DIDs were concurrently updated by another anchor.
+func WithMaxConflictRetries(retries int) Opt {
+	return func(w *Writer) {
+		w.maxConflictRetries = retries
+	}
+}
+
+// WithMaxRetries sets the maximum number of times that a failed stage is retried.
+func WithMaxRetries(retries int) Opt {
+	return func(w *Writer) {
+		w.maxRetries = retries
+	}
+}
+
+// WithRetryBackoff sets the time to wait before retrying a failed stage.
+func WithRetryBackoff(backoff time.Duration) Opt {
+	return func(w *Writer) {
+		w.retryBackoff = backoff
+	}
+}
+
+// WithRedriveInterval sets the interval at which the pending anchors are checked for anchors that must be
+// processed again. It's also the delay before an anchor that failed is processed again for the first time.
+func WithRedriveInterval(interval time.Duration) Opt {
+	return func(w *Writer) {
+		w.redriveInterval = interval
+	}
+}
+
+// WithMaxRedriveBackoff sets the maximum delay before an anchor that failed is processed again.
+func WithMaxRedriveBackoff(backoff time.Duration) Opt {
+	return func(w *Writer) {
+		w.maxRedriveBackoff = backoff
+	}
+}
+
 // New returns a new orb transaction client.
-func New(namespace string, providers *Providers, txnCh chan []string) *Writer {
-	return &Writer{
-		Providers: providers,
-		txnCh:     txnCh,
-		namespace: namespace,
+func New(namespace string, providers *Providers, opts ...Opt) *Writer {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &Writer{
+		Providers:          providers,
+		namespace:          namespace,
+		queue:              make(chan string, queueSize),
+		notify:             make(chan struct{}, 1),
+		ctx:                ctx,
+		cancel:             cancel,
+		maxConflictRetries: defaultMaxConflictRetries,
+		maxRetries:         defaultMaxRetries,
+		retryBackoff:       defaultRetryBackoff,
+		redriveInterval:    defaultRedriveInterval,
+		maxRedriveBackoff:  defaultMaxRedriveBackoff,
+		now:                time.Now,
 	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Start resumes the incomplete anchors and starts processing new anchors in the background.
+// Start should be invoked after the observer has been started.
+func (c *Writer) Start() {
+	go c.run()
 }
 
-// WriteAnchor writes anchor string to orb transaction.
+// Stop stops processing anchors. Incomplete anchors are resumed by the next Start.
+func (c *Writer) Stop() {
+	c.cancel()
+}
+
+// WriteAnchor writes anchor string to orb transaction. The anchor credential subject is built and the anchor is
+// persisted before this function returns, after which the anchor is signed, witnessed, stored, indexed and
+// announced in the background.
 func (c *Writer) WriteAnchor(anchor string, refs []*operation.Reference, version uint64) error {
-	vc, err := c.buildCredential(anchor, refs, version)
+	anchorData, err := txnprovider.ParseAnchorData(anchor)
+	if err != nil {
+		return fmt.Errorf("failed to parse anchor string: %s", err.Error())
+	}
+
+	dids := getDIDs(refs)
+
+	// The previous transactions are resolved when the anchor credential is signed.
+	pendingAnchor := &pending.Anchor{
+		ID:    anchor,
+		State: pending.StateBuilt,
+		Payload: &txn.Payload{
+			AnchorString:   anchor,
+			Namespace:      c.namespace,
+			Version:        version,
+			OperationCount: uint64(anchorData.NumberOfOperations),
+			OperationTypes: getOperationTypes(refs),
+			CoreIndex:      anchorData.CoreIndexFileURI,
+		},
+		DIDs: dids,
+	}
+
+	err = c.PendingStore.Put(pendingAnchor)
+	if err != nil {
+		return err
+	}
+
+	c.updateStatus(pendingAnchor)
+
+	logger.Debugf("built anchor [%s]", anchor)
+
+	// The anchor has been persisted, so if the queue is full then the worker is signalled to pick up the anchor
+	// from the pending store instead of blocking the caller.
+	select {
+	case c.queue <- anchor:
+	default:
+		logger.Debugf("writer queue is full; anchor [%s] will be picked up from the pending store", anchor)
+
+		select {
+		case c.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (c *Writer) run() {
+	c.resume()
+
+	ticker := time.NewTicker(c.redriveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case id := <-c.queue:
+			c.process(id)
+		case <-c.notify:
+			c.redrive()
+		case <-ticker.C:
+			c.redrive()
+		case <-c.ctx.Done():
+			logger.Infof("anchor writer stopped")
+
+			return
+		}
+	}
+}
+
+// resume processes all of the anchors that were left incomplete.
+func (c *Writer) resume() {
+	c.processPending(func(*pending.Anchor) bool {
+		return true
+	})
+}
+
+// redrive processes the pending anchors that are due, i.e. anchors that weren't queued and anchors that failed
+// and whose retry time has passed.
+func (c *Writer) redrive() {
+	now := c.now()
+
+	c.processPending(func(anchor *pending.Anchor) bool {
+		return anchor.NextRetry == nil || !now.Before(*anchor.NextRetry)
+	})
+}
+
+func (c *Writer) processPending(due func(anchor *pending.Anchor) bool) {
+	anchors, err := c.PendingStore.GetAll()
+	if err != nil {
+		logger.Errorf("failed to get pending anchors: %s", err)
+
+		return
+	}
+
+	var ids []string
+
+	for _, anchor := range anchors {
+		if due(anchor) {
+			ids = append(ids, anchor.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	logger.Infof("processing %d pending anchors", len(ids))
+
+	for _, id := range ids {
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		c.process(id)
+	}
+}
+
+// process moves the anchor with the given ID through the remaining stages. If a stage fails after all retries
+// then the error is saved with the anchor and the anchor is processed again after a backoff.
+func (c *Writer) process(id string) {
+	anchor, err := c.PendingStore.Get(id)
+	if err != nil {
+		if !errors.Is(err, pending.ErrNotFound) {
+			logger.Errorf("failed to get pending anchor [%s]: %s", id, err)
+		}
+
+		// otherwise the anchor has already been written (e.g. by resume)
+		return
+	}
+
+	var conflicts int
+
+	for anchor.State != pending.StateAnnounced {
+		state := anchor.State
+
+		err = c.advanceWithRetry(anchor)
+		if err == nil && state == pending.StateStored && anchor.State == pending.StateBuilt {
+			conflicts++
+
+			if conflicts > c.maxConflictRetries {
+				err = fmt.Errorf("too many did transaction reference conflicts (%d)", conflicts)
+			}
+		}
+
+		if err != nil {
+			c.fail(anchor, err)
+
+			return
+		}
+	}
+
+	logger.Debugf("anchor [%s] was written as [%s]", anchor.ID, anchor.CID)
+
+	err = c.PendingStore.Delete(anchor.ID)
+	if err != nil {
+		// the anchor is announced again at the next startup
+		logger.Warnf("failed to delete pending anchor [%s]: %s", anchor.ID, err)
+	}
+}
+
+func (c *Writer) fail(anchor *pending.Anchor, cause error) {
+	anchor.Failures++
+
+	backoff := c.redriveBackoff(anchor.Failures)
+	nextRetry := c.now().Add(backoff)
+
+	logger.Errorf("failed to write anchor [%s] in state [%s]; the anchor will be retried in %s: %s",
+		anchor.ID, anchor.State, backoff, cause)
+
+	anchor.Error = cause.Error()
+	anchor.NextRetry = &nextRetry
+
+	err := c.PendingStore.Put(anchor)
+	if err != nil {
+		logger.Warnf("failed to save error of pending anchor [%s]: %s", anchor.ID, err)
+	}
+
+	if c.AnchorStatus == nil {
+		return
+	}
+
+	err = c.AnchorStatus.Fail(anchor.ID, cause)
+	if err != nil {
+		logger.Warnf("failed to save error status of anchor [%s]: %s", anchor.ID, err)
+	}
+}
+
+// redriveBackoff returns the delay before an anchor that failed the given number of times is processed again.
+// The delay starts at the redrive interval and doubles with each failure (up to the maximum backoff).
+func (c *Writer) redriveBackoff(failures int) time.Duration {
+	backoff := c.redriveInterval
+
+	for i := 1; i < failures && backoff < c.maxRedriveBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > c.maxRedriveBackoff {
+		return c.maxRedriveBackoff
+	}
+
+	return backoff
+}
+
+// updateStatus updates the lifecycle status of the anchor according to the state of the anchor in the writer
+// pipeline. Failures are only logged since the status is informational.
+func (c *Writer) updateStatus(anchor *pending.Anchor) {
+	if c.AnchorStatus == nil {
+		return
+	}
+
+	var err error
+
+	switch anchor.State {
+	case pending.StateBuilt:
+		err = c.AnchorStatus.Building(anchor.ID, getSuffixes(anchor.DIDs))
+	case pending.StateSigned:
+		err = c.AnchorStatus.Transition(anchor.ID, status.StateAwaitingWitnesses, "")
+	case pending.StateStored:
+		err = c.AnchorStatus.Transition(anchor.ID, status.StateStored, anchor.CID)
+	case pending.StateAnnounced:
+		err = c.AnchorStatus.Transition(anchor.ID, status.StateAnnounced, anchor.CID)
+	default:
+		// the witnessed and indexed states are internal to the writer
+		return
+	}
+
+	if err != nil {
+		logger.Warnf("failed to update status of anchor [%s] to [%s]: %s", anchor.ID, anchor.State, err)
+	}
+}
+
+// advanceWithRetry advances the anchor to the next state, retrying on failure.
+func (c *Writer) advanceWithRetry(anchor *pending.Anchor) error {
+	for attempt := 1; ; attempt++ {
+		err := c.advance(anchor)
+		if err == nil {
+			return nil
+		}
+
+		if attempt > c.maxRetries {
+			return err
+		}
+
+		logger.Warnf("failed to advance anchor [%s] from state [%s] (attempt %d); retrying in %s: %s",
+			anchor.ID, anchor.State, attempt, c.retryBackoff, err)
+
+		select {
+		case <-time.After(c.retryBackoff):
+		case <-c.ctx.Done():
+			return err
+		}
+	}
+}
+
+// advance executes the stage that follows the current state of the anchor and persists the new state. The given
+// anchor is only updated if the new state was persisted.
+func (c *Writer) advance(anchor *pending.Anchor) error {
+	updated := *anchor
+
+	var err error
+
+	switch anchor.State {
+	case pending.StateBuilt:
+		err = c.sign(&updated)
+	case pending.StateSigned:
+		err = c.witness(&updated)
+	case pending.StateWitnessed:
+		err = c.store(&updated)
+	case pending.StateStored:
+		err = c.index(&updated)
+	case pending.StateIndexed:
+		err = c.announce(&updated)
+	default:
+		err = fmt.Errorf("unexpected state [%s]", anchor.State)
+	}
+
 	if err != nil {
 		return err
 	}
 
-	logger.Debugf("created anchor credential for anchor: %s", anchor)
+	updated.Error = ""
 
-	// TODO: create an offer for witnesses and wait for witness proofs (separate go routine)
-	cid, err := c.TxnGraph.Add(vc)
+	err = c.PendingStore.Put(&updated)
 	if err != nil {
 		return err
 	}
 
-	// update global did/txn references
-	for _, ref := range refs {
-		addErr := c.DidTxns.Add(ref.UniqueSuffix, cid)
-		if addErr != nil {
-			return addErr
+	*anchor = updated
+
+	c.updateStatus(anchor)
+
+	return nil
+}
+
+// sign builds and signs the anchor credential. The previous transactions are resolved again since anchors
+// that were queued before this anchor may have been indexed after this anchor was built.
+func (c *Writer) sign(anchor *pending.Anchor) error {
+	previousTxns, err := c.getPreviousTransactions(anchor.DIDs)
+	if err != nil {
+		return err
+	}
+
+	payload := *anchor.Payload
+	payload.PreviousTransactions = previousTxns
+
+	vc, err := c.TxnBuilder.Build(&payload)
+	if err != nil {
+		return fmt.Errorf("failed to build anchor credential: %s", err.Error())
+	}
+
+	logger.Debugf("created anchor credential [%s] for anchor: %s", vc.ID, anchor.ID)
+
+	anchor.Payload = &payload
+	anchor.VCID = vc.ID
+	anchor.VC = vc.Bytes
+	anchor.State = pending.StateSigned
+
+	return nil
+}
+
+func (c *Writer) witness(anchor *pending.Anchor) error {
+	// TODO: create an offer for witnesses and wait for witness proofs
+	anchor.State = pending.StateWitnessed
+
+	return nil
+}
+
+// store adds the anchor credential to the anchor graph. Adding the same credential again results in the same CID.
+func (c *Writer) store(anchor *pending.Anchor) error {
+	cid, err := c.TxnGraph.Add(c.ctx, anchor.VC)
+	if err != nil {
+		return err
+	}
+
+	// save the CID of the anchor credential so that the credential can be served at its ID
+	err = c.VCStore.Put(anchor.VCID, cid)
+	if err != nil {
+		return err
+	}
+
+	anchor.CID = cid
+	anchor.State = pending.StateStored
+
+	return nil
+}
+
+// index adds the anchor to the transaction references of its DIDs and to the reverse (anchor to DIDs) index.
+// If the transaction references were updated by another anchor after the anchor credential was signed then the
+// anchor is returned to the built state so that it's signed again with the new previous transactions. The
+// reverse index is only updated once the references were updated so that an abandoned anchor isn't indexed.
+func (c *Writer) index(anchor *pending.Anchor) error {
+	rolledBack, err := c.updateDidTxns(anchor)
+	if err != nil {
+		if !rolledBack || !errors.Is(err, didtxnref.ErrDidTransactionConflict) {
+			return err
 		}
+
+		logger.Infof("rebuilding anchor [%s] after did transaction reference conflict: %s", anchor.ID, err)
+
+		// the anchor credential remains in the anchor graph unreferenced
+		anchor.VCID = ""
+		anchor.VC = nil
+		anchor.CID = ""
+		anchor.Conflicts++
+		anchor.State = pending.StateBuilt
+
+		return nil
 	}
 
-	// TODO: announce txn to followers and node observer (if running in observer node)
+	err = c.indexDIDs(anchor.CID, anchor.DIDs)
+	if err != nil {
+		return err
+	}
+
+	anchor.State = pending.StateIndexed
+
+	return nil
+}
+
+func (c *Writer) announce(anchor *pending.Anchor) error {
+	err := c.appendToLog(anchor)
+	if err != nil {
+		return err
+	}
+
+	// TODO: announce txn to followers
+	err = c.Publisher.Publish(c.ctx, anchor.CID)
+	if err != nil {
+		return fmt.Errorf("failed to publish anchor: %w", err)
+	}
 
-	c.txnCh <- []string{cid}
+	anchor.State = pending.StateAnnounced
 
 	return nil
 }
 
-// Read reads transactions since transaction time.
-// TODO: This is not used and can be removed from interface if we change observer in sidetree-mock to point
-// to core observer (can be done easily) Concern: Reference app has this interface.
-func (c *Writer) Read(_ int) (bool, *txnapi.SidetreeTxn) {
-	// not used
+// updateDidTxns adds the anchor to the transaction references of all of the DIDs in the anchor. References that
+// already contain the anchor (i.e. when resuming an anchor) are left as is. If a reference can't be updated then
+// the references that were updated are rolled back and true is returned along with the error, unless the
+// rollback itself failed.
+func (c *Writer) updateDidTxns(anchor *pending.Anchor) (bool, error) {
+	var updated []string
+
+	for _, did := range anchor.DIDs {
+		refs, err := c.DidTxns.Get(did.Suffix)
+		if err != nil && !errors.Is(err, didtxnref.ErrDidTransactionsNotFound) {
+			return c.rollback(anchor.CID, updated, err)
+		}
+
+		if contains(refs, anchor.CID) {
+			updated = append(updated, did.Suffix)
+
+			continue
+		}
+
+		err = c.DidTxns.AddIfLast(did.Suffix, anchor.Payload.PreviousTransactions[did.Suffix], anchor.CID)
+		if err != nil {
+			return c.rollback(anchor.CID, updated, err)
+		}
+
+		updated = append(updated, did.Suffix)
+	}
+
 	return false, nil
 }
 
+// rollback removes the given anchor from the transaction references of the given DIDs (in reverse order) and
+// returns true along with the original error if the rollback succeeded.
+func (c *Writer) rollback(cid string, suffixes []string, cause error) (bool, error) {
+	for i := len(suffixes) - 1; i >= 0; i-- {
+		err := c.DidTxns.RemoveIfLast(suffixes[i], cid)
+		if err != nil {
+			return false, fmt.Errorf("%w (failed to roll back did transaction reference of anchor [%s] for suffix [%s]: %s)",
+				cause, cid, suffixes[i], err)
+		}
+	}
+
+	if len(suffixes) > 0 {
+		logger.Infof("rolled back %d did transaction references of anchor [%s]", len(suffixes), cid)
+	}
+
+	return true, cause
+}
+
+// indexDIDs adds the DIDs of the given anchor to the reverse (anchor to DIDs) index.
+func (c *Writer) indexDIDs(cid string, dids []*didindex.DID) error {
+	if c.DIDIndex == nil {
+		return nil
+	}
+
+	return c.DIDIndex.Put(cid, dids)
+}
+
+func getDIDs(refs []*operation.Reference) []*didindex.DID {
+	dids := make([]*didindex.DID, len(refs))
+
+	for i, ref := range refs {
+		dids[i] = &didindex.DID{Suffix: ref.UniqueSuffix, Type: ref.Type}
+	}
+
+	return dids
+}
+
+func getSuffixes(dids []*didindex.DID) []string {
+	suffixes := make([]string, len(dids))
+
+	for i, did := range dids {
+		suffixes[i] = did.Suffix
+	}
+
+	return suffixes
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendToLog adds the anchor to the anchor log. An anchor that's already in the log (i.e. when resuming an
+// anchor) isn't added again.
+func (c *Writer) appendToLog(anchor *pending.Anchor) error {
+	if c.AnchorLog == nil {
+		return nil
+	}
+
+	_, err := c.AnchorLog.Append(&anchorlog.Entry{
+		CID:       anchor.CID,
+		Anchor:    anchor.ID,
+		Namespace: anchor.Payload.Namespace,
+		Version:   anchor.Payload.Version,
+		Source:    anchorlog.SourceWritten,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add anchor to anchor log: %w", err)
+	}
+
+	return nil
+}
+
+// Read returns the transaction (from the anchor log) that follows the transaction with the given sequence
+// number, i.e. Read(-1) returns the first transaction. The transaction time and number are both the sequence
+// number of the transaction in the anchor log. The returned flag indicates whether there are more transactions
+// after the returned transaction.
+func (c *Writer) Read(sinceTxnTime int) (bool, *txnapi.SidetreeTxn) {
+	if c.AnchorLog == nil {
+		return false, nil
+	}
+
+	var from uint64
+
+	if sinceTxnTime >= 0 {
+		from = uint64(sinceTxnTime) + 1
+	}
+
+	entries, err := c.AnchorLog.List(from, readLimit)
+	if err != nil {
+		logger.Errorf("failed to read anchor log from [%d]: %s", from, err)
+
+		return false, nil
+	}
+
+	if len(entries) == 0 {
+		return false, nil
+	}
+
+	entry := entries[0]
+
+	return len(entries) > 1, &txnapi.SidetreeTxn{
+		TransactionTime:     entry.Sequence,
+		TransactionNumber:   entry.Sequence,
+		AnchorString:        entry.Anchor,
+		Namespace:           entry.Namespace,
+		ProtocolGenesisTime: entry.Version,
+		Reference:           entry.CID,
+	}
+}
+
 //
-func (c *Writer) getPreviousTransactions(refs []*operation.Reference) (map[string]string, error) {
+func (c *Writer) getPreviousTransactions(dids []*didindex.DID) (map[string]string, error) {
 	// assemble map of previous did transaction for each did that is referenced in anchor
 	previousDidTxns := make(map[string]string)
 
-	for _, ref := range refs {
-		last, err := c.DidTxns.Last(ref.UniqueSuffix)
+	for _, did := range dids {
+		last, err := c.DidTxns.Last(did.Suffix)
 		if err != nil {
 			if err == didtxnref.ErrDidTransactionsNotFound {
 				// TODO: it is ok for transaction references not to be there for create; handle other types here
@@ -110,31 +767,27 @@ func (c *Writer) getPreviousTransactions(refs []*operation.Reference) (map[strin
 			}
 		}
 
-		previousDidTxns[ref.UniqueSuffix] = last
+		previousDidTxns[did.Suffix] = last
 	}
 
 	return previousDidTxns, nil
 }
 
-// WriteAnchor writes anchor string to orb transaction.
-func (c *Writer) buildCredential(anchor string, refs []*operation.Reference, version uint64) (*verifiable.Credential, error) { //nolint: lll
-	// get previous did transaction for each did that is referenced in anchor
-	previousTxns, err := c.getPreviousTransactions(refs)
-	if err != nil {
-		return nil, err
-	}
+// getOperationTypes returns the distinct (sorted) operation types of the given references.
+func getOperationTypes(refs []*operation.Reference) []string {
+	typeMap := make(map[string]struct{})
 
-	subject := &txn.Payload{
-		AnchorString:         anchor,
-		Namespace:            c.namespace,
-		Version:              version,
-		PreviousTransactions: previousTxns,
+	for _, ref := range refs {
+		typeMap[string(ref.Type)] = struct{}{}
 	}
 
-	vc, err := c.TxnBuilder.Build(subject)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build anchor credential: %s", err.Error())
+	var types []string
+
+	for t := range typeMap {
+		types = append(types, t)
 	}
 
-	return vc, nil
+	sort.Strings(types)
+
+	return types
 }
@@ -7,100 +7,586 @@ SPDX-License-Identifier: Apache-2.0
 package writer
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
 
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+	"github.com/trustbloc/orb/pkg/anchor/builder"
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
 	"github.com/trustbloc/orb/pkg/anchor/graph"
+	"github.com/trustbloc/orb/pkg/anchor/pending"
+	"github.com/trustbloc/orb/pkg/anchor/publisher"
+	"github.com/trustbloc/orb/pkg/anchor/status"
 	"github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/didtxnref"
 	"github.com/trustbloc/orb/pkg/didtxnref/memdidtxnref"
 )
 
 const (
-	namespace = "did:sidetree"
+	namespace  = "did:sidetree"
+	testAnchor = "2.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt"
+	testDID    = "did:method:abc"
+	testDID2   = "did:method:xyz"
 )
 
 func TestNew(t *testing.T) {
-	var txnCh chan []string
-
 	providers := &Providers{
 		TxnGraph:   graph.New(nil, pubKeyFetcherFnc),
 		DidTxns:    memdidtxnref.New(),
 		TxnBuilder: &mockTxnBuilder{},
+		VCStore:    &mockVCStore{},
 	}
 
-	c := New(namespace, providers, txnCh)
+	c := New(namespace, providers, WithMaxRetries(1), WithRetryBackoff(time.Millisecond),
+		WithRedriveInterval(time.Second), WithMaxRedriveBackoff(time.Minute))
 	require.NotNil(t, c)
+	require.Equal(t, 1, c.maxRetries)
+	require.Equal(t, time.Millisecond, c.retryBackoff)
+	require.Equal(t, time.Second, c.redriveInterval)
+	require.Equal(t, time.Minute, c.maxRedriveBackoff)
 }
 
 func TestClient_WriteAnchor(t *testing.T) {
-	providers := &Providers{
-		TxnGraph:   graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc),
-		DidTxns:    memdidtxnref.New(),
-		TxnBuilder: &mockTxnBuilder{},
-	}
-
 	t.Run("success", func(t *testing.T) {
-		txnCh := make(chan []string, 100)
-
-		const testDID = "did:method:abc"
+		pub := newPublisher(t)
+		txnCh := pub.RegisterForOrbTxn()
 
 		didTxns := memdidtxnref.New()
 		err := didTxns.Add(testDID, "cid")
 		require.NoError(t, err)
 
-		c := New(namespace, providers, txnCh)
+		didIndex, err := didindex.New(mem.NewProvider())
+		require.NoError(t, err)
 
-		err = c.WriteAnchor("anchor", []*operation.Reference{{UniqueSuffix: testDID}}, 1)
+		txnBuilder := &mockTxnBuilder{}
+		vcStore := &mockVCStore{}
+		pendingStore := newPendingStore(t)
+
+		c := New(namespace, &Providers{
+			TxnGraph:     graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc),
+			DidTxns:      didTxns,
+			TxnBuilder:   txnBuilder,
+			VCStore:      vcStore,
+			DIDIndex:     didIndex,
+			PendingStore: pendingStore,
+			Publisher:    pub,
+		})
+
+		c.Start()
+		defer c.Stop()
+
+		err = c.WriteAnchor(testAnchor, []*operation.Reference{
+			{UniqueSuffix: testDID, Type: operation.TypeUpdate},
+			{UniqueSuffix: testDID2, Type: operation.TypeCreate},
+		}, 1)
 		require.NoError(t, err)
-	})
 
-	t.Run("error - cas error", func(t *testing.T) {
-		txnCh := make(chan []string, 100)
+		cid := receive(t, txnCh)
+		require.Equal(t, []string{vcStore.getCID()}, cid)
+		require.Equal(t, testVCID, vcStore.getID())
 
-		const testDID = "did:method:abc"
+		subject := txnBuilder.getSubject()
+		require.NotNil(t, subject)
+		require.Equal(t, uint64(2), subject.OperationCount)
+		require.Equal(t, []string{"create", "update"}, subject.OperationTypes)
+		require.Equal(t, "QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt", subject.CoreIndex)
+		require.Equal(t, map[string]string{testDID: "cid"}, subject.PreviousTransactions)
 
-		didTxns := memdidtxnref.New()
-		err := didTxns.Add(testDID, "cid")
+		didTxnRefs, err := didTxns.Get(testDID2)
 		require.NoError(t, err)
+		require.Equal(t, cid, didTxnRefs)
+
+		dids, err := didIndex.Get(cid[0])
+		require.NoError(t, err)
+		require.Equal(t, []*didindex.DID{
+			{Suffix: testDID, Type: operation.TypeUpdate},
+			{Suffix: testDID2, Type: operation.TypeCreate},
+		}, dids)
+
+		require.Eventually(t, func() bool {
+			anchors, e := pendingStore.GetAll()
 
-		casErr := errors.New("CAS Error")
-		providersWithErr := &Providers{
-			TxnGraph:   graph.New(mocks.NewMockCasClient(casErr), pubKeyFetcherFnc),
-			DidTxns:    memdidtxnref.New(),
-			TxnBuilder: &mockTxnBuilder{},
+			return e == nil && len(anchors) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("success - queue is full", func(t *testing.T) {
+		f := newFixture(t)
+
+		// fill up the queue with anchors that have already been written
+		for i := 0; i < queueSize; i++ {
+			f.writer.queue <- "anchor"
 		}
 
-		c := New(namespace, providersWithErr, txnCh)
+		// doesn't block
+		require.NoError(t, f.writer.WriteAnchor(testAnchor, []*operation.Reference{{UniqueSuffix: testDID}}, 1))
+		require.Len(t, f.writer.notify, 1)
+
+		// the anchor is persisted and picked up from the pending store
+		f.requirePending(t, pending.StateBuilt)
+
+		f.writer.Start()
+		defer f.writer.Stop()
+
+		cid := receive(t, f.txnCh)
+		require.Equal(t, []string{f.vcStore.getCID()}, cid)
+	})
+
+	t.Run("error - invalid anchor string", func(t *testing.T) {
+		c := New(namespace, &Providers{PendingStore: newPendingStore(t)})
+
+		err := c.WriteAnchor("anchor", []*operation.Reference{{UniqueSuffix: testDID}}, 1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to parse anchor string")
+	})
+
+	t.Run("error - pending store error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("pending error")
+
+		pendingStore, err := pending.New(provider)
+		require.NoError(t, err)
+
+		c := New(namespace, &Providers{
+			DidTxns:      memdidtxnref.New(),
+			PendingStore: pendingStore,
+		})
+
+		err = c.WriteAnchor(testAnchor, []*operation.Reference{{UniqueSuffix: testDID}}, 1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "pending error")
+	})
+}
+
+func TestWriter_Process(t *testing.T) {
+	t.Run("success - stage is retried", func(t *testing.T) {
+		f := newFixture(t)
+		f.vcStore.failures = 2
+
+		f.write(t, testDID)
+
+		cid := receive(t, f.txnCh)
+		require.Equal(t, []string{f.vcStore.getCID()}, cid)
+		f.requireNoPending(t)
+
+		st := f.requireStatus(t, status.StateAnnounced)
+		require.Equal(t, cid[0], st.CID)
+		require.Equal(t, []string{testDID}, st.DIDs)
+		require.Empty(t, st.Error)
+		require.Len(t, st.History, 4)
+		require.Equal(t, status.StateBuilding, st.History[0].State)
+		require.Equal(t, status.StateAwaitingWitnesses, st.History[1].State)
+		require.Equal(t, status.StateStored, st.History[2].State)
+		require.Equal(t, status.StateAnnounced, st.History[3].State)
+
+		st, err := f.anchorStatus.GetByCID(cid[0])
+		require.NoError(t, err)
+		require.Equal(t, testAnchor, st.Anchor)
+	})
+
+	t.Run("success - status error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		anchorStatus, err := status.New(provider)
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.AnchorStatus = anchorStatus
+		f.txnBuilder.Err = errors.New("sign error")
+
+		// status errors are only logged
+		f.write(t, testDID)
+
+		f.txnBuilder.Err = nil
+
+		f.writer.process(testAnchor)
+
+		require.Equal(t, []string{f.vcStore.getCID()}, receive(t, f.txnCh))
+		f.requireNoPending(t)
+	})
+
+	t.Run("success - rebuilt after did transaction reference conflict", func(t *testing.T) {
+		didIndex, err := didindex.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.DIDIndex = didIndex
+		f.didTxns.conflicts = 1
+		f.didTxns.concurrentCID = "other"
+
+		f.write(t, testDID)
+
+		cid := receive(t, f.txnCh)
+
+		// only the rebuilt anchor is in the reverse index
+		require.Len(t, f.didTxns.conflictCIDs, 1)
+		require.NotEqual(t, cid[0], f.didTxns.conflictCIDs[0])
+
+		_, err = didIndex.Get(f.didTxns.conflictCIDs[0])
+		require.True(t, errors.Is(err, didindex.ErrNotFound))
+
+		dids, err := didIndex.Get(cid[0])
+		require.NoError(t, err)
+		require.Equal(t, []*didindex.DID{{Suffix: testDID, Type: operation.TypeCreate}}, dids)
+
+		// the credential was rebuilt with the concurrently added reference as the previous transaction
+		require.Equal(t, map[string]string{testDID: "other"}, f.txnBuilder.getSubject().PreviousTransactions)
+
+		st := f.requireStatus(t, status.StateAnnounced)
+		require.Equal(t, cid[0], st.CID)
+		require.Len(t, st.History, 7)
+		require.Equal(t, status.StateBuilding, st.History[3].State)
+
+		didTxnRefs, err := f.didTxns.Get(testDID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"other", cid[0]}, didTxnRefs)
+
+		f.requireNoPending(t)
+	})
+
+	t.Run("success - partial update is rolled back and rebuilt", func(t *testing.T) {
+		f := newFixture(t)
+		f.didTxns.conflicts = 1
+		f.didTxns.conflictSuffix = testDID2
+
+		f.write(t, testDID, testDID2)
+
+		cid := receive(t, f.txnCh)
+		require.Equal(t, 2, f.didTxns.attempts)
+
+		// the reference that was added by the first attempt was rolled back
+		didTxnRefs, err := f.didTxns.Get(testDID)
+		require.NoError(t, err)
+		require.Equal(t, cid, didTxnRefs)
+
+		f.requireNoPending(t)
+	})
+
+	t.Run("success - already written", func(t *testing.T) {
+		f := newFixture(t)
+
+		f.writer.process(testAnchor)
+		require.Empty(t, f.txnCh)
+	})
+
+	t.Run("error - too many did transaction reference conflicts", func(t *testing.T) {
+		f := newFixture(t, WithMaxConflictRetries(2))
+		f.didTxns.conflicts = 10
+
+		f.write(t, testDID)
 
-		err = c.WriteAnchor("anchor", []*operation.Reference{{UniqueSuffix: testDID}}, 1)
-		require.Equal(t, err, casErr)
+		require.Empty(t, f.txnCh)
+		require.Equal(t, 3, f.didTxns.attempts)
+
+		anchor := f.requirePending(t, pending.StateBuilt)
+		require.Equal(t, 3, anchor.Conflicts)
+		require.Contains(t, anchor.Error, "too many did transaction reference conflicts")
+	})
+
+	t.Run("error - rollback error", func(t *testing.T) {
+		f := newFixture(t)
+		f.didTxns.conflicts = 10
+		f.didTxns.conflictSuffix = testDID2
+		f.didTxns.removeErr = errors.New("remove error")
+
+		f.write(t, testDID, testDID2)
+
+		require.Empty(t, f.txnCh)
+
+		// the anchor remains stored so that its references are reconciled when it's resumed
+		anchor := f.requirePending(t, pending.StateStored)
+		require.Contains(t, anchor.Error, "failed to roll back did transaction reference")
 	})
 
 	t.Run("error - build error", func(t *testing.T) {
-		txnCh := make(chan []string, 100)
+		f := newFixture(t)
+		f.txnBuilder.Err = errors.New("sign error")
 
-		const testDID = "did:method:abc"
+		f.write(t, testDID)
 
-		didTxns := memdidtxnref.New()
-		err := didTxns.Add(testDID, "cid")
+		anchor := f.requirePending(t, pending.StateBuilt)
+		require.Contains(t, anchor.Error, "failed to build anchor credential: sign error")
+
+		st := f.requireStatus(t, status.StateBuilding)
+		require.Contains(t, st.Error, "failed to build anchor credential: sign error")
+		require.NotNil(t, st.ErrorTime)
+	})
+
+	t.Run("error - did transaction reference error", func(t *testing.T) {
+		f := newFixture(t)
+		f.didTxns.lastErr = errors.New("last error")
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateBuilt)
+		require.Contains(t, anchor.Error, "last error")
+	})
+
+	t.Run("error - cas error", func(t *testing.T) {
+		f := newFixture(t)
+		f.writer.TxnGraph = graph.New(mocks.NewMockCasClient(errors.New("CAS Error")), pubKeyFetcherFnc)
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateWitnessed)
+		require.Equal(t, "CAS Error", anchor.Error)
+		require.NotEmpty(t, anchor.VC)
+
+		st := f.requireStatus(t, status.StateAwaitingWitnesses)
+		require.Equal(t, "CAS Error", st.Error)
+	})
+
+	t.Run("error - VC store error", func(t *testing.T) {
+		f := newFixture(t)
+		f.vcStore.Err = errors.New("store error")
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateWitnessed)
+		require.Equal(t, "store error", anchor.Error)
+	})
+
+	t.Run("error - DID index error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("index error")
+
+		didIndex, err := didindex.New(provider)
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.DIDIndex = didIndex
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateStored)
+		require.Contains(t, anchor.Error, "index error")
+	})
+
+	t.Run("error - did transaction reference store error", func(t *testing.T) {
+		f := newFixture(t)
+		f.didTxns.err = errors.New("refs error")
+
+		f.write(t, testDID)
+
+		// not a conflict so the anchor isn't rebuilt
+		anchor := f.requirePending(t, pending.StateStored)
+		require.Equal(t, "refs error", anchor.Error)
+	})
+
+	t.Run("error - writer stopped while announcing", func(t *testing.T) {
+		pub, err := publisher.New(publisher.WithBufferSize(0))
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.Publisher = pub
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+
+			f.writer.Stop()
+		}()
+
+		// blocks until the writer is stopped since nobody receives the anchor
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateIndexed)
+		require.Equal(t, "failed to publish anchor: context canceled", anchor.Error)
+	})
+
+	t.Run("error - writer stopped while storing", func(t *testing.T) {
+		f := newFixture(t)
+		f.writer.Stop()
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateWitnessed)
+		require.Contains(t, anchor.Error, "context canceled")
+	})
+
+	t.Run("error - anchor log error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		anchorLog, err := anchorlog.New(provider)
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.AnchorLog = anchorLog
+
+		f.write(t, testDID)
+
+		require.Empty(t, f.txnCh)
+
+		anchor := f.requirePending(t, pending.StateIndexed)
+		require.Contains(t, anchor.Error, "failed to add anchor to anchor log")
+	})
+
+	t.Run("error - unexpected state", func(t *testing.T) {
+		f := newFixture(t)
+
+		require.NoError(t, f.pendingStore.Put(&pending.Anchor{ID: testAnchor, State: "unknown"}))
+
+		f.writer.process(testAnchor)
+
+		anchor := f.requirePending(t, "unknown")
+		require.Equal(t, "unexpected state [unknown]", anchor.Error)
+	})
+
+	t.Run("error - pending store error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		pendingStore, err := pending.New(provider)
+		require.NoError(t, err)
+
+		f := newFixture(t)
+		f.writer.PendingStore = pendingStore
+
+		require.NoError(t, f.writer.WriteAnchor(testAnchor, []*operation.Reference{{UniqueSuffix: testDID}}, 1))
+
+		provider.Store.ErrGet = errors.New("get error")
+
+		f.writer.process(testAnchor)
+		require.Empty(t, f.txnCh)
+
+		provider.Store.ErrGet = nil
+		provider.Store.ErrPut = errors.New("put error")
+
+		f.writer.process(testAnchor)
+		require.Empty(t, f.txnCh)
+
+		anchor, err := pendingStore.Get(testAnchor)
+		require.NoError(t, err)
+		require.Equal(t, pending.StateBuilt, anchor.State)
+	})
+}
+
+func TestWriter_Resume(t *testing.T) {
+	t.Run("success - references are completed", func(t *testing.T) {
+		f := newFixture(t)
+
+		// an anchor that was stored but whose references were only partially updated before a crash
+		vc, err := f.txnBuilder.Build(&txn.Payload{AnchorString: testAnchor})
 		require.NoError(t, err)
 
-		providersWithErr := &Providers{
-			TxnGraph:   graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc),
-			DidTxns:    memdidtxnref.New(),
-			TxnBuilder: &mockTxnBuilder{Err: errors.New("sign error")},
+		cid, err := f.writer.TxnGraph.Add(context.Background(), vc.Bytes)
+		require.NoError(t, err)
+
+		require.NoError(t, f.didTxns.Add(testDID, "prev"))
+		require.NoError(t, f.didTxns.Add(testDID, cid))
+
+		require.NoError(t, f.pendingStore.Put(&pending.Anchor{
+			ID:      testAnchor,
+			State:   pending.StateStored,
+			Payload: &txn.Payload{AnchorString: testAnchor, PreviousTransactions: map[string]string{testDID: "prev"}},
+			DIDs:    []*didindex.DID{{Suffix: testDID}, {Suffix: testDID2}},
+			VCID:    vc.ID,
+			VC:      vc.Bytes,
+			CID:     cid,
+		}))
+
+		f.writer.Start()
+		defer f.writer.Stop()
+
+		require.Equal(t, []string{cid}, receive(t, f.txnCh))
+
+		didTxnRefs, err := f.didTxns.Get(testDID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"prev", cid}, didTxnRefs)
+
+		didTxnRefs, err = f.didTxns.Get(testDID2)
+		require.NoError(t, err)
+		require.Equal(t, []string{cid}, didTxnRefs)
+
+		require.Eventually(t, func() bool {
+			anchors, e := f.pendingStore.GetAll()
+
+			return e == nil && len(anchors) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("error - pending store error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrItr = errors.New("iterator error")
+
+		pendingStore, err := pending.New(provider)
+		require.NoError(t, err)
+
+		c := New(namespace, &Providers{PendingStore: pendingStore})
+
+		c.resume()
+	})
+}
+
+func TestWriter_Redrive(t *testing.T) {
+	t.Run("success - failed anchor is processed again", func(t *testing.T) {
+		f := newFixture(t, WithRedriveInterval(10*time.Millisecond))
+		f.txnBuilder.Err = errors.New("sign error")
+
+		f.write(t, testDID)
+
+		anchor := f.requirePending(t, pending.StateBuilt)
+		require.Equal(t, 1, anchor.Failures)
+		require.NotNil(t, anchor.NextRetry)
+
+		f.txnBuilder.Err = nil
+
+		f.writer.Start()
+		defer f.writer.Stop()
+
+		cid := receive(t, f.txnCh)
+		require.Equal(t, []string{f.vcStore.getCID()}, cid)
+
+		require.Eventually(t, func() bool {
+			anchors, e := f.pendingStore.GetAll()
+
+			return e == nil && len(anchors) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("success - anchor isn't processed before its retry time", func(t *testing.T) {
+		f := newFixture(t)
+		f.txnBuilder.Err = errors.New("sign error")
+
+		f.write(t, testDID)
+
+		f.txnBuilder.Err = nil
+
+		f.writer.redrive()
+
+		anchor := f.requirePending(t, pending.StateBuilt)
+		require.Equal(t, 1, anchor.Failures)
+
+		f.writer.now = func() time.Time {
+			return anchor.NextRetry.Add(time.Millisecond)
 		}
 
-		c := New(namespace, providersWithErr, txnCh)
+		f.writer.redrive()
 
-		err = c.WriteAnchor("anchor", []*operation.Reference{{UniqueSuffix: testDID}}, 1)
-		require.Contains(t, err.Error(), "failed to build anchor credential: sign error")
+		require.Equal(t, []string{f.vcStore.getCID()}, receive(t, f.txnCh))
+		f.requireNoPending(t)
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		c := New(namespace, &Providers{}, WithRedriveInterval(time.Second), WithMaxRedriveBackoff(5*time.Second))
+
+		require.Equal(t, time.Second, c.redriveBackoff(1))
+		require.Equal(t, 2*time.Second, c.redriveBackoff(2))
+		require.Equal(t, 4*time.Second, c.redriveBackoff(3))
+		require.Equal(t, 5*time.Second, c.redriveBackoff(4))
+		require.Equal(t, 5*time.Second, c.redriveBackoff(100))
 	})
 }
 
@@ -111,26 +597,317 @@ func TestClient_Read(t *testing.T) {
 	}
 
 	t.Run("success", func(t *testing.T) {
-		txnCh := make(chan []string, 100)
-
-		c := New(namespace, providers, txnCh)
+		c := New(namespace, providers)
 
 		more, entries := c.Read(-1)
 		require.False(t, more)
 		require.Empty(t, entries)
 	})
+
+	t.Run("success - anchor log", func(t *testing.T) {
+		f := newFixture(t)
+
+		more, txn := f.writer.Read(-1)
+		require.False(t, more)
+		require.Nil(t, txn)
+
+		f.write(t, testDID)
+
+		cid := receive(t, f.txnCh)
+
+		_, err := f.anchorLog.Append(&anchorlog.Entry{CID: "other", Anchor: "1.other", Namespace: namespace})
+		require.NoError(t, err)
+
+		more, txn = f.writer.Read(-1)
+		require.True(t, more)
+		require.NotNil(t, txn)
+		require.Equal(t, uint64(0), txn.TransactionTime)
+		require.Equal(t, uint64(0), txn.TransactionNumber)
+		require.Equal(t, testAnchor, txn.AnchorString)
+		require.Equal(t, namespace, txn.Namespace)
+		require.Equal(t, uint64(1), txn.ProtocolGenesisTime)
+		require.Equal(t, cid[0], txn.Reference)
+
+		more, txn = f.writer.Read(0)
+		require.False(t, more)
+		require.NotNil(t, txn)
+		require.Equal(t, uint64(1), txn.TransactionNumber)
+		require.Equal(t, "other", txn.Reference)
+
+		more, txn = f.writer.Read(1)
+		require.False(t, more)
+		require.Nil(t, txn)
+	})
+
+	t.Run("error - anchor log error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+
+		anchorLog, err := anchorlog.New(provider)
+		require.NoError(t, err)
+
+		_, err = anchorLog.Append(&anchorlog.Entry{CID: "cid"})
+		require.NoError(t, err)
+
+		provider.Store.ErrGet = errors.New("get error")
+
+		c := New(namespace, &Providers{AnchorLog: anchorLog})
+
+		more, txn := c.Read(-1)
+		require.False(t, more)
+		require.Nil(t, txn)
+	})
+}
+
+const testVCID = "https://orb.domain.com/vc/1234"
+
+type fixture struct {
+	writer       *Writer
+	didTxns      *conflictingDidTxns
+	txnBuilder   *mockTxnBuilder
+	vcStore      *mockVCStore
+	pendingStore *pending.Store
+	anchorStatus *status.Store
+	anchorLog    *anchorlog.Store
+	txnCh        <-chan []string
+}
+
+func newFixture(t *testing.T, opts ...Opt) *fixture {
+	t.Helper()
+
+	f := &fixture{
+		didTxns:      &conflictingDidTxns{MemDidTxnRef: memdidtxnref.New()},
+		txnBuilder:   &mockTxnBuilder{},
+		vcStore:      &mockVCStore{},
+		pendingStore: newPendingStore(t),
+	}
+
+	pub := newPublisher(t)
+	f.txnCh = pub.RegisterForOrbTxn()
+
+	anchorStatus, err := status.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	f.anchorStatus = anchorStatus
+
+	anchorLog, err := anchorlog.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	f.anchorLog = anchorLog
+
+	f.writer = New(namespace, &Providers{
+		TxnGraph:     graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc),
+		DidTxns:      f.didTxns,
+		TxnBuilder:   f.txnBuilder,
+		VCStore:      f.vcStore,
+		PendingStore: f.pendingStore,
+		AnchorStatus: f.anchorStatus,
+		Publisher:    pub,
+		AnchorLog:    f.anchorLog,
+	}, append([]Opt{WithMaxRetries(2), WithRetryBackoff(time.Millisecond)}, opts...)...)
+
+	return f
+}
+
+// write writes an anchor for the given DIDs and processes it synchronously.
+func (f *fixture) write(t *testing.T, dids ...string) {
+	t.Helper()
+
+	refs := make([]*operation.Reference, len(dids))
+
+	for i, did := range dids {
+		refs[i] = &operation.Reference{UniqueSuffix: did, Type: operation.TypeCreate}
+	}
+
+	require.NoError(t, f.writer.WriteAnchor(testAnchor, refs, 1))
+
+	f.writer.process(testAnchor)
+}
+
+func (f *fixture) requirePending(t *testing.T, state pending.State) *pending.Anchor {
+	t.Helper()
+
+	anchor, err := f.pendingStore.Get(testAnchor)
+	require.NoError(t, err)
+	require.Equal(t, state, anchor.State)
+
+	return anchor
+}
+
+func (f *fixture) requireStatus(t *testing.T, state status.State) *status.Status {
+	t.Helper()
+
+	st, err := f.anchorStatus.Get(testAnchor)
+	require.NoError(t, err)
+	require.Equal(t, state, st.State)
+
+	return st
+}
+
+func (f *fixture) requireNoPending(t *testing.T) {
+	t.Helper()
+
+	anchors, err := f.pendingStore.GetAll()
+	require.NoError(t, err)
+	require.Empty(t, anchors)
+}
+
+func newPublisher(t *testing.T) *publisher.Publisher {
+	t.Helper()
+
+	pub, err := publisher.New()
+	require.NoError(t, err)
+
+	return pub
+}
+
+func receive(t *testing.T, txnCh <-chan []string) []string {
+	t.Helper()
+
+	select {
+	case cid := <-txnCh:
+		return cid
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for anchor")
+
+		return nil
+	}
+}
+
+func newPendingStore(t *testing.T) *pending.Store {
+	t.Helper()
+
+	s, err := pending.New(mem.NewProvider())
+	require.NoError(t, err)
+
+	return s
 }
 
 type mockTxnBuilder struct {
-	Err error
+	mutex   sync.Mutex
+	Err     error
+	subject *txn.Payload
 }
 
-func (m *mockTxnBuilder) Build(subject *txn.Payload) (*verifiable.Credential, error) {
+func (m *mockTxnBuilder) Build(subject *txn.Payload) (*builder.Credential, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
 
-	return &verifiable.Credential{Subject: subject}, nil
+	m.mutex.Lock()
+	m.subject = subject
+	m.mutex.Unlock()
+
+	vc := &verifiable.Credential{ID: testVCID, Subject: subject}
+
+	vcBytes, err := vc.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return &builder.Credential{ID: vc.ID, Bytes: vcBytes}, nil
+}
+
+func (m *mockTxnBuilder) getSubject() *txn.Payload {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.subject
+}
+
+type mockVCStore struct {
+	mutex    sync.Mutex
+	Err      error
+	failures int
+	id       string
+	cid      string
+}
+
+func (m *mockVCStore) Put(id, cid string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+
+	if m.failures > 0 {
+		m.failures--
+
+		return errors.New("injected error")
+	}
+
+	m.id = id
+	m.cid = cid
+
+	return nil
+}
+
+func (m *mockVCStore) getID() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.id
+}
+
+func (m *mockVCStore) getCID() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.cid
+}
+
+// conflictingDidTxns simulates concurrent updates of did transaction references.
+type conflictingDidTxns struct {
+	*memdidtxnref.MemDidTxnRef
+	conflicts      int
+	conflictSuffix string
+	concurrentCID  string
+	err            error
+	removeErr      error
+	lastErr        error
+	attempts       int
+	conflictCIDs   []string
+}
+
+func (m *conflictingDidTxns) AddIfLast(did, expectedLast, cid string) error {
+	if m.conflictSuffix == "" || m.conflictSuffix == did {
+		m.attempts++
+
+		if m.err != nil {
+			return m.err
+		}
+
+		if m.conflicts > 0 {
+			m.conflicts--
+			m.conflictCIDs = append(m.conflictCIDs, cid)
+
+			if m.concurrentCID != "" {
+				if err := m.MemDidTxnRef.Add(did, m.concurrentCID); err != nil {
+					return err
+				}
+			}
+
+			return fmt.Errorf("%w: injected conflict", didtxnref.ErrDidTransactionConflict)
+		}
+	}
+
+	return m.MemDidTxnRef.AddIfLast(did, expectedLast, cid)
+}
+
+func (m *conflictingDidTxns) RemoveIfLast(did, cid string) error {
+	if m.removeErr != nil {
+		return m.removeErr
+	}
+
+	return m.MemDidTxnRef.RemoveIfLast(did, cid)
+}
+
+func (m *conflictingDidTxns) Last(did string) (string, error) {
+	if m.lastErr != nil {
+		return "", m.lastErr
+	}
+
+	return m.MemDidTxnRef.Last(did)
 }
 
 var pubKeyFetcherFnc = func(issuerID, keyID string) (*verifier.PublicKey, error) {
@@ -0,0 +1,715 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	log "github.com/sirupsen/logrus"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+)
+
+const (
+	carVersion = 1
+
+	// maxCARSectionSize is the maximum size of a block (including its CID) in a CAR archive.
+	maxCARSectionSize = 8 * 1024 * 1024
+
+	// maxCARFileSize is the maximum size of a unixfs file that is reassembled from the blocks of a CAR archive.
+	maxCARFileSize = 100 * 1024 * 1024
+
+	// maxCARFileDepth is the maximum depth of a unixfs file DAG in a CAR archive. 'ipfs add' creates balanced
+	// DAGs with up to 174 links per node, so a depth of 4 is sufficient for files of several terabytes.
+	maxCARFileDepth = 16
+
+	// maxCARFileNodes is the maximum number of nodes that are visited when a unixfs file is reassembled. Nodes
+	// may be linked several times, so the number of visits (rather than the number of blocks) is limited.
+	maxCARFileNodes = 100000
+
+	// CBOR major types, additional info values and the CBOR tag of a CID (as used by dag-cbor).
+	cborUint       byte = 0
+	cborBytes      byte = 2
+	cborText       byte = 3
+	cborArray      byte = 4
+	cborMap        byte = 5
+	cborTag        byte = 6
+	cborMajorShift      = 5
+	cborInfoMask        = 0x1f
+	cborInfo8      byte = 24
+	cborInfo16     byte = 25
+	cborInfo32     byte = 26
+	cborInfo64     byte = 27
+	cborCIDTag          = 42
+	carHeaderSize       = 2
+
+	// protobuf field numbers and wire types used by dag-pb and unixfs.
+	pbNodeDataField   = 1
+	pbNodeLinksField  = 2
+	pbLinkHashField   = 1
+	unixfsDataField   = 2
+	unixfsSizeField   = 3
+	pbWireVarint      = 0
+	pbWireLengthDelim = 2
+	pbWireTypeMask    = 0x7
+	pbFieldShift      = 3
+)
+
+// ErrInvalidCAR is returned if a CAR archive is malformed or not supported.
+var ErrInvalidCAR = errors.New("invalid CAR archive")
+
+// Block is an IPLD block, i.e. the encoded content (or a part of the content) of a CID.
+type Block struct {
+	CID  cid.Cid
+	Data []byte
+}
+
+// Blocks returns the IPLD blocks of the given content with the given CID, i.e. the blocks of the unixfs file DAG
+// for dag-pb CIDs (computed in the same way as 'ipfs add') and a single block for raw and dag-json CIDs.
+// Sidetree (base64url encoded multihash) addresses are converted to raw CIDs (v1) with the same multihash.
+// An error that wraps ErrContentMismatch is returned if the content doesn't match the CID.
+func Blocks(id string, content []byte) ([]*Block, error) {
+	err := VerifyCID(id, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, e := cid.Decode(id); e != nil {
+		mh, e := encoder.DecodeString(id)
+		if e != nil {
+			return nil, fmt.Errorf("%w [%s]: %s", ErrInvalidCID, id, e)
+		}
+
+		return []*Block{{CID: cid.NewCidV1(cid.Raw, mh), Data: content}}, nil
+	}
+
+	c, err := ParseCID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Type() != cid.DagProtobuf {
+		return []*Block{{CID: c, Data: content}}, nil
+	}
+
+	var blocks []*Block
+
+	_, err = fileDAGForCID(c, content, func(id cid.Cid, block []byte) {
+		blocks = append(blocks, &Block{CID: id, Data: block})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// CARWriter writes IPLD blocks to a CARv1 archive.
+type CARWriter struct {
+	w io.Writer
+}
+
+// NewCARWriter writes the header of a CARv1 archive with the given roots to the given writer
+// and returns a writer for the blocks of the archive.
+func NewCARWriter(w io.Writer, roots []cid.Cid) (*CARWriter, error) {
+	header := encodeCARHeader(roots)
+
+	err := writeSection(w, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write CAR header: %w", err)
+	}
+
+	return &CARWriter{w: w}, nil
+}
+
+// WriteBlock writes the given block to the archive.
+func (c *CARWriter) WriteBlock(b *Block) error {
+	err := writeSection(c.w, append(b.CID.Bytes(), b.Data...))
+	if err != nil {
+		return fmt.Errorf("failed to write block [%s] to CAR: %w", b.CID, err)
+	}
+
+	return nil
+}
+
+// CARReader reads the IPLD blocks of a CARv1 archive.
+type CARReader struct {
+	r     *bufio.Reader
+	Roots []cid.Cid
+}
+
+// NewCARReader reads the header of the CARv1 archive from the given reader and returns a reader for the
+// blocks of the archive. An error that wraps ErrInvalidCAR is returned if the header is invalid.
+func NewCARReader(r io.Reader) (*CARReader, error) {
+	br := bufio.NewReader(r)
+
+	header, err := readSection(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: missing header", ErrInvalidCAR)
+		}
+
+		return nil, err
+	}
+
+	roots, err := decodeCARHeader(header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCAR, err)
+	}
+
+	return &CARReader{r: br, Roots: roots}, nil
+}
+
+// Next returns the next block of the archive. The block is verified against its CID. Returns io.EOF if there
+// are no more blocks.
+func (c *CARReader) Next() (*Block, error) {
+	section, err := readSection(c.r)
+	if err != nil {
+		return nil, err
+	}
+
+	n, id, err := cid.CidFromBytes(section)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid block CID: %s", ErrInvalidCAR, err)
+	}
+
+	data := section[n:]
+
+	expected, err := id.Prefix().Sum(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to verify block [%s]: %s", ErrInvalidCAR, id, err)
+	}
+
+	if !expected.Equals(id) {
+		return nil, fmt.Errorf("%w: %s", ErrContentMismatch, id)
+	}
+
+	return &Block{CID: id, Data: data}, nil
+}
+
+// CARImport is the result of importing a CAR archive.
+type CARImport struct {
+	Roots []string `json:"roots"`
+	// CIDs contains the CIDs of the content (files and IPLD nodes) that was written to CAS.
+	CIDs []string `json:"cids"`
+}
+
+// ImportCAR reads the given CARv1 archive and writes its content to the given CAS client. unixfs file DAGs
+// (dag-pb) are reassembled into files, dag-json nodes are written as IPLD nodes (the client must support IPLD
+// nodes) and other raw blocks are written as is. Every block is verified against its CID.
+func ImportCAR(ctx context.Context, c casapi.Client, r io.Reader) (*CARImport, error) {
+	reader, err := NewCARReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CARImport{}
+
+	for _, root := range reader.Roots {
+		result.Roots = append(result.Roots, root.String())
+	}
+
+	var ordered []*Block
+
+	blocks := make(map[string]*Block)
+	linked := make(map[string]bool)
+
+	for {
+		b, e := reader.Next()
+		if errors.Is(e, io.EOF) {
+			break
+		}
+
+		if e != nil {
+			return nil, e
+		}
+
+		if b.CID.Type() == cid.DagProtobuf {
+			_, links, e := decodeDAGPB(b.Data)
+			if e != nil {
+				return nil, fmt.Errorf("%w: invalid dag-pb block [%s]: %s", ErrInvalidCAR, b.CID, e)
+			}
+
+			for _, link := range links {
+				linked[string(link.Hash())] = true
+			}
+		}
+
+		ordered = append(ordered, b)
+		blocks[string(b.CID.Hash())] = b
+	}
+
+	for _, b := range ordered {
+		// blocks that are linked from a file DAG are written as part of the file
+		if linked[string(b.CID.Hash())] {
+			continue
+		}
+
+		id, e := importBlock(ctx, c, b, blocks)
+		if e != nil {
+			return nil, fmt.Errorf("failed to import [%s]: %w", b.CID, e)
+		}
+
+		result.CIDs = append(result.CIDs, id)
+	}
+
+	return result, nil
+}
+
+func importBlock(ctx context.Context, c casapi.Client, b *Block, blocks map[string]*Block) (string, error) {
+	switch b.CID.Type() {
+	case DagJSON:
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return "", fmt.Errorf("write IPLD node: %w", ErrNotSupported)
+		}
+
+		id, err := nc.WriteNode(ctx, b.Data)
+
+		return checkImported(b.CID, id, err)
+	case cid.DagProtobuf:
+		content, err := decodeFile(b, blocks)
+		if err != nil {
+			return "", err
+		}
+
+		id, err := WriteWithContext(ctx, c, content)
+
+		return checkImported(b.CID, id, err)
+	default:
+		return WriteWithContext(ctx, c, b.Data)
+	}
+}
+
+// checkImported logs a warning if content was written to CAS with a CID other than the CID in the archive.
+func checkImported(expected cid.Cid, id string, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+
+	if c, e := cid.Decode(id); e != nil || !bytes.Equal(c.Hash(), expected.Hash()) {
+		log.Warnf("CAS returned cid %s for imported content with cid %s", id, expected)
+	}
+
+	return id, nil
+}
+
+// decodeFile reassembles the content of the unixfs file with the given root block. The file is rejected if it
+// exceeds the maximum size, depth or number of nodes, or if a node's content doesn't match its unixfs filesize.
+func decodeFile(root *Block, blocks map[string]*Block) ([]byte, error) {
+	d := &fileDecoder{root: root.CID, blocks: blocks, content: &bytes.Buffer{}}
+
+	err := d.decode(root, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.content.Bytes(), nil
+}
+
+// fileDecoder reassembles the content of a unixfs file DAG.
+type fileDecoder struct {
+	root    cid.Cid
+	blocks  map[string]*Block
+	content *bytes.Buffer
+	nodes   int
+}
+
+func (d *fileDecoder) decode(b *Block, depth int) error {
+	d.nodes++
+
+	if d.nodes > maxCARFileNodes {
+		return fmt.Errorf("%w: file [%s] has more than %d nodes", ErrInvalidCAR, d.root, maxCARFileNodes)
+	}
+
+	if depth > maxCARFileDepth {
+		return fmt.Errorf("%w: file [%s] is deeper than %d levels", ErrInvalidCAR, d.root, maxCARFileDepth)
+	}
+
+	if b.CID.Type() != cid.DagProtobuf {
+		return d.write(b.Data)
+	}
+
+	data, links, err := decodeDAGPB(b.Data)
+	if err != nil {
+		return fmt.Errorf("%w: invalid dag-pb block [%s]: %s", ErrInvalidCAR, b.CID, err)
+	}
+
+	nodeData, fileSize, hasFileSize, err := decodeUnixFSData(data)
+	if err != nil {
+		return fmt.Errorf("%w: invalid unixfs data in block [%s]: %s", ErrInvalidCAR, b.CID, err)
+	}
+
+	if hasFileSize && fileSize > uint64(maxCARFileSize-d.content.Len()) {
+		return fmt.Errorf("%w: file [%s] exceeds the maximum size of %d bytes", ErrInvalidCAR, d.root, maxCARFileSize)
+	}
+
+	start := d.content.Len()
+
+	if len(links) == 0 {
+		err = d.write(nodeData)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, link := range links {
+		child, ok := d.blocks[string(link.Hash())]
+		if !ok {
+			return fmt.Errorf("%w: missing block [%s] of file [%s]", ErrInvalidCAR, link, d.root)
+		}
+
+		err = d.decode(child, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if hasFileSize && uint64(d.content.Len()-start) != fileSize {
+		return fmt.Errorf("%w: block [%s] of file [%s] has %d bytes of content but a filesize of %d",
+			ErrInvalidCAR, b.CID, d.root, d.content.Len()-start, fileSize)
+	}
+
+	return nil
+}
+
+func (d *fileDecoder) write(data []byte) error {
+	if len(data) > maxCARFileSize-d.content.Len() {
+		return fmt.Errorf("%w: file [%s] exceeds the maximum size of %d bytes", ErrInvalidCAR, d.root, maxCARFileSize)
+	}
+
+	d.content.Write(data)
+
+	return nil
+}
+
+// decodeDAGPB returns the data and the links of the given dag-pb node.
+func decodeDAGPB(block []byte) ([]byte, []cid.Cid, error) {
+	var data []byte
+
+	var links []cid.Cid
+
+	err := decodeProtobuf(block, func(field uint64, value []byte) error {
+		switch field {
+		case pbNodeDataField:
+			data = value
+		case pbNodeLinksField:
+			return decodeProtobuf(value, func(field uint64, value []byte) error {
+				if field != pbLinkHashField {
+					return nil
+				}
+
+				link, err := cid.Cast(value)
+				if err != nil {
+					return fmt.Errorf("invalid link: %w", err)
+				}
+
+				links = append(links, link)
+
+				return nil
+			}, nil)
+		}
+
+		return nil
+	}, nil)
+
+	return data, links, err
+}
+
+// decodeUnixFSData returns the file content (of a leaf node) and the filesize (if set) of the given unixfs data.
+func decodeUnixFSData(unixfs []byte) ([]byte, uint64, bool, error) {
+	var content []byte
+
+	var fileSize uint64
+
+	hasFileSize := false
+
+	err := decodeProtobuf(unixfs, func(field uint64, value []byte) error {
+		if field == unixfsDataField {
+			content = value
+		}
+
+		return nil
+	}, func(field, value uint64) {
+		if field == unixfsSizeField {
+			fileSize = value
+			hasFileSize = true
+		}
+	})
+
+	return content, fileSize, hasFileSize, err
+}
+
+// decodeProtobuf invokes fn for every length-delimited field and varintFn (if not nil) for every varint field
+// of the given protobuf message.
+func decodeProtobuf(msg []byte, fn func(field uint64, value []byte) error, varintFn func(field, value uint64)) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errors.New("invalid protobuf key")
+		}
+
+		msg = msg[n:]
+
+		switch key & pbWireTypeMask {
+		case pbWireVarint:
+			value, n := binary.Uvarint(msg)
+			if n <= 0 {
+				return errors.New("invalid protobuf varint")
+			}
+
+			if varintFn != nil {
+				varintFn(key>>pbFieldShift, value)
+			}
+
+			msg = msg[n:]
+		case pbWireLengthDelim:
+			length, n := binary.Uvarint(msg)
+			if n <= 0 || length > uint64(len(msg)-n) {
+				return errors.New("invalid protobuf length")
+			}
+
+			err := fn(key>>pbFieldShift, msg[n:n+int(length)])
+			if err != nil {
+				return err
+			}
+
+			msg = msg[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", key&pbWireTypeMask)
+		}
+	}
+
+	return nil
+}
+
+func writeSection(w io.Writer, section []byte) error {
+	buf := &bytes.Buffer{}
+
+	writeUvarint(buf, uint64(len(section)))
+	buf.Write(section)
+
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
+
+func readSection(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+
+		return nil, fmt.Errorf("%w: invalid section length: %s", ErrInvalidCAR, err)
+	}
+
+	if length > maxCARSectionSize {
+		return nil, fmt.Errorf("%w: section too large: %d", ErrInvalidCAR, length)
+	}
+
+	section := make([]byte, length)
+
+	_, err = io.ReadFull(r, section)
+	if err != nil {
+		return nil, fmt.Errorf("%w: truncated section: %s", ErrInvalidCAR, err)
+	}
+
+	return section, nil
+}
+
+// encodeCARHeader returns the dag-cbor encoded CARv1 header, i.e. {"roots": [CID...], "version": 1}.
+func encodeCARHeader(roots []cid.Cid) []byte {
+	buf := &bytes.Buffer{}
+
+	writeCBORHead(buf, cborMap, carHeaderSize)
+
+	// keys are sorted by length (dag-cbor canonical ordering)
+	writeCBORText(buf, "roots")
+	writeCBORHead(buf, cborArray, uint64(len(roots)))
+
+	for _, root := range roots {
+		// CIDs are encoded as byte strings with a leading zero (the multibase prefix for binary)
+		b := append([]byte{0}, root.Bytes()...)
+
+		writeCBORHead(buf, cborTag, cborCIDTag)
+		writeCBORHead(buf, cborBytes, uint64(len(b)))
+		buf.Write(b)
+	}
+
+	writeCBORText(buf, "version")
+	writeCBORHead(buf, cborUint, carVersion)
+
+	return buf.Bytes()
+}
+
+func decodeCARHeader(header []byte) ([]cid.Cid, error) {
+	r := bytes.NewReader(header)
+
+	entries, err := readCBORHead(r, cborMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []cid.Cid
+
+	version := uint64(0)
+
+	for i := uint64(0); i < entries; i++ {
+		key, err := readCBORText(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "version":
+			version, err = readCBORHead(r, cborUint)
+			if err != nil {
+				return nil, err
+			}
+		case "roots":
+			roots, err = readCBORRoots(r)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported header field [%s]", key)
+		}
+	}
+
+	if version != carVersion {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+
+	return roots, nil
+}
+
+func readCBORRoots(r *bytes.Reader) ([]cid.Cid, error) {
+	n, err := readCBORHead(r, cborArray)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []cid.Cid
+
+	for i := uint64(0); i < n; i++ {
+		tag, err := readCBORHead(r, cborTag)
+		if err != nil {
+			return nil, err
+		}
+
+		if tag != cborCIDTag {
+			return nil, fmt.Errorf("unexpected CBOR tag %d", tag)
+		}
+
+		b, err := readCBORBytes(r, cborBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(b) == 0 || b[0] != 0 {
+			return nil, errors.New("invalid root CID")
+		}
+
+		root, err := cid.Cast(b[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid root CID: %w", err)
+		}
+
+		roots = append(roots, root)
+	}
+
+	return roots, nil
+}
+
+func writeCBORHead(buf *bytes.Buffer, major byte, v uint64) {
+	major <<= cborMajorShift
+
+	switch {
+	case v < uint64(cborInfo8):
+		buf.WriteByte(major | byte(v))
+	case v <= math.MaxUint8:
+		buf.WriteByte(major | cborInfo8)
+		buf.WriteByte(byte(v))
+	case v <= math.MaxUint16:
+		buf.WriteByte(major | cborInfo16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(v))
+	case v <= math.MaxUint32:
+		buf.WriteByte(major | cborInfo32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(v))
+	default:
+		buf.WriteByte(major | cborInfo64)
+		_ = binary.Write(buf, binary.BigEndian, v)
+	}
+}
+
+func writeCBORText(buf *bytes.Buffer, s string) {
+	writeCBORHead(buf, cborText, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+// readCBORHead reads the head of a CBOR data item of the given major type and returns its argument.
+func readCBORHead(r *bytes.Reader, major byte) (uint64, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, errors.New("unexpected end of header")
+	}
+
+	if b>>cborMajorShift != major {
+		return 0, fmt.Errorf("unexpected CBOR major type %d", b>>cborMajorShift)
+	}
+
+	info := b & cborInfoMask
+
+	if info < cborInfo8 {
+		return uint64(info), nil
+	}
+
+	if info > cborInfo64 {
+		return 0, fmt.Errorf("unsupported CBOR additional info %d", info)
+	}
+
+	// the argument is a big-endian unsigned integer of 1, 2, 4 or 8 bytes
+	var arg [8]byte
+
+	if _, err := io.ReadFull(r, arg[len(arg)-1<<(info-cborInfo8):]); err != nil {
+		return 0, errors.New("unexpected end of header")
+	}
+
+	return binary.BigEndian.Uint64(arg[:]), nil
+}
+
+func readCBORBytes(r *bytes.Reader, major byte) ([]byte, error) {
+	n, err := readCBORHead(r, major)
+	if err != nil {
+		return nil, err
+	}
+
+	if n > uint64(r.Len()) {
+		return nil, errors.New("unexpected end of header")
+	}
+
+	b := make([]byte, n)
+
+	_, _ = io.ReadFull(r, b)
+
+	return b, nil
+}
+
+func readCBORText(r *bytes.Reader) (string, error) {
+	b, err := readCBORBytes(r, cborText)
+
+	return string(b), err
+}
@@ -0,0 +1,462 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+)
+
+func TestBlocks(t *testing.T) {
+	t.Run("dag-pb", func(t *testing.T) {
+		blocks, err := Blocks(helloWorldCID, []byte("hello world"))
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, helloWorldCID, blocks[0].CID.String())
+	})
+
+	t.Run("dag-pb - multiple blocks", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*chunkSize+1)
+
+		id, err := GetCID(content)
+		require.NoError(t, err)
+
+		blocks, err := Blocks(id, content)
+		require.NoError(t, err)
+		require.Len(t, blocks, 4)
+		require.Equal(t, id, blocks[3].CID.String())
+	})
+
+	t.Run("dag-pb - v1 with raw leaves", func(t *testing.T) {
+		const id = "bafybeiexg2oqkfnj56l7fcmawswqbijt5shq4b5rg6a546uwpkqqzwjioi"
+
+		content := testContent(chunkSize + 1)
+
+		blocks, err := Blocks(id, content)
+		require.NoError(t, err)
+		require.Len(t, blocks, 3)
+		require.Equal(t, uint64(cid.Raw), blocks[0].CID.Type())
+		require.Equal(t, content[:chunkSize], blocks[0].Data)
+		require.Equal(t, id, blocks[2].CID.String())
+
+		var imported []byte
+
+		for _, b := range blocks[:2] {
+			imported = append(imported, b.Data...)
+		}
+
+		require.Equal(t, content, imported)
+	})
+
+	t.Run("dag-pb - v1 with dag-pb leaves", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*chunkSize+1)
+
+		v0, err := GetCID(content)
+		require.NoError(t, err)
+
+		c, err := cid.Decode(v0)
+		require.NoError(t, err)
+
+		id := cid.NewCidV1(cid.DagProtobuf, c.Hash())
+
+		blocks, err := Blocks(id.String(), content)
+		require.NoError(t, err)
+		require.Len(t, blocks, 4)
+		require.Equal(t, uint64(cid.DagProtobuf), blocks[0].CID.Type())
+		require.Equal(t, id, blocks[3].CID)
+	})
+
+	t.Run("dag-json", func(t *testing.T) {
+		blocks, err := Blocks(nodeCID(t), []byte("{}"))
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, nodeCID(t), blocks[0].CID.String())
+	})
+
+	t.Run("Sidetree address", func(t *testing.T) {
+		address, err := mocks.NewMockCasClient(nil).Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		blocks, err := Blocks(address, []byte("hello world"))
+		require.NoError(t, err)
+		require.Len(t, blocks, 1)
+		require.Equal(t, uint64(cid.Raw), blocks[0].CID.Type())
+	})
+
+	t.Run("error - content mismatch", func(t *testing.T) {
+		blocks, err := Blocks(helloWorldCID, []byte("forged"))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+		require.Nil(t, blocks)
+	})
+}
+
+func TestImportCAR(t *testing.T) {
+	largeContent := largeTestContent()
+
+	largeCID, err := GetCID(largeContent)
+	require.NoError(t, err)
+
+	address, err := mocks.NewMockCasClient(nil).Write([]byte("sidetree"))
+	require.NoError(t, err)
+
+	contents := map[string][]byte{
+		helloWorldCID: []byte("hello world"),
+		largeCID:      largeContent,
+		nodeCID(t):    []byte("{}"),
+		address:       []byte("sidetree"),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		car := writeCAR(t, []string{nodeCID(t), helloWorldCID}, contents)
+
+		c := newLocal(t)
+
+		result, err := ImportCAR(context.Background(), c, bytes.NewReader(car))
+		require.NoError(t, err)
+		require.Equal(t, []string{nodeCID(t), helloWorldCID}, result.Roots)
+		require.Len(t, result.CIDs, 4)
+
+		content, err := c.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		content, err = c.Read(largeCID)
+		require.NoError(t, err)
+		require.Equal(t, largeContent, content)
+
+		node, err := c.ReadNode(context.Background(), nodeCID(t))
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(node))
+
+		// raw blocks are written as is (the CAS determines the CID)
+		id, err := GetCID([]byte("sidetree"))
+		require.NoError(t, err)
+		require.Contains(t, result.CIDs, id)
+	})
+
+	t.Run("success - many roots", func(t *testing.T) {
+		var roots []string
+
+		for i := 0; i < 30; i++ {
+			roots = append(roots, helloWorldCID)
+		}
+
+		reader, err := NewCARReader(bytes.NewReader(writeCAR(t, roots, nil)))
+		require.NoError(t, err)
+		require.Len(t, reader.Roots, 30)
+
+		_, err = reader.Next()
+		require.Equal(t, io.EOF, err)
+	})
+
+	t.Run("error - IPLD nodes not supported", func(t *testing.T) {
+		car := writeCAR(t, nil, map[string][]byte{nodeCID(t): []byte("{}")})
+
+		result, err := ImportCAR(context.Background(), mocks.NewMockCasClient(nil), bytes.NewReader(car))
+		require.True(t, errors.Is(err, ErrNotSupported))
+		require.Nil(t, result)
+	})
+
+	t.Run("error - missing block", func(t *testing.T) {
+		blocks, err := Blocks(largeCID, largeContent)
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+
+		w, err := NewCARWriter(buf, nil)
+		require.NoError(t, err)
+
+		// skip the first leaf
+		for _, b := range blocks[1:] {
+			require.NoError(t, w.WriteBlock(b))
+		}
+
+		result, err := ImportCAR(context.Background(), newLocal(t), buf)
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "missing block")
+		require.Nil(t, result)
+	})
+
+	t.Run("error - forged block", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+
+		w, err := NewCARWriter(buf, nil)
+		require.NoError(t, err)
+
+		id, err := cid.Decode(nodeCID(t))
+		require.NoError(t, err)
+
+		require.NoError(t, w.WriteBlock(&Block{CID: id, Data: []byte(`{"forged":true}`)}))
+
+		result, err := ImportCAR(context.Background(), newLocal(t), buf)
+		require.True(t, errors.Is(err, ErrContentMismatch))
+		require.Nil(t, result)
+	})
+
+	t.Run("error - invalid header", func(t *testing.T) {
+		result, err := ImportCAR(context.Background(), newLocal(t), bytes.NewReader(nil))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "missing header")
+		require.Nil(t, result)
+
+		result, err = ImportCAR(context.Background(), newLocal(t), bytes.NewReader([]byte{3, 0xa1, 0x61, 0x78}))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Nil(t, result)
+
+		header := &bytes.Buffer{}
+		writeCBORHead(header, cborMap, 1)
+		writeCBORText(header, "version")
+		writeCBORHead(header, cborUint, 2)
+
+		car := &bytes.Buffer{}
+		require.NoError(t, writeSection(car, header.Bytes()))
+
+		result, err = ImportCAR(context.Background(), newLocal(t), car)
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "unsupported version 2")
+		require.Nil(t, result)
+	})
+
+	t.Run("error - truncated block", func(t *testing.T) {
+		car := writeCAR(t, nil, map[string][]byte{helloWorldCID: []byte("hello world")})
+
+		result, err := ImportCAR(context.Background(), newLocal(t), bytes.NewReader(car[:len(car)-1]))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "truncated section")
+		require.Nil(t, result)
+	})
+}
+
+func TestDecodeFile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		leaf := testBlock(t, cid.Raw, []byte("hello"))
+		root := testFileNode(t, []*Block{leaf, leaf}, 10, true)
+
+		content, err := decodeFile(root, testBlockMap(leaf))
+		require.NoError(t, err)
+		require.Equal(t, "hellohello", string(content))
+	})
+
+	t.Run("error - filesize exceeds maximum size", func(t *testing.T) {
+		leaf := testBlock(t, cid.Raw, []byte("hello"))
+		root := testFileNode(t, []*Block{leaf}, maxCARFileSize+1, true)
+
+		content, err := decodeFile(root, testBlockMap(leaf))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "exceeds the maximum size")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - content exceeds maximum size", func(t *testing.T) {
+		leaf := testBlock(t, cid.Raw, make([]byte, maxCARFileSize/10))
+
+		var links []*Block
+
+		for i := 0; i < 11; i++ {
+			links = append(links, leaf)
+		}
+
+		// the filesize is optional so the size of the content is also checked while it's reassembled
+		root := testFileNode(t, links, 0, false)
+
+		content, err := decodeFile(root, testBlockMap(leaf))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "exceeds the maximum size")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - filesize mismatch", func(t *testing.T) {
+		leaf := testBlock(t, cid.Raw, []byte("hello"))
+		root := testFileNode(t, []*Block{leaf}, 4, true)
+
+		content, err := decodeFile(root, testBlockMap(leaf))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "has 5 bytes of content but a filesize of 4")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - too deep", func(t *testing.T) {
+		node := testBlock(t, cid.Raw, []byte("hello"))
+		blocks := testBlockMap(node)
+
+		for i := 0; i <= maxCARFileDepth; i++ {
+			node = testFileNode(t, []*Block{node}, 5, true)
+			blocks[string(node.CID.Hash())] = node
+		}
+
+		content, err := decodeFile(node, blocks)
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "is deeper than")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - too many nodes", func(t *testing.T) {
+		// an empty leaf that is linked many times doesn't add any content
+		leaf := testBlock(t, cid.Raw, nil)
+
+		var links []*Block
+
+		for i := 0; i < maxLinks; i++ {
+			links = append(links, leaf)
+		}
+
+		node := testFileNode(t, links, 0, true)
+
+		links = nil
+
+		for i := 0; i < maxLinks; i++ {
+			links = append(links, node)
+		}
+
+		node2 := testFileNode(t, links, 0, true)
+
+		for i := 0; i < maxLinks; i++ {
+			links[i] = node2
+		}
+
+		root := testFileNode(t, links, 0, true)
+
+		content, err := decodeFile(root, testBlockMap(leaf, node, node2))
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "nodes")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - invalid unixfs data", func(t *testing.T) {
+		node := &bytes.Buffer{}
+
+		node.WriteByte(pbNodeDataTag)
+		writeUvarint(node, 1)
+		node.WriteByte(unixfsFileSizeTag)
+
+		content, err := decodeFile(testBlock(t, cid.DagProtobuf, node.Bytes()), nil)
+		require.True(t, errors.Is(err, ErrInvalidCAR))
+		require.Contains(t, err.Error(), "invalid unixfs data")
+		require.Nil(t, content)
+	})
+}
+
+func TestCBORHead(t *testing.T) {
+	for _, v := range []uint64{0, 23, 24, 255, 256, 65535, 65536, 1 << 32, 1<<64 - 1} {
+		buf := &bytes.Buffer{}
+		writeCBORHead(buf, cborUint, v)
+
+		decoded, err := readCBORHead(bytes.NewReader(buf.Bytes()), cborUint)
+		require.NoError(t, err)
+		require.Equal(t, v, decoded)
+	}
+
+	_, err := readCBORHead(bytes.NewReader([]byte{0x1c}), cborUint)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported CBOR additional info")
+
+	_, err = readCBORHead(bytes.NewReader([]byte{0x19, 0x01}), cborUint)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected end of header")
+}
+
+// largeTestContent returns content that is split into three different leaf blocks.
+func largeTestContent() []byte {
+	content := make([]byte, 2*chunkSize+1)
+
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+
+	return content
+}
+
+func writeCAR(t *testing.T, roots []string, contents map[string][]byte) []byte {
+	t.Helper()
+
+	var rootCIDs []cid.Cid
+
+	for _, root := range roots {
+		c, err := cid.Decode(root)
+		require.NoError(t, err)
+
+		rootCIDs = append(rootCIDs, c)
+	}
+
+	buf := &bytes.Buffer{}
+
+	w, err := NewCARWriter(buf, rootCIDs)
+	require.NoError(t, err)
+
+	for id, content := range contents {
+		blocks, err := Blocks(id, content)
+		require.NoError(t, err)
+
+		for _, b := range blocks {
+			require.NoError(t, w.WriteBlock(b))
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func testBlock(t *testing.T, codec uint64, data []byte) *Block {
+	t.Helper()
+
+	id, err := cid.V1Builder{Codec: codec, MhType: multihash.SHA2_256}.Sum(data)
+	require.NoError(t, err)
+
+	return &Block{CID: id, Data: data}
+}
+
+// testFileNode returns a dag-pb encoded unixfs file node with the given links and (optional) filesize.
+func testFileNode(t *testing.T, links []*Block, fileSize uint64, hasFileSize bool) *Block {
+	t.Helper()
+
+	node := &bytes.Buffer{}
+
+	for _, l := range links {
+		link := &bytes.Buffer{}
+
+		link.WriteByte(pbLinkHashTag)
+		writeUvarint(link, uint64(len(l.CID.Bytes())))
+		link.Write(l.CID.Bytes())
+
+		node.WriteByte(pbNodeLinksTag)
+		writeUvarint(node, uint64(link.Len()))
+		node.Write(link.Bytes())
+	}
+
+	unixfs := &bytes.Buffer{}
+
+	unixfs.WriteByte(unixfsTypeTag)
+	writeUvarint(unixfs, unixfsTypeFile)
+
+	if hasFileSize {
+		unixfs.WriteByte(unixfsFileSizeTag)
+		writeUvarint(unixfs, fileSize)
+	}
+
+	node.WriteByte(pbNodeDataTag)
+	writeUvarint(node, uint64(unixfs.Len()))
+	node.Write(unixfs.Bytes())
+
+	return testBlock(t, cid.DagProtobuf, node.Bytes())
+}
+
+func testBlockMap(blocks ...*Block) map[string]*Block {
+	m := make(map[string]*Block)
+
+	for _, b := range blocks {
+		m[string(b.CID.Hash())] = b
+	}
+
+	return m
+}
@@ -0,0 +1,460 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/hashing"
+)
+
+const (
+	// DagJSON is the multicodec code of dag-json encoded IPLD nodes. (This version of go-cid does not
+	// define it.)
+	DagJSON = 0x0129
+
+	// chunkSize is the default IPFS chunk size. Content that is larger than this is split into
+	// multiple leaf blocks by IPFS.
+	chunkSize = 256 * 1024
+
+	// maxLinks is the default maximum number of links of a (balanced) IPFS file DAG node.
+	maxLinks = 174
+
+	// protobuf field tags of the dag-pb node and link and of the unixfs data.
+	pbNodeDataTag              = 0x0a
+	pbNodeLinksTag             = 0x12
+	pbLinkHashTag              = 0x0a
+	pbLinkNameTag              = 0x12
+	pbLinkTsizeTag             = 0x18
+	unixfsTypeTag              = 0x08
+	unixfsDataTag              = 0x12
+	unixfsFileSizeTag          = 0x18
+	unixfsBlockSizesTag        = 0x20
+	unixfsTypeFile      uint64 = 2
+)
+
+// ErrContentMismatch is returned if the content does not hash to the given CID.
+var ErrContentMismatch = errors.New("content does not match CID")
+
+// ErrInvalidCID is returned if the given string is not a valid (or supported) CID.
+var ErrInvalidCID = errors.New("invalid CID")
+
+// MultihashAlgorithmsProvider returns the multihash algorithms that are allowed in CIDs (e.g. the
+// MultihashAlgorithms of the current protocol version).
+type MultihashAlgorithmsProvider func() ([]uint, error)
+
+// GetCID returns the CID (v0) of the given content that is computed in the same way as 'ipfs add'
+// with default options, i.e. the content is chunked into unixfs file nodes which are encoded as dag-pb
+// and linked in a balanced DAG.
+func GetCID(content []byte) (string, error) {
+	root, err := fileDAG(content, false, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return root.String(), nil
+}
+
+// GetDAGJSONCID returns the CID (v1) of the given dag-json encoded IPLD node, i.e. the CID of
+// the node stored as a single block with the dag-json codec.
+func GetDAGJSONCID(node []byte) (string, error) {
+	mh, err := multihash.Sum(node, multihash.SHA2_256, -1)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute multihash: %w", err)
+	}
+
+	return cid.NewCidV1(DagJSON, mh).String(), nil
+}
+
+// IsDAGJSON returns true if the given ID is a CID of a dag-json encoded IPLD node.
+func IsDAGJSON(id string) bool {
+	c, err := cid.Decode(id)
+	if err != nil {
+		return false
+	}
+
+	return c.Type() == DagJSON
+}
+
+// ParseCID parses the given IPFS CID (v0 or v1 with dag-pb, raw or dag-json codec). If multihash algorithms
+// are provided (e.g. the protocol's MultihashAlgorithms) then the multihash of the CID must use one of them.
+// An error that wraps ErrInvalidCID is returned if the CID is malformed or not supported.
+func ParseCID(id string, algorithms ...uint) (cid.Cid, error) {
+	c, err := cid.Decode(id)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("%w [%s]: %s", ErrInvalidCID, id, err)
+	}
+
+	prefix := c.Prefix()
+
+	switch prefix.Codec {
+	case cid.DagProtobuf:
+		// the unixfs file DAG is only computed with the IPFS default hash
+		if prefix.MhType != multihash.SHA2_256 {
+			return cid.Undef, fmt.Errorf("%w [%s]: unsupported hash type %d", ErrInvalidCID, id, prefix.MhType)
+		}
+	case cid.Raw, DagJSON:
+	default:
+		return cid.Undef, fmt.Errorf("%w [%s]: unsupported codec %d", ErrInvalidCID, id, prefix.Codec)
+	}
+
+	if !isAllowed(prefix.MhType, algorithms) {
+		return cid.Undef, fmt.Errorf("%w [%s]: unsupported hash type %d", ErrInvalidCID, id, prefix.MhType)
+	}
+
+	return c, nil
+}
+
+// ValidateCID validates the given CID which may be either an IPFS CID (see ParseCID) or a Sidetree
+// (base64url encoded multihash) address. If multihash algorithms are provided then the CID must use one of them.
+// An error that wraps ErrInvalidCID is returned if the CID is invalid.
+func ValidateCID(id string, algorithms ...uint) error {
+	if _, err := cid.Decode(id); err != nil {
+		_, err = multihashCode(id, algorithms)
+
+		return err
+	}
+
+	_, err := ParseCID(id, algorithms...)
+
+	return err
+}
+
+// VerifyCID verifies that the given content hashes to the given CID. IPFS CIDs (v0 and v1 with
+// dag-pb, raw or dag-json codec) are supported as well as Sidetree (base64url encoded multihash) addresses.
+// The file DAG of a v1 dag-pb CID is recomputed with raw leaves (as 'ipfs add --cid-version=1').
+// If multihash algorithms are provided then the CID must use one of them.
+// An error that wraps ErrInvalidCID is returned if the CID is invalid and an error that wraps
+// ErrContentMismatch is returned if the content doesn't match the CID.
+func VerifyCID(id string, content []byte, algorithms ...uint) error {
+	if _, err := cid.Decode(id); err != nil {
+		return verifyMultihash(id, content, algorithms)
+	}
+
+	c, err := ParseCID(id, algorithms...)
+	if err != nil {
+		return err
+	}
+
+	prefix := c.Prefix()
+
+	var expected cid.Cid
+
+	if prefix.Codec == cid.DagProtobuf {
+		expected, err = fileDAGForCID(c, content, nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		expected, err = prefix.Sum(content)
+		if err != nil {
+			return fmt.Errorf("failed to compute CID: %w", err)
+		}
+	}
+
+	if !expected.Equals(c) {
+		return fmt.Errorf("%w: %s", ErrContentMismatch, id)
+	}
+
+	return nil
+}
+
+func verifyMultihash(address string, content []byte, algorithms []uint) error {
+	code, err := multihashCode(address, algorithms)
+	if err != nil {
+		return err
+	}
+
+	mh, err := hashing.ComputeMultihash(uint(code), content)
+	if err != nil {
+		return fmt.Errorf("failed to compute multihash for [%s]: %w", address, err)
+	}
+
+	if encoder.EncodeToString(mh) != address {
+		return fmt.Errorf("%w: %s", ErrContentMismatch, address)
+	}
+
+	return nil
+}
+
+// multihashCode returns the multihash code of the given Sidetree address.
+func multihashCode(address string, algorithms []uint) (uint64, error) {
+	code, err := hashing.GetMultihashCode(address)
+	if err != nil {
+		return 0, fmt.Errorf("%w [%s]: %s", ErrInvalidCID, address, err)
+	}
+
+	if !isAllowed(code, algorithms) {
+		return 0, fmt.Errorf("%w [%s]: unsupported hash type %d", ErrInvalidCID, address, code)
+	}
+
+	return code, nil
+}
+
+// isAllowed returns true if the given multihash code is one of the given algorithms
+// (or if no algorithms are given).
+func isAllowed(code uint64, algorithms []uint) bool {
+	if len(algorithms) == 0 {
+		return true
+	}
+
+	for _, alg := range algorithms {
+		if uint64(alg) == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileDAGForCID computes the file DAG of the given content with the layout that is implied by the given
+// dag-pb CID and returns the CID of the root node. 'ipfs add' creates v0 CIDs with dag-pb leaves by default
+// and v1 CIDs with raw leaves if the CID version is 1. v1 CIDs of DAGs with dag-pb leaves
+// ('ipfs add --cid-version=1 --raw-leaves=false') are also supported. If visit is not nil then it is invoked
+// for every block of the DAG (children before their parents).
+func fileDAGForCID(c cid.Cid, content []byte, visit func(id cid.Cid, block []byte)) (cid.Cid, error) {
+	if c.Version() == 0 {
+		return fileDAG(content, false, visit)
+	}
+
+	// the blocks are only visited once the layout is known
+	var blocks []*Block
+
+	collect := func(id cid.Cid, block []byte) {
+		if visit != nil {
+			blocks = append(blocks, &Block{CID: id, Data: block})
+		}
+	}
+
+	root, err := fileDAG(content, true, collect)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	if !root.Equals(c) {
+		blocks = nil
+
+		root, err = fileDAG(content, false, collect)
+		if err != nil {
+			return cid.Undef, err
+		}
+
+		root = cid.NewCidV1(cid.DagProtobuf, root.Hash())
+
+		// the root is visited last and it's referenced by the v1 CID
+		if len(blocks) > 0 {
+			blocks[len(blocks)-1].CID = root
+		}
+	}
+
+	for _, b := range blocks {
+		visit(b.CID, b.Data)
+	}
+
+	return root, nil
+}
+
+// fileDAG computes the balanced unixfs file DAG of the given content in the same way as 'ipfs add' and
+// returns the CID of the root node. If rawLeaves is false then the leaves are dag-pb encoded unixfs file nodes
+// and all nodes are referenced by v0 CIDs (as with the default options). Otherwise the leaves are raw blocks
+// and all nodes are referenced by v1 CIDs (as with '--cid-version=1'), so content that fits into a single
+// chunk is a single raw block. If visit is not nil then it is invoked for every block of the DAG (children
+// before their parents).
+func fileDAG(content []byte, rawLeaves bool, visit func(id cid.Cid, block []byte)) (cid.Cid, error) {
+	b := &dagBuilder{rawLeaves: rawLeaves, visit: visit}
+
+	var chunks [][]byte
+
+	for len(content) > chunkSize {
+		chunks = append(chunks, content[:chunkSize])
+		content = content[chunkSize:]
+	}
+
+	chunks = append(chunks, content)
+
+	if len(chunks) == 1 {
+		n, err := b.leaf(chunks[0])
+		if err != nil {
+			return cid.Undef, err
+		}
+
+		return n.id, nil
+	}
+
+	depth := 1
+
+	for capacity := maxLinks; capacity < len(chunks); capacity *= maxLinks {
+		depth++
+	}
+
+	n, err := b.node(chunks, depth)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	return n.id, nil
+}
+
+type dagBuilder struct {
+	rawLeaves bool
+	visit     func(id cid.Cid, block []byte)
+}
+
+type dagNode struct {
+	id       cid.Cid
+	fileSize uint64
+	tsize    uint64
+}
+
+// node builds a node of the given depth of the balanced DAG. Leaves are at depth 0 and
+// every internal node links to at most maxLinks children.
+func (b *dagBuilder) node(chunks [][]byte, depth int) (*dagNode, error) {
+	if depth == 0 {
+		return b.leaf(chunks[0])
+	}
+
+	childCapacity := 1
+
+	for i := 1; i < depth; i++ {
+		childCapacity *= maxLinks
+	}
+
+	var children []*dagNode
+
+	for len(chunks) > 0 {
+		n := childCapacity
+		if n > len(chunks) {
+			n = len(chunks)
+		}
+
+		child, err := b.node(chunks[:n], depth-1)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, child)
+		chunks = chunks[n:]
+	}
+
+	var fileSize uint64
+
+	unixfs := &bytes.Buffer{}
+
+	unixfs.WriteByte(unixfsTypeTag)
+	writeUvarint(unixfs, unixfsTypeFile)
+
+	for _, child := range children {
+		fileSize += child.fileSize
+	}
+
+	unixfs.WriteByte(unixfsFileSizeTag)
+	writeUvarint(unixfs, fileSize)
+
+	for _, child := range children {
+		unixfs.WriteByte(unixfsBlockSizesTag)
+		writeUvarint(unixfs, child.fileSize)
+	}
+
+	node := &bytes.Buffer{}
+
+	// links are encoded before data (as is done by the Go implementation of dag-pb)
+	for _, child := range children {
+		link := &bytes.Buffer{}
+
+		link.WriteByte(pbLinkHashTag)
+		writeUvarint(link, uint64(len(child.id.Bytes())))
+		link.Write(child.id.Bytes())
+		link.WriteByte(pbLinkNameTag)
+		writeUvarint(link, 0)
+		link.WriteByte(pbLinkTsizeTag)
+		writeUvarint(link, child.tsize)
+
+		node.WriteByte(pbNodeLinksTag)
+		writeUvarint(node, uint64(link.Len()))
+		node.Write(link.Bytes())
+	}
+
+	node.WriteByte(pbNodeDataTag)
+	writeUvarint(node, uint64(unixfs.Len()))
+	node.Write(unixfs.Bytes())
+
+	return b.newNode(node.Bytes(), cid.DagProtobuf, fileSize, children)
+}
+
+// leaf builds a leaf node for the given chunk, i.e. a raw block or a dag-pb encoded unixfs file node.
+func (b *dagBuilder) leaf(chunk []byte) (*dagNode, error) {
+	if b.rawLeaves {
+		return b.newNode(chunk, cid.Raw, uint64(len(chunk)), nil)
+	}
+
+	return b.newNode(dagPBFileNode(chunk), cid.DagProtobuf, uint64(len(chunk)), nil)
+}
+
+func (b *dagBuilder) newNode(block []byte, codec uint64, fileSize uint64, children []*dagNode) (*dagNode, error) {
+	mh, err := multihash.Sum(block, multihash.SHA2_256, -1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute multihash: %w", err)
+	}
+
+	id := cid.NewCidV0(mh)
+	if b.rawLeaves {
+		id = cid.NewCidV1(codec, mh)
+	}
+
+	n := &dagNode{
+		id:       id,
+		fileSize: fileSize,
+		tsize:    uint64(len(block)),
+	}
+
+	for _, child := range children {
+		n.tsize += child.tsize
+	}
+
+	if b.visit != nil {
+		b.visit(n.id, block)
+	}
+
+	return n, nil
+}
+
+// dagPBFileNode returns the dag-pb encoded unixfs file (leaf) node for the given content.
+func dagPBFileNode(content []byte) []byte {
+	unixfs := &bytes.Buffer{}
+
+	unixfs.WriteByte(unixfsTypeTag)
+	writeUvarint(unixfs, unixfsTypeFile)
+
+	if len(content) > 0 {
+		unixfs.WriteByte(unixfsDataTag)
+		writeUvarint(unixfs, uint64(len(content)))
+		unixfs.Write(content)
+	}
+
+	unixfs.WriteByte(unixfsFileSizeTag)
+	writeUvarint(unixfs, uint64(len(content)))
+
+	node := &bytes.Buffer{}
+
+	node.WriteByte(pbNodeDataTag)
+	writeUvarint(node, uint64(unixfs.Len()))
+	node.Write(unixfs.Bytes())
+
+	return node.Bytes()
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+
+	buf.Write(b[:binary.PutUvarint(b, v)])
+}
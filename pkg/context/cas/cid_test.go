@@ -0,0 +1,346 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/encoder"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+)
+
+func TestGetCID(t *testing.T) {
+	// expected values were generated with 'ipfs add'
+	tests := []struct {
+		content string
+		cid     string
+	}{
+		{content: "", cid: "QmbFMke1KXqnYyBBWxB74N4c5SBnJMVAiMNRcGu6x1AwQH"},
+		{content: "hello world", cid: "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"},
+		{content: "hello world\n", cid: "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o"},
+	}
+
+	for _, test := range tests {
+		id, err := GetCID([]byte(test.content))
+		require.NoError(t, err)
+		require.Equal(t, test.cid, id)
+	}
+}
+
+func TestGetDAGJSONCID(t *testing.T) {
+	id, err := GetDAGJSONCID([]byte(`{"a":{"/":"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"}}`))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(id, "bagu"), "expecting base32 CID v1 with dag-json codec")
+	require.True(t, IsDAGJSON(id))
+
+	require.False(t, IsDAGJSON("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"))
+	require.False(t, IsDAGJSON("invalid"))
+}
+
+func TestVerifyCID(t *testing.T) {
+	content := []byte("hello world")
+
+	t.Run("CID v0", func(t *testing.T) {
+		require.NoError(t, VerifyCID("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", content))
+
+		err := VerifyCID("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", []byte("forged"))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("CID v1 dag-pb", func(t *testing.T) {
+		v0, err := cid.Decode("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.NoError(t, err)
+
+		v1 := cid.NewCidV1(cid.DagProtobuf, v0.Hash()).String()
+
+		require.NoError(t, VerifyCID(v1, content))
+
+		err = VerifyCID(v1, []byte("forged"))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("CID v1 dag-pb with raw leaves", func(t *testing.T) {
+		// expected values were generated with the balanced importer of go-unixfs with raw leaves and CID v1,
+		// as used by 'ipfs add --cid-version=1'
+		tests := []struct {
+			size int
+			cid  string
+		}{
+			{size: chunkSize + 1, cid: "bafybeiexg2oqkfnj56l7fcmawswqbijt5shq4b5rg6a546uwpkqqzwjioi"},
+			{size: 600000, cid: "bafybeicp64het67shnhxiyl3sg5mylxqop6pnqsqpfecb6pmni2ghoxzom"},
+		}
+
+		for _, test := range tests {
+			multiChunk := testContent(test.size)
+
+			require.NoError(t, VerifyCID(test.cid, multiChunk))
+
+			multiChunk[0]++
+
+			err := VerifyCID(test.cid, multiChunk)
+			require.True(t, errors.Is(err, ErrContentMismatch))
+		}
+
+		// content that fits into a single chunk is a single raw block
+		require.NoError(t, VerifyCID("bafkreidyuyttca6rpq42bnqsnyrgz3dq4mztp5f4ni4am5abwvfdhz4ovu", testContent(11)))
+		require.NoError(t, VerifyCID("bafkreibruh455iawsviqslif5c7uurdcfdemh22mtnytyzvnzn75kpejxy",
+			testContent(chunkSize)))
+	})
+
+	t.Run("CID v1 raw", func(t *testing.T) {
+		mh, err := multihash.Sum(content, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		v1 := cid.NewCidV1(cid.Raw, mh).String()
+
+		require.NoError(t, VerifyCID(v1, content))
+
+		err = VerifyCID(v1, []byte("forged"))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("CID v1 dag-json", func(t *testing.T) {
+		node := []byte(`{"a":1}`)
+
+		id, err := GetDAGJSONCID(node)
+		require.NoError(t, err)
+
+		require.NoError(t, VerifyCID(id, node))
+
+		err = VerifyCID(id, []byte(`{"a":2}`))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("Sidetree multihash", func(t *testing.T) {
+		address, err := mocks.NewMockCasClient(nil).Write(content)
+		require.NoError(t, err)
+
+		require.NoError(t, VerifyCID(address, content))
+
+		err = VerifyCID(address, []byte("forged"))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		err := VerifyCID("invalid", content)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "invalid CID [invalid]")
+	})
+
+	t.Run("error - multihash algorithm not allowed", func(t *testing.T) {
+		mh, err := multihash.Sum(content, multihash.SHA2_512, -1)
+		require.NoError(t, err)
+
+		id := cid.NewCidV1(cid.Raw, mh).String()
+
+		require.NoError(t, VerifyCID(id, content))
+
+		err = VerifyCID(id, content, multihash.SHA2_256)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported hash type")
+
+		address := encoder.EncodeToString(mh)
+
+		require.NoError(t, VerifyCID(address, content))
+
+		err = VerifyCID(address, content, multihash.SHA2_256)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported hash type")
+	})
+
+	t.Run("error - unsupported codec", func(t *testing.T) {
+		mh, err := multihash.Sum(content, multihash.SHA2_256, -1)
+		require.NoError(t, err)
+
+		err = VerifyCID(cid.NewCidV1(cid.DagCBOR, mh).String(), content)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported codec")
+	})
+
+	t.Run("content larger than one chunk", func(t *testing.T) {
+		largeContent := bytes.Repeat([]byte("a"), chunkSize+1)
+
+		id, err := GetCID(largeContent)
+		require.NoError(t, err)
+
+		require.NoError(t, VerifyCID(id, largeContent))
+
+		err = VerifyCID(id, largeContent[:chunkSize])
+		require.True(t, errors.Is(err, ErrContentMismatch))
+	})
+
+	t.Run("error - unsupported hash type", func(t *testing.T) {
+		mh, err := multihash.Sum(content, multihash.SHA2_512, -1)
+		require.NoError(t, err)
+
+		err = VerifyCID(cid.NewCidV1(cid.DagProtobuf, mh).String(), content)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported hash type")
+	})
+}
+
+func TestParseCID(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := ParseCID("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", multihash.SHA2_256)
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), c.Version())
+
+		id, err := GetDAGJSONCID([]byte("{}"))
+		require.NoError(t, err)
+
+		c, err = ParseCID(id)
+		require.NoError(t, err)
+		require.Equal(t, uint64(DagJSON), c.Type())
+	})
+
+	t.Run("error - malformed CID", func(t *testing.T) {
+		for _, id := range []string{"", "invalid", "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLy"} {
+			_, err := ParseCID(id)
+			require.True(t, errors.Is(err, ErrInvalidCID), id)
+		}
+	})
+
+	t.Run("error - multihash algorithm not allowed", func(t *testing.T) {
+		mh, err := multihash.Sum([]byte("{}"), multihash.SHA3_256, -1)
+		require.NoError(t, err)
+
+		id := cid.NewCidV1(DagJSON, mh).String()
+
+		_, err = ParseCID(id)
+		require.NoError(t, err)
+
+		_, err = ParseCID(id, multihash.SHA2_256)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Contains(t, err.Error(), "unsupported hash type")
+	})
+}
+
+func TestValidateCID(t *testing.T) {
+	require.NoError(t, ValidateCID("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", multihash.SHA2_256))
+
+	mh, err := multihash.Sum([]byte("content"), multihash.SHA2_256, -1)
+	require.NoError(t, err)
+
+	require.NoError(t, ValidateCID(encoder.EncodeToString(mh), multihash.SHA2_256))
+
+	err = ValidateCID(encoder.EncodeToString(mh), multihash.SHA2_512)
+	require.True(t, errors.Is(err, ErrInvalidCID))
+
+	err = ValidateCID("invalid")
+	require.True(t, errors.Is(err, ErrInvalidCID))
+
+	err = ValidateCID("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", multihash.SHA2_512)
+	require.True(t, errors.Is(err, ErrInvalidCID))
+}
+
+func TestFileDAG(t *testing.T) {
+	t.Run("single chunk", func(t *testing.T) {
+		var blocks [][]byte
+
+		root, err := fileDAG([]byte("hello world"), false, func(_ cid.Cid, block []byte) {
+			blocks = append(blocks, block)
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", root.String())
+		require.Len(t, blocks, 1)
+	})
+
+	t.Run("multiple chunks", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*chunkSize+1)
+
+		var ids []cid.Cid
+
+		var blocks [][]byte
+
+		root, err := fileDAG(content, false, func(id cid.Cid, block []byte) {
+			ids = append(ids, id)
+			blocks = append(blocks, block)
+		})
+		require.NoError(t, err)
+
+		// three leaves and the root
+		require.Len(t, blocks, 4)
+		require.Equal(t, root, ids[3])
+
+		// the first two leaves are identical
+		require.Equal(t, ids[0], ids[1])
+		require.Equal(t, dagPBFileNode(content[:chunkSize]), blocks[0])
+		require.Equal(t, dagPBFileNode([]byte("a")), blocks[2])
+
+		// the root links to the leaves
+		require.True(t, bytes.Contains(blocks[3], ids[0].Bytes()))
+		require.True(t, bytes.Contains(blocks[3], ids[2].Bytes()))
+	})
+
+	t.Run("balanced DAG with depth 2", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), maxLinks*chunkSize+1)
+
+		var blocks int
+
+		_, err := fileDAG(content, false, func(cid.Cid, []byte) {
+			blocks++
+		})
+		require.NoError(t, err)
+
+		// leaves, two internal nodes and the root
+		require.Equal(t, maxLinks+1+2+1, blocks)
+	})
+
+	t.Run("raw leaves", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), 2*chunkSize+1)
+
+		var ids []cid.Cid
+
+		var blocks [][]byte
+
+		root, err := fileDAG(content, true, func(id cid.Cid, block []byte) {
+			ids = append(ids, id)
+			blocks = append(blocks, block)
+		})
+		require.NoError(t, err)
+
+		require.Len(t, blocks, 4)
+		require.Equal(t, root, ids[3])
+		require.Equal(t, uint64(1), root.Version())
+		require.Equal(t, uint64(cid.DagProtobuf), root.Type())
+
+		// the leaves are the chunks
+		require.Equal(t, uint64(cid.Raw), ids[0].Type())
+		require.Equal(t, content[:chunkSize], blocks[0])
+		require.Equal(t, []byte("a"), blocks[2])
+
+		// the root links to the leaves by their v1 CIDs
+		require.True(t, bytes.Contains(blocks[3], ids[0].Bytes()))
+		require.True(t, bytes.Contains(blocks[3], ids[2].Bytes()))
+	})
+
+	t.Run("raw leaves - single chunk", func(t *testing.T) {
+		root, err := fileDAG([]byte("hello world"), true, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(cid.Raw), root.Type())
+	})
+}
+
+// testContent returns content of the given size with the same (non-repeating per chunk) pattern
+// that was used to generate the test vectors.
+func testContent(size int) []byte {
+	const modulus = 251
+
+	content := make([]byte, size)
+
+	for i := range content {
+		content[i] = byte(i % modulus)
+	}
+
+	return content
+}
@@ -0,0 +1,96 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+)
+
+// contextClient is implemented by CAS clients that support cancellation of reads and writes.
+type contextClient interface {
+	WriteContext(ctx context.Context, content []byte) (string, error)
+	ReadContext(ctx context.Context, cid string) ([]byte, error)
+}
+
+// nodeClient is implemented by CAS clients that are able to store IPLD (dag-json) nodes.
+type nodeClient interface {
+	WriteNode(ctx context.Context, node []byte) (string, error)
+	ReadNode(ctx context.Context, cid string) ([]byte, error)
+}
+
+// Pinner is implemented by CAS clients that are able to pin content, i.e. ensure that the content
+// is retained (e.g. not removed by IPFS garbage collection).
+type Pinner interface {
+	Pin(ctx context.Context, cid string) error
+	IsPinned(ctx context.Context, cid string) (bool, error)
+}
+
+// WriteWithContext writes the given content to the given CAS client. If the client doesn't support
+// contexts then the write is performed in the background and the context error is returned as soon as
+// the context is done.
+func WriteWithContext(ctx context.Context, c casapi.Client, content []byte) (string, error) {
+	if cc, ok := c.(contextClient); ok {
+		return cc.WriteContext(ctx, content)
+	}
+
+	result, err := runWithContext(ctx, func() (interface{}, error) {
+		return c.Write(content)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result.(string), nil
+}
+
+// ReadWithContext reads the content for the given CID from the given CAS client. If the client doesn't support
+// contexts then the read is performed in the background and the context error is returned as soon as the
+// context is done.
+func ReadWithContext(ctx context.Context, c casapi.Client, id string) ([]byte, error) {
+	if cc, ok := c.(contextClient); ok {
+		return cc.ReadContext(ctx, id)
+	}
+
+	result, err := runWithContext(ctx, func() (interface{}, error) {
+		return c.Read(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]byte), nil
+}
+
+type fnResult struct {
+	value interface{}
+	err   error
+}
+
+// runWithContext invokes the given function and returns the context error if the context is done before
+// the function returns. (The function keeps running in the background in this case and its result is discarded.)
+func runWithContext(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan *fnResult, 1)
+
+	go func() {
+		value, err := fn()
+
+		done <- &fnResult{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotSupported is returned if the operation is not supported by the CAS client.
+var ErrNotSupported = errors.New("operation not supported")
+
+// ErrContentTooLarge is returned if the content returned by a peer exceeds the maximum content size.
+var ErrContentTooLarge = errors.New("content too large")
+
+const defaultMaxContentSize = 10 * 1024 * 1024
+
+// HTTPClient reads content from the CAS endpoint of a peer orb node (<peer>/cas/{cid}). Since the
+// peer is not trusted, the content is verified against the CID. It implements Sidetree CAS interface
+// but content may only be read.
+type HTTPClient struct {
+	baseURL        string
+	httpClient     *http.Client
+	authToken      string
+	maxContentSize int64
+}
+
+// HTTPOpt is an HTTP CAS client option.
+type HTTPOpt func(c *HTTPClient)
+
+// WithHTTPClient sets the HTTP client that is used to connect to the peer.
+func WithHTTPClient(httpClient *http.Client) HTTPOpt {
+	return func(c *HTTPClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAuthToken sets the bearer token that is sent to the peer.
+func WithAuthToken(token string) HTTPOpt {
+	return func(c *HTTPClient) {
+		c.authToken = token
+	}
+}
+
+// WithMaxContentSize sets the maximum size (in bytes) of the content that is read from the peer. The read is
+// aborted with an error that wraps ErrContentTooLarge if the peer returns more. Defaults to 10MB.
+func WithMaxContentSize(size int64) HTTPOpt {
+	return func(c *HTTPClient) {
+		c.maxContentSize = size
+	}
+}
+
+// NewHTTP creates an HTTP CAS client for the given CAS endpoint of a peer (e.g. https://peer.domain.com/cas).
+func NewHTTP(baseURL string, opts ...HTTPOpt) *HTTPClient {
+	c := &HTTPClient{
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		httpClient:     http.DefaultClient,
+		maxContentSize: defaultMaxContentSize,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Write is not supported since content may only be read from a peer.
+func (m *HTTPClient) Write([]byte) (string, error) {
+	return "", fmt.Errorf("write to peer CAS [%s]: %w", m.baseURL, ErrNotSupported)
+}
+
+// WriteContext is not supported since content may only be read from a peer.
+func (m *HTTPClient) WriteContext(context.Context, []byte) (string, error) {
+	return "", fmt.Errorf("write to peer CAS [%s]: %w", m.baseURL, ErrNotSupported)
+}
+
+// Read reads the content for the given CID from the peer and verifies it against the CID.
+// returns the contents of CID.
+func (m *HTTPClient) Read(id string) ([]byte, error) {
+	return m.ReadContext(context.Background(), id)
+}
+
+// ReadContext reads the content for the given CID from the peer and verifies it against the CID.
+// The request is aborted if the context is done.
+// returns the contents of CID.
+func (m *HTTPClient) ReadContext(ctx context.Context, id string) ([]byte, error) {
+	content, err := m.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifyCID(id, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify content from peer CAS [%s]: %w", m.baseURL, err)
+	}
+
+	return content, nil
+}
+
+// WriteNode is not supported since IPLD nodes may only be read from a peer.
+func (m *HTTPClient) WriteNode(context.Context, []byte) (string, error) {
+	return "", fmt.Errorf("write node to peer CAS [%s]: %w", m.baseURL, ErrNotSupported)
+}
+
+// ReadNode reads the IPLD node for the given CID from the peer and verifies it against the CID.
+func (m *HTTPClient) ReadNode(ctx context.Context, id string) ([]byte, error) {
+	return m.ReadContext(ctx, id)
+}
+
+func (m *HTTPClient) get(ctx context.Context, id string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.baseURL+"/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for CID [%s]: %w", id, err)
+	}
+
+	if m.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+m.authToken)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CID [%s] from peer CAS [%s]: %w", id, m.baseURL, err)
+	}
+
+	defer close(resp.Body)
+
+	// The peer isn't trusted so no more than the maximum content size (plus one byte in order to detect
+	// oversized content) is read.
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, m.maxContentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response for CID [%s] from peer CAS [%s]: %w", id, m.baseURL, err)
+	}
+
+	if int64(len(content)) > m.maxContentSize {
+		return nil, fmt.Errorf("%w: content for CID [%s] from peer CAS [%s] exceeds %d bytes",
+			ErrContentTooLarge, id, m.baseURL, m.maxContentSize)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		log.Debugf("read content for cid %s from peer CAS %s", id, m.baseURL)
+
+		return content, nil
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrContentNotFound, id)
+	default:
+		return nil, fmt.Errorf("failed to read CID [%s] from peer CAS [%s]: status code %d: %s",
+			id, m.baseURL, resp.StatusCode, content)
+	}
+}
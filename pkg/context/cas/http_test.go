@@ -0,0 +1,132 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const helloWorldCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+func TestHTTPClient_Read(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/cas/"+helloWorldCID, r.URL.Path)
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+			fmt.Fprint(w, "hello world")
+		}))
+		defer peer.Close()
+
+		c := NewHTTP(peer.URL+"/cas/", WithHTTPClient(peer.Client()), WithAuthToken("token"))
+
+		content, err := c.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("success - IPLD node", func(t *testing.T) {
+		node := []byte(`{"anchorCredential":"jwt"}`)
+
+		id, err := GetDAGJSONCID(node)
+		require.NoError(t, err)
+
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(node) //nolint:errcheck
+		}))
+		defer peer.Close()
+
+		content, err := NewHTTP(peer.URL+"/cas").ReadNode(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, node, content)
+	})
+
+	t.Run("error - content does not match CID", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "forged")
+		}))
+		defer peer.Close()
+
+		content, err := NewHTTP(peer.URL + "/cas").Read(helloWorldCID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrContentMismatch))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - content too large", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "hello world")
+		}))
+		defer peer.Close()
+
+		content, err := NewHTTP(peer.URL+"/cas", WithMaxContentSize(5)).Read(helloWorldCID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrContentTooLarge))
+		require.Contains(t, err.Error(), "exceeds 5 bytes")
+		require.Nil(t, content)
+
+		content, err = NewHTTP(peer.URL+"/cas", WithMaxContentSize(int64(len("hello world")))).Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer peer.Close()
+
+		content, err := NewHTTP(peer.URL + "/cas").Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrContentNotFound))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer peer.Close()
+
+		content, err := NewHTTP(peer.URL + "/cas").Read(helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "status code 500")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - peer not reachable", func(t *testing.T) {
+		content, err := NewHTTP("http://localhost:1/cas").Read(helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read CID")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - invalid URL", func(t *testing.T) {
+		content, err := NewHTTP("://invalid").Read(helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create request")
+		require.Nil(t, content)
+	})
+}
+
+func TestHTTPClient_Write(t *testing.T) {
+	c := NewHTTP("https://peer.domain.com/cas")
+
+	id, err := c.Write([]byte("hello world"))
+	require.True(t, errors.Is(err, ErrNotSupported))
+	require.Empty(t, id)
+
+	id, err = c.WriteNode(context.Background(), []byte("{}"))
+	require.True(t, errors.Is(err, ErrNotSupported))
+	require.Empty(t, id)
+}
@@ -7,55 +7,293 @@ SPDX-License-Identifier: Apache-2.0
 package cas
 
 import (
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"strings"
+	"time"
 
 	shell "github.com/ipfs/go-ipfs-api"
+	files "github.com/ipfs/go-ipfs-files"
 	log "github.com/sirupsen/logrus"
 )
 
+const (
+	defaultReadTimeout  = 20 * time.Second
+	defaultWriteTimeout = time.Minute
+)
+
 // Client will write new documents to IPFS and read existing documents from IPFS based on CID.
-// It implements Sidetree CAS interface.
+// Content that is read from IPFS is verified against the CID so that a compromised (or buggy)
+// IPFS node isn't able to return forged content. It implements Sidetree CAS interface.
 type Client struct {
-	ipfs *shell.Shell
+	ipfs         *shell.Shell
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	algorithms   MultihashAlgorithmsProvider
+}
+
+// Opt is an IPFS client option.
+type Opt func(c *Client)
+
+// WithReadTimeout sets the timeout for a read operation. Zero means no timeout.
+func WithReadTimeout(timeout time.Duration) Opt {
+	return func(c *Client) {
+		c.readTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets the timeout for a write operation. Zero means no timeout.
+func WithWriteTimeout(timeout time.Duration) Opt {
+	return func(c *Client) {
+		c.writeTimeout = timeout
+	}
+}
+
+// WithMultihashAlgorithms sets the multihash algorithms (e.g. the protocol's MultihashAlgorithms) that are
+// allowed in the CIDs of content that is read. Defaults to any algorithm that is supported for verification.
+func WithMultihashAlgorithms(algorithms ...uint) Opt {
+	return func(c *Client) {
+		c.algorithms = func() ([]uint, error) { return algorithms, nil }
+	}
+}
+
+// WithMultihashAlgorithmsProvider sets the provider of the multihash algorithms that are allowed in the CIDs of
+// content that is read. The provider is invoked for every CID so that e.g. the algorithms of the current protocol
+// version are used.
+func WithMultihashAlgorithmsProvider(provider MultihashAlgorithmsProvider) Opt {
+	return func(c *Client) {
+		c.algorithms = provider
+	}
 }
 
 // New creates cas client.
-func New(url string) *Client {
-	ipfs := shell.NewShell(url)
+func New(url string, opts ...Opt) *Client {
+	c := &Client{
+		ipfs:         shell.NewShell(url),
+		readTimeout:  defaultReadTimeout,
+		writeTimeout: defaultWriteTimeout,
+	}
 
-	return &Client{ipfs: ipfs}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // Write writes the given content to CAS.
 // returns cid which represents the address of the content.
 func (m *Client) Write(content []byte) (string, error) {
-	cid, err := m.ipfs.Add(bytes.NewReader(content))
+	return m.WriteContext(context.Background(), content)
+}
+
+// WriteContext writes the given content to CAS. The content is pinned so that it isn't removed by
+// IPFS garbage collection. The write is aborted if the context is done or if the write timeout expires.
+// returns cid which represents the address of the content.
+func (m *Client) WriteContext(ctx context.Context, content []byte) (string, error) {
+	ctx, cancel := withTimeout(ctx, m.writeTimeout)
+	defer cancel()
+
+	var out struct {
+		Hash string
+	}
+
+	err := m.ipfs.Request("add").
+		Option("pin", true).
+		Body(newFileReader(files.NewBytesFile(content))).
+		Exec(ctx, &out)
 	if err != nil {
 		return "", err
 	}
 
-	log.Debugf("added content returned cid: %s", cid)
+	log.Debugf("added content returned cid: %s", out.Hash)
 
-	return cid, nil
+	return out.Hash, nil
 }
 
 // Read reads the content for the given CID from CAS.
 // returns the contents of CID.
 func (m *Client) Read(cid string) ([]byte, error) {
-	reader, err := m.ipfs.Cat(cid)
+	return m.ReadContext(context.Background(), cid)
+}
+
+// ReadContext reads the content for the given CID from CAS. The read is aborted if the context is done
+// or if the read timeout expires. An error that wraps ErrInvalidCID is returned if the CID is invalid
+// and an error that wraps ErrContentMismatch is returned if the content returned by IPFS doesn't match the CID.
+// returns the contents of CID.
+func (m *Client) ReadContext(ctx context.Context, cid string) ([]byte, error) {
+	return m.get(ctx, "cat", cid)
+}
+
+// WriteNode writes the given dag-json encoded IPLD node to IPFS as a single (pinned) block. Since the node is
+// stored with the dag-json codec, IPFS is able to traverse the links of the node (e.g. for recursive pinning).
+// returns the CID (v1) of the node.
+func (m *Client) WriteNode(ctx context.Context, node []byte) (string, error) {
+	ctx, cancel := withTimeout(ctx, m.writeTimeout)
+	defer cancel()
+
+	var out struct {
+		Key string
+	}
+
+	err := m.ipfs.Request("block/put").
+		Option("cid-codec", "dag-json").
+		Option("mhtype", "sha2-256").
+		Option("pin", true).
+		Body(newFileReader(files.NewBytesFile(node))).
+		Exec(ctx, &out)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("added IPLD node returned cid: %s", out.Key)
+
+	return out.Key, nil
+}
+
+// ReadNode reads the (raw) block of the IPLD node for the given CID. The block is verified against the CID.
+func (m *Client) ReadNode(ctx context.Context, cid string) ([]byte, error) {
+	return m.get(ctx, "block/get", cid)
+}
+
+// Pin (recursively) pins the content for the given CID so that it isn't removed by IPFS garbage collection.
+// If the content isn't available locally then IPFS fetches it from the network. (The pin is aborted if the
+// context is done or if the write timeout expires.)
+func (m *Client) Pin(ctx context.Context, cid string) error {
+	algorithms, err := m.multihashAlgorithms()
+	if err != nil {
+		return err
+	}
+
+	_, err = ParseCID(cid, algorithms...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, m.writeTimeout)
+	defer cancel()
+
+	err = m.ipfs.Request("pin/add", cid).
+		Option("recursive", true).
+		Exec(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pin CID [%s]: %w", cid, err)
+	}
+
+	log.Debugf("pinned cid: %s", cid)
+
+	return nil
+}
+
+// IsPinned returns true if the content for the given CID is pinned (either directly or indirectly
+// as part of a recursively pinned DAG).
+func (m *Client) IsPinned(ctx context.Context, cid string) (bool, error) {
+	algorithms, err := m.multihashAlgorithms()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = ParseCID(cid, algorithms...)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := withTimeout(ctx, m.readTimeout)
+	defer cancel()
+
+	var out struct {
+		Keys map[string]struct {
+			Type string
+		}
+	}
+
+	err = m.ipfs.Request("pin/ls", cid).Exec(ctx, &out)
+	if err != nil {
+		var shellErr *shell.Error
+		if errors.As(err, &shellErr) && strings.Contains(shellErr.Message, "not pinned") {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get pin for CID [%s]: %w", cid, err)
+	}
+
+	return len(out.Keys) > 0, nil
+}
+
+func (m *Client) get(ctx context.Context, command, cid string) ([]byte, error) {
+	algorithms, err := m.multihashAlgorithms()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ParseCID(cid, algorithms...)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := m.send(ctx, command, cid)
 	if err != nil {
 		return nil, err
 	}
 
-	defer close(reader)
+	err = VerifyCID(cid, content, algorithms...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify content from IPFS: %w", err)
+	}
+
+	return content, nil
+}
+
+func (m *Client) send(ctx context.Context, command, cid string) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, m.readTimeout)
+	defer cancel()
+
+	resp, err := m.ipfs.Request(command, cid).Send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer close(resp)
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	return ioutil.ReadAll(resp.Output)
+}
+
+func newFileReader(file files.Node) io.Reader {
+	return files.NewMultiFileReader(files.NewSliceDirectory([]files.DirEntry{files.FileEntry("", file)}), true)
+}
+
+// multihashAlgorithms returns the multihash algorithms that are allowed in CIDs (nil if any algorithm is allowed).
+func (m *Client) multihashAlgorithms() ([]uint, error) {
+	if m.algorithms == nil {
+		return nil, nil
+	}
+
+	algorithms, err := m.algorithms()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multihash algorithms: %w", err)
+	}
+
+	return algorithms, nil
+}
+
+// withTimeout returns a context that is done after the given timeout (if not zero).
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(ctx)
+	}
 
-	return ioutil.ReadAll(reader)
+	return context.WithTimeout(ctx, timeout)
 }
 
-func close(rc io.Closer) {
-	if err := rc.Close(); err != nil {
-		log.Warnf("failed to close reader: %s", err.Error())
+func close(c io.Closer) {
+	if err := c.Close(); err != nil {
+		log.Warnf("failed to close: %s", err.Error())
 	}
 }
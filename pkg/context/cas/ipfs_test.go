@@ -7,11 +7,15 @@ SPDX-License-Identifier: Apache-2.0
 package cas
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,19 +27,28 @@ func TestNew(t *testing.T) {
 func TestWrite(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprint(w, "{}")
+			if r.URL.Path == "/api/v0/add" {
+				require.Equal(t, "true", r.URL.Query().Get("pin"))
+
+				fmt.Fprintf(w, `{"Hash":"%s"}`, helloWorldCID)
+
+				return
+			}
+
+			fmt.Fprint(w, "hello world")
 		}))
 		defer ipfs.Close()
 
 		cas := New(ipfs.URL)
 		require.NotNil(t, cas)
 
-		cid, err := cas.Write([]byte("content"))
+		cid, err := cas.Write([]byte("hello world"))
 		require.Nil(t, err)
+		require.Equal(t, helloWorldCID, cid)
 
 		read, err := cas.Read(cid)
 		require.Nil(t, err)
-		require.NotNil(t, read)
+		require.Equal(t, "hello world", string(read))
 	})
 
 	t.Run("error - internal server error", func(t *testing.T) {
@@ -56,6 +69,129 @@ func TestWrite(t *testing.T) {
 func TestRead(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v0/cat", r.URL.Path)
+
+			fmt.Fprint(w, "hello world")
+		}))
+		defer ipfs.Close()
+
+		cas := New(ipfs.URL, WithMultihashAlgorithms(multihash.SHA2_256))
+		require.NotNil(t, cas)
+
+		read, err := cas.Read(helloWorldCID)
+		require.Nil(t, err)
+		require.Equal(t, "hello world", string(read))
+	})
+
+	t.Run("error - forged content", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "forged")
+		}))
+		defer ipfs.Close()
+
+		read, err := New(ipfs.URL).Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrContentMismatch))
+		require.Nil(t, read)
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("IPFS should not be called")
+		}))
+		defer ipfs.Close()
+
+		read, err := New(ipfs.URL).Read("cid")
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Nil(t, read)
+
+		read, err = New(ipfs.URL, WithMultihashAlgorithms(multihash.SHA2_512)).Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Nil(t, read)
+	})
+
+	t.Run("multihash algorithms provider", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "hello world")
+		}))
+		defer ipfs.Close()
+
+		algorithms := []uint{multihash.SHA2_512}
+
+		cas := New(ipfs.URL, WithMultihashAlgorithmsProvider(func() ([]uint, error) { return algorithms, nil }))
+
+		read, err := cas.Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrInvalidCID))
+		require.Nil(t, read)
+
+		// the algorithms are resolved for every read
+		algorithms = []uint{multihash.SHA2_256}
+
+		read, err = cas.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(read))
+
+		read, err = New(ipfs.URL, WithMultihashAlgorithmsProvider(func() ([]uint, error) {
+			return nil, errors.New("protocol error")
+		})).Read(helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get multihash algorithms: protocol error")
+		require.Nil(t, read)
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ipfs.Close()
+
+		cas := New(ipfs.URL)
+		require.NotNil(t, cas)
+
+		cid, err := cas.Read(helloWorldCID)
+		require.Error(t, err)
+		require.Empty(t, cid)
+	})
+}
+
+func TestWriteNode(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v0/block/put", r.URL.Path)
+			require.Equal(t, "dag-json", r.URL.Query().Get("cid-codec"))
+			require.Equal(t, "true", r.URL.Query().Get("pin"))
+
+			fmt.Fprint(w, `{"Key":"bagu"}`)
+		}))
+		defer ipfs.Close()
+
+		cas := New(ipfs.URL)
+		require.NotNil(t, cas)
+
+		cid, err := cas.WriteNode(context.Background(), []byte("{}"))
+		require.NoError(t, err)
+		require.Equal(t, "bagu", cid)
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ipfs.Close()
+
+		cas := New(ipfs.URL)
+		require.NotNil(t, cas)
+
+		cid, err := cas.WriteNode(context.Background(), []byte("{}"))
+		require.Error(t, err)
+		require.Empty(t, cid)
+	})
+}
+
+func TestReadNode(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v0/block/get", r.URL.Path)
+
 			fmt.Fprint(w, "{}")
 		}))
 		defer ipfs.Close()
@@ -63,9 +199,20 @@ func TestRead(t *testing.T) {
 		cas := New(ipfs.URL)
 		require.NotNil(t, cas)
 
-		read, err := cas.Read("cid")
-		require.Nil(t, err)
-		require.NotNil(t, read)
+		node, err := cas.ReadNode(context.Background(), nodeCID(t))
+		require.NoError(t, err)
+		require.Equal(t, []byte("{}"), node)
+	})
+
+	t.Run("error - forged node", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"forged":true}`)
+		}))
+		defer ipfs.Close()
+
+		node, err := New(ipfs.URL).ReadNode(context.Background(), nodeCID(t))
+		require.True(t, errors.Is(err, ErrContentMismatch))
+		require.Nil(t, node)
 	})
 
 	t.Run("error - internal server error", func(t *testing.T) {
@@ -77,8 +224,154 @@ func TestRead(t *testing.T) {
 		cas := New(ipfs.URL)
 		require.NotNil(t, cas)
 
-		cid, err := cas.Read("cid")
+		node, err := cas.ReadNode(context.Background(), nodeCID(t))
 		require.Error(t, err)
+		require.Empty(t, node)
+	})
+}
+
+func TestPin(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v0/pin/add", r.URL.Path)
+			require.Equal(t, helloWorldCID, r.URL.Query().Get("arg"))
+			require.Equal(t, "true", r.URL.Query().Get("recursive"))
+
+			fmt.Fprintf(w, `{"Pins":["%s"]}`, helloWorldCID)
+		}))
+		defer ipfs.Close()
+
+		require.NoError(t, New(ipfs.URL).Pin(context.Background(), helloWorldCID))
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		err := New("ipfs:5001").Pin(context.Background(), "cid")
+		require.True(t, errors.Is(err, ErrInvalidCID))
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ipfs.Close()
+
+		err := New(ipfs.URL).Pin(context.Background(), helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to pin CID")
+	})
+}
+
+func TestIsPinned(t *testing.T) {
+	t.Run("pinned", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "/api/v0/pin/ls", r.URL.Path)
+
+			fmt.Fprintf(w, `{"Keys":{"%s":{"Type":"indirect"}}}`, helloWorldCID)
+		}))
+		defer ipfs.Close()
+
+		pinned, err := New(ipfs.URL).IsPinned(context.Background(), helloWorldCID)
+		require.NoError(t, err)
+		require.True(t, pinned)
+	})
+
+	t.Run("not pinned", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(w, `{"Message":"path '%s' is not pinned","Code":0,"Type":"error"}`, helloWorldCID)
+		}))
+		defer ipfs.Close()
+
+		pinned, err := New(ipfs.URL).IsPinned(context.Background(), helloWorldCID)
+		require.NoError(t, err)
+		require.False(t, pinned)
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		_, err := New("ipfs:5001").IsPinned(context.Background(), "cid")
+		require.True(t, errors.Is(err, ErrInvalidCID))
+	})
+
+	t.Run("error - internal server error", func(t *testing.T) {
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ipfs.Close()
+
+		_, err := New(ipfs.URL).IsPinned(context.Background(), helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get pin for CID")
+	})
+}
+
+func TestClient_Timeout(t *testing.T) {
+	ipfs, release := newHangingIPFS()
+	defer ipfs.Close()
+	defer release()
+
+	t.Run("read timeout", func(t *testing.T) {
+		cas := New(ipfs.URL, WithReadTimeout(50*time.Millisecond))
+
+		content, err := cas.Read(helloWorldCID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Nil(t, content)
+
+		content, err = cas.ReadNode(context.Background(), nodeCID(t))
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Nil(t, content)
+	})
+
+	t.Run("write timeout", func(t *testing.T) {
+		cas := New(ipfs.URL, WithWriteTimeout(50*time.Millisecond))
+
+		cid, err := cas.Write([]byte("content"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Empty(t, cid)
+
+		cid, err = cas.WriteNode(context.Background(), []byte("{}"))
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
 		require.Empty(t, cid)
 	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		cas := New(ipfs.URL, WithReadTimeout(0))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+		}()
+
+		content, err := cas.ReadContext(ctx, helloWorldCID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, content)
+	})
+}
+
+func nodeCID(t *testing.T) string {
+	t.Helper()
+
+	id, err := GetDAGJSONCID([]byte("{}"))
+	require.NoError(t, err)
+
+	return id
+}
+
+// newHangingIPFS returns a fake IPFS HTTP API server that doesn't respond until either the request
+// is cancelled by the client or the returned release function is invoked.
+func newHangingIPFS() (*httptest.Server, func()) {
+	ctx, release := context.WithCancel(context.Background())
+
+	ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-ctx.Done():
+		}
+	}))
+
+	return ipfs, release
 }
@@ -0,0 +1,270 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+)
+
+// Tier is a tier of the layered CAS client.
+type Tier struct {
+	// Name is the name of the tier (used for logging and errors).
+	Name string
+	// Client is the CAS client of the tier.
+	Client casapi.Client
+	// Timeout is the maximum time to wait for the tier to respond. Zero means no timeout (other than
+	// the timeout of the client itself).
+	Timeout time.Duration
+	// FailFast indicates that an error (other than content not found) from this tier is returned
+	// immediately instead of falling back to the next tier.
+	FailFast bool
+}
+
+// LayeredClient is a CAS client that reads through an ordered chain of tiers (e.g. local cache, IPFS, peers).
+// Content that is found in a tier is back-filled into the (writable) tiers before it. Content is written
+// to the primary tier and to the tiers before it. It implements Sidetree CAS interface.
+type LayeredClient struct {
+	tiers   []*Tier
+	primary int
+}
+
+// NewLayered creates a layered CAS client with the given tiers (in read order) where the tier with the given
+// name is the primary tier.
+func NewLayered(primary string, tiers ...*Tier) (*LayeredClient, error) {
+	for i, tier := range tiers {
+		if tier.Name == primary {
+			return &LayeredClient{tiers: tiers, primary: i}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("primary CAS tier [%s] not found", primary)
+}
+
+// Local returns a layered client with the primary tier and the tiers before it (e.g. the cache), i.e. without
+// the tiers after the primary tier (e.g. peers). It is used to serve content to peers without forwarding reads
+// to other peers (which could loop between peers).
+func (m *LayeredClient) Local() *LayeredClient {
+	return &LayeredClient{tiers: m.tiers[:m.primary+1], primary: m.primary}
+}
+
+// Write writes the given content to the primary tier and to the tiers before it.
+// returns the CID of the content (as returned by the primary tier).
+func (m *LayeredClient) Write(content []byte) (string, error) {
+	return m.WriteContext(context.Background(), content)
+}
+
+// WriteContext writes the given content to the primary tier and to the tiers before it.
+// returns the CID of the content (as returned by the primary tier).
+func (m *LayeredClient) WriteContext(ctx context.Context, content []byte) (string, error) {
+	return m.write(ctx, content, func(ctx context.Context, c casapi.Client) (string, error) {
+		return WriteWithContext(ctx, c, content)
+	})
+}
+
+// Read reads the content for the given CID from the first tier that has it.
+// returns the contents of CID.
+func (m *LayeredClient) Read(id string) ([]byte, error) {
+	return m.ReadContext(context.Background(), id)
+}
+
+// ReadContext reads the content for the given CID from the first tier that has it.
+// returns the contents of CID.
+func (m *LayeredClient) ReadContext(ctx context.Context, id string) ([]byte, error) {
+	return m.read(ctx, id, func(ctx context.Context, c casapi.Client) ([]byte, error) {
+		return ReadWithContext(ctx, c, id)
+	}, func(ctx context.Context, c casapi.Client, content []byte) (string, error) {
+		return WriteWithContext(ctx, c, content)
+	})
+}
+
+// WriteNode writes the given dag-json encoded IPLD node to the primary tier and to the tiers before it.
+// returns the CID of the node.
+func (m *LayeredClient) WriteNode(ctx context.Context, node []byte) (string, error) {
+	return m.write(ctx, node, func(ctx context.Context, c casapi.Client) (string, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return "", ErrNotSupported
+		}
+
+		return nc.WriteNode(ctx, node)
+	})
+}
+
+// ReadNode reads the IPLD node for the given CID from the first tier that has it.
+// Tiers that don't support IPLD nodes are skipped.
+func (m *LayeredClient) ReadNode(ctx context.Context, id string) ([]byte, error) {
+	return m.read(ctx, id, func(ctx context.Context, c casapi.Client) ([]byte, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return nil, ErrNotSupported
+		}
+
+		return nc.ReadNode(ctx, id)
+	}, func(ctx context.Context, c casapi.Client, node []byte) (string, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return "", ErrNotSupported
+		}
+
+		return nc.WriteNode(ctx, node)
+	})
+}
+
+// Pin pins the content for the given CID in the primary tier.
+func (m *LayeredClient) Pin(ctx context.Context, id string) error {
+	p, err := m.pinner()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := withTimeout(ctx, m.tiers[m.primary].Timeout)
+	defer cancel()
+
+	return p.Pin(ctx, id)
+}
+
+// IsPinned returns true if the content for the given CID is pinned in the primary tier.
+func (m *LayeredClient) IsPinned(ctx context.Context, id string) (bool, error) {
+	p, err := m.pinner()
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := withTimeout(ctx, m.tiers[m.primary].Timeout)
+	defer cancel()
+
+	return p.IsPinned(ctx, id)
+}
+
+func (m *LayeredClient) pinner() (Pinner, error) {
+	primary := m.tiers[m.primary]
+
+	p, ok := primary.Client.(Pinner)
+	if !ok {
+		return nil, fmt.Errorf("pin in CAS tier [%s]: %w", primary.Name, ErrNotSupported)
+	}
+
+	return p, nil
+}
+
+// Stopper is implemented by CAS clients that do work in the background (e.g. the replicating CAS client).
+type Stopper interface {
+	Stop()
+}
+
+// Stop stops the tiers that do work in the background.
+func (m *LayeredClient) Stop() {
+	for _, tier := range m.tiers {
+		if s, ok := tier.Client.(Stopper); ok {
+			s.Stop()
+		}
+	}
+}
+
+type writeFunc func(ctx context.Context, c casapi.Client) (string, error)
+
+type readFunc func(ctx context.Context, c casapi.Client) ([]byte, error)
+
+type fillFunc func(ctx context.Context, c casapi.Client, content []byte) (string, error)
+
+func (m *LayeredClient) write(ctx context.Context, content []byte, write writeFunc) (string, error) {
+	primary := m.tiers[m.primary]
+
+	id, err := writeTier(ctx, primary, write)
+	if err != nil {
+		return "", fmt.Errorf("failed to write to CAS tier [%s]: %w", primary.Name, err)
+	}
+
+	for _, tier := range m.tiers[:m.primary] {
+		m.fill(ctx, tier, id, write)
+	}
+
+	return id, nil
+}
+
+func (m *LayeredClient) read(ctx context.Context, id string, read readFunc, write fillFunc) ([]byte, error) {
+	var errs []string
+
+	notFound := true
+
+	for i, tier := range m.tiers {
+		content, err := readTier(ctx, tier, read)
+		if err == nil {
+			for _, t := range m.tiers[:i] {
+				m.fill(ctx, t, id, func(ctx context.Context, c casapi.Client) (string, error) {
+					return write(ctx, c, content)
+				})
+			}
+
+			return content, nil
+		}
+
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+
+		// the caller is no longer interested in the content
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to read CID [%s] from CAS: %w", id, ctx.Err())
+		}
+
+		if !errors.Is(err, ErrContentNotFound) {
+			if tier.FailFast {
+				return nil, fmt.Errorf("failed to read CID [%s] from CAS tier [%s]: %w", id, tier.Name, err)
+			}
+
+			notFound = false
+		}
+
+		log.Debugf("failed to read cid %s from CAS tier %s: %s", id, tier.Name, err)
+
+		errs = append(errs, fmt.Sprintf("%s: %s", tier.Name, err))
+	}
+
+	if notFound {
+		return nil, fmt.Errorf("%w: %s", ErrContentNotFound, id)
+	}
+
+	return nil, fmt.Errorf("failed to read CID [%s] from CAS: %s", id, strings.Join(errs, "; "))
+}
+
+// fill writes the content into the given tier. Errors are logged since the content is available in another tier.
+func (m *LayeredClient) fill(ctx context.Context, tier *Tier, id string, write writeFunc) {
+	filledID, err := writeTier(ctx, tier, write)
+	if err != nil {
+		if !errors.Is(err, ErrNotSupported) {
+			log.Warnf("failed to back-fill cid %s into CAS tier %s: %s", id, tier.Name, err)
+		}
+
+		return
+	}
+
+	if filledID != id {
+		log.Warnf("CAS tier %s returned cid %s for content with cid %s", tier.Name, filledID, id)
+	}
+}
+
+func readTier(ctx context.Context, tier *Tier, read readFunc) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, tier.Timeout)
+	defer cancel()
+
+	return read(ctx, tier.Client)
+}
+
+func writeTier(ctx context.Context, tier *Tier, write writeFunc) (string, error) {
+	ctx, cancel := withTimeout(ctx, tier.Timeout)
+	defer cancel()
+
+	return write(ctx, tier.Client)
+}
@@ -0,0 +1,440 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+)
+
+func TestNewLayered(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := NewLayered("ipfs", &Tier{Name: "cache", Client: newLocal(t)}, &Tier{Name: "ipfs", Client: newLocal(t)})
+		require.NoError(t, err)
+		require.NotNil(t, c)
+	})
+
+	t.Run("error - primary not found", func(t *testing.T) {
+		c, err := NewLayered("ipfs", &Tier{Name: "cache", Client: newLocal(t)})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "primary CAS tier [ipfs] not found")
+		require.Nil(t, c)
+	})
+}
+
+func TestLayeredClient_Write(t *testing.T) {
+	t.Run("success - written to primary and cache", func(t *testing.T) {
+		cache := newLocal(t)
+		primary := newLocal(t)
+		peer := &mockCAS{err: errors.New("should not be called")}
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+			&Tier{Name: "peer", Client: peer},
+		)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+
+		content, err := cache.Read(id)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		content, err = primary.Read(id)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		require.Equal(t, 0, peer.writes)
+	})
+
+	t.Run("success - cache returns different CID", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: mocks.NewMockCasClient(nil)},
+			&Tier{Name: "primary", Client: newLocal(t)},
+		)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+	})
+
+	t.Run("success - cache error is ignored", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: &mockCAS{err: errors.New("cache error")}},
+			&Tier{Name: "primary", Client: newLocal(t)},
+		)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+	})
+
+	t.Run("error - primary error", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: newLocal(t)},
+			&Tier{Name: "primary", Client: &mockCAS{err: errors.New("primary error")}},
+		)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to write to CAS tier [primary]: primary error")
+		require.Empty(t, id)
+	})
+
+	t.Run("error - primary timeout", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "primary", Client: &mockCAS{delay: time.Second}, Timeout: 10 * time.Millisecond},
+		)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Empty(t, id)
+	})
+}
+
+func TestLayeredClient_Read(t *testing.T) {
+	t.Run("success - back-filled from peer", func(t *testing.T) {
+		peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "hello world")
+		}))
+		defer peer.Close()
+
+		cache := newLocal(t)
+		primary := newLocal(t)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+			&Tier{Name: "peer", Client: NewHTTP(peer.URL + "/cas")},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		content, err = cache.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		content, err = primary.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("success - read from cache", func(t *testing.T) {
+		cache := newLocal(t)
+
+		_, err := cache.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		primary := &mockCAS{err: errors.New("should not be called")}
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+		require.Equal(t, 0, primary.reads)
+	})
+
+	t.Run("success - fall back after error and timeout", func(t *testing.T) {
+		peer := newLocal(t)
+
+		_, err := peer.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: &mockCAS{err: errors.New("cache error")}},
+			&Tier{Name: "primary", Client: &mockCAS{delay: time.Second}, Timeout: 10 * time.Millisecond},
+			&Tier{Name: "peer", Client: peer},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("error - cancelled", func(t *testing.T) {
+		peer := newLocal(t)
+
+		_, err := peer.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		primary := &mockCAS{delay: time.Second}
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "primary", Client: primary},
+			&Tier{Name: "peer", Client: peer},
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		content, err := c.ReadContext(ctx, helloWorldCID)
+		require.True(t, errors.Is(err, context.Canceled))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: newLocal(t)},
+			&Tier{Name: "primary", Client: newLocal(t)},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrContentNotFound))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - all tiers failed", func(t *testing.T) {
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: newLocal(t)},
+			&Tier{Name: "primary", Client: &mockCAS{err: errors.New("primary error")}},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrContentNotFound))
+		require.Contains(t, err.Error(), "primary: primary error")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - fail fast", func(t *testing.T) {
+		peer := newLocal(t)
+
+		_, err := peer.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "primary", Client: &mockCAS{err: errors.New("primary error")}, FailFast: true},
+			&Tier{Name: "peer", Client: peer},
+		)
+		require.NoError(t, err)
+
+		content, err := c.Read(helloWorldCID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to read CID ["+helloWorldCID+"] from CAS tier [primary]")
+		require.Nil(t, content)
+	})
+}
+
+func TestLayeredClient_Node(t *testing.T) {
+	node := []byte(`{"anchorCredential":"jwt"}`)
+
+	t.Run("success", func(t *testing.T) {
+		cache := newLocal(t)
+		primary := newLocal(t)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "sidetree", Client: mocks.NewMockCasClient(nil)},
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+		)
+		require.NoError(t, err)
+
+		id, err := c.WriteNode(context.Background(), node)
+		require.NoError(t, err)
+		require.True(t, IsDAGJSON(id))
+
+		content, err := cache.ReadNode(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, node, content)
+
+		content, err = c.ReadNode(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, node, content)
+	})
+
+	t.Run("success - back-filled", func(t *testing.T) {
+		cache := newLocal(t)
+		primary := newLocal(t)
+
+		id, err := primary.WriteNode(context.Background(), node)
+		require.NoError(t, err)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+		)
+		require.NoError(t, err)
+
+		content, err := c.ReadNode(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, node, content)
+
+		content, err = cache.ReadNode(context.Background(), id)
+		require.NoError(t, err)
+		require.Equal(t, node, content)
+	})
+
+	t.Run("error - primary doesn't support IPLD nodes", func(t *testing.T) {
+		c, err := NewLayered("primary", &Tier{Name: "primary", Client: mocks.NewMockCasClient(nil)})
+		require.NoError(t, err)
+
+		id, err := c.WriteNode(context.Background(), node)
+		require.True(t, errors.Is(err, ErrNotSupported))
+		require.Empty(t, id)
+
+		content, err := c.ReadNode(context.Background(), "bagu")
+		require.True(t, errors.Is(err, ErrContentNotFound))
+		require.Nil(t, content)
+	})
+}
+
+func TestLayeredClient_Pin(t *testing.T) {
+	t.Run("success - pinned in primary", func(t *testing.T) {
+		cache := newLocal(t)
+		primary := newLocal(t)
+
+		c, err := NewLayered("primary",
+			&Tier{Name: "cache", Client: cache},
+			&Tier{Name: "primary", Client: primary},
+		)
+		require.NoError(t, err)
+
+		_, err = cache.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		pinned, err := c.IsPinned(context.Background(), helloWorldCID)
+		require.NoError(t, err)
+		require.False(t, pinned)
+
+		require.True(t, errors.Is(c.Pin(context.Background(), helloWorldCID), ErrContentNotFound))
+
+		_, err = primary.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Pin(context.Background(), helloWorldCID))
+
+		pinned, err = c.IsPinned(context.Background(), helloWorldCID)
+		require.NoError(t, err)
+		require.True(t, pinned)
+	})
+
+	t.Run("error - primary doesn't support pinning", func(t *testing.T) {
+		c, err := NewLayered("primary", &Tier{Name: "primary", Client: mocks.NewMockCasClient(nil)})
+		require.NoError(t, err)
+
+		require.True(t, errors.Is(c.Pin(context.Background(), helloWorldCID), ErrNotSupported))
+
+		_, err = c.IsPinned(context.Background(), helloWorldCID)
+		require.True(t, errors.Is(err, ErrNotSupported))
+	})
+}
+
+func newLocal(t *testing.T) *LocalClient {
+	t.Helper()
+
+	c, err := NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	return c
+}
+
+type mockCAS struct {
+	err    error
+	delay  time.Duration
+	mutex  sync.Mutex
+	reads  int
+	writes int
+}
+
+func (m *mockCAS) Write([]byte) (string, error) {
+	m.mutex.Lock()
+	m.writes++
+	m.mutex.Unlock()
+
+	time.Sleep(m.delay)
+
+	return "", m.err
+}
+
+func (m *mockCAS) Read(string) ([]byte, error) {
+	m.mutex.Lock()
+	m.reads++
+	m.mutex.Unlock()
+
+	time.Sleep(m.delay)
+
+	return nil, m.err
+}
+
+func TestLayeredClient_Local(t *testing.T) {
+	cache := newLocal(t)
+	primary := newLocal(t)
+	peer := &mockCAS{err: errors.New("should not be called")}
+
+	_, err := primary.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	c, err := NewLayered("primary",
+		&Tier{Name: "cache", Client: cache},
+		&Tier{Name: "primary", Client: primary},
+		&Tier{Name: "peer", Client: peer},
+	)
+	require.NoError(t, err)
+
+	local := c.Local()
+
+	content, err := local.Read(helloWorldCID)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(content))
+
+	// back-filled into the cache
+	content, err = cache.Read(helloWorldCID)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(content))
+
+	_, err = local.Read("bafkreibvpkpxjm5qp3tjw3dxa4yzdawpmtprqrpjf6rqyjkdhfywj6xpia")
+	require.True(t, errors.Is(err, ErrContentNotFound))
+	require.Equal(t, 0, peer.reads)
+}
+
+func TestLayeredClient_Stop(t *testing.T) {
+	replicated, err := NewReplicated(1, []*Replica{{Name: "ipfs", Client: newLocal(t)}})
+	require.NoError(t, err)
+
+	c, err := NewLayered("ipfs",
+		&Tier{Name: "cache", Client: newLocal(t)},
+		&Tier{Name: "ipfs", Client: replicated},
+	)
+	require.NoError(t, err)
+
+	c.Stop()
+
+	require.Error(t, replicated.ctx.Err())
+}
@@ -0,0 +1,179 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+const localStoreName = "cas"
+
+// ErrContentNotFound is returned if the content for the given CID is not found.
+var ErrContentNotFound = errors.New("content not found")
+
+// LocalClient is a content addressable store that is backed by an aries storage provider.
+// The content is addressed by the same CIDs that IPFS computes, so that the local CAS and IPFS
+// are interchangeable. It implements Sidetree CAS interface.
+type LocalClient struct {
+	store storage.Store
+}
+
+// NewLocal creates a local CAS client.
+func NewLocal(provider storage.Provider) (*LocalClient, error) {
+	store, err := provider.OpenStore(localStoreName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CAS store: %w", err)
+	}
+
+	return &LocalClient{store: store}, nil
+}
+
+// Write writes the given content to CAS.
+// returns the CID (v0) which represents the address of the content (same as 'ipfs add').
+func (m *LocalClient) Write(content []byte) (string, error) {
+	id, err := GetCID(content)
+	if err != nil {
+		return "", err
+	}
+
+	err = m.put(id, content)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("added content returned cid: %s", id)
+
+	return id, nil
+}
+
+// WriteContext writes the given content to CAS. The content isn't written if the context is already done.
+// returns the CID (v0) which represents the address of the content.
+func (m *LocalClient) WriteContext(ctx context.Context, content []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return m.Write(content)
+}
+
+// Read reads the content for the given CID (v0 or v1) from CAS.
+// returns the contents of CID.
+func (m *LocalClient) Read(id string) ([]byte, error) {
+	return m.get(id)
+}
+
+// ReadContext reads the content for the given CID (v0 or v1) from CAS. The content isn't read if the
+// context is already done.
+// returns the contents of CID.
+func (m *LocalClient) ReadContext(ctx context.Context, id string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.get(id)
+}
+
+// WriteNode writes the given dag-json encoded IPLD node to CAS.
+// returns the CID (v1) of the node (same as 'ipfs block put' with dag-json codec).
+func (m *LocalClient) WriteNode(ctx context.Context, node []byte) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	id, err := GetDAGJSONCID(node)
+	if err != nil {
+		return "", err
+	}
+
+	err = m.put(id, node)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("added IPLD node returned cid: %s", id)
+
+	return id, nil
+}
+
+// ReadNode reads the IPLD node for the given CID.
+func (m *LocalClient) ReadNode(ctx context.Context, id string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return m.get(id)
+}
+
+// Pin ensures that the content for the given CID is retained. Since content is never removed from the local CAS,
+// this only checks that the content exists (an error that wraps ErrContentNotFound is returned otherwise).
+func (m *LocalClient) Pin(ctx context.Context, id string) error {
+	_, err := m.ReadContext(ctx, id)
+
+	return err
+}
+
+// IsPinned returns true if the content for the given CID exists in the local CAS.
+func (m *LocalClient) IsPinned(ctx context.Context, id string) (bool, error) {
+	_, err := m.ReadContext(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (m *LocalClient) put(id string, content []byte) error {
+	key, err := storeKey(id)
+	if err != nil {
+		return err
+	}
+
+	err = m.store.Put(key, content)
+	if err != nil {
+		return fmt.Errorf("failed to store content for CID [%s]: %w", id, err)
+	}
+
+	return nil
+}
+
+func (m *LocalClient) get(id string) ([]byte, error) {
+	key, err := storeKey(id)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := m.store.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrContentNotFound, id)
+		}
+
+		return nil, fmt.Errorf("failed to get content for CID [%s]: %w", id, err)
+	}
+
+	return content, nil
+}
+
+// storeKey returns the key under which the content of the given CID is stored. Content is stored by
+// multihash (as is done by the IPFS blockstore) so that different versions of a CID refer to the same content.
+func storeKey(id string) (string, error) {
+	c, err := ParseCID(id)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Hash().B58String(), nil
+}
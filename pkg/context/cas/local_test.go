@@ -0,0 +1,151 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocal(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, c)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		c, err := NewLocal(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open CAS store: open error")
+		require.Nil(t, c)
+	})
+}
+
+func TestLocalClient_WriteRead(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", id)
+
+		content, err := c.Read(id)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("success - CID v1", func(t *testing.T) {
+		c, err := NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		v0, err := cid.Decode(id)
+		require.NoError(t, err)
+
+		content, err := c.Read(cid.NewCidV1(cid.DagProtobuf, v0.Hash()).String())
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		c, err := NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+
+		content, err := c.Read("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.True(t, errors.Is(err, ErrContentNotFound))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - invalid CID", func(t *testing.T) {
+		c, err := NewLocal(mem.NewProvider())
+		require.NoError(t, err)
+
+		content, err := c.Read("invalid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid CID [invalid]")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		c, err := NewLocal(provider)
+		require.NoError(t, err)
+
+		id, err := c.Write([]byte("hello world"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+		require.Empty(t, id)
+
+		id, err = c.WriteNode(context.Background(), []byte("{}"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+		require.Empty(t, id)
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		c, err := NewLocal(provider)
+		require.NoError(t, err)
+
+		content, err := c.Read("Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+		require.Nil(t, content)
+	})
+}
+
+func TestLocalClient_WriteReadNode(t *testing.T) {
+	c, err := NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	id, err := c.WriteNode(context.Background(), []byte(`{"a":1}`))
+	require.NoError(t, err)
+	require.True(t, IsDAGJSON(id))
+	require.NoError(t, VerifyCID(id, []byte(`{"a":1}`)))
+
+	node, err := c.ReadNode(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, string(node))
+}
+
+func TestLocalClient_Pin(t *testing.T) {
+	c, err := NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	pinned, err := c.IsPinned(context.Background(), helloWorldCID)
+	require.NoError(t, err)
+	require.False(t, pinned)
+
+	err = c.Pin(context.Background(), helloWorldCID)
+	require.True(t, errors.Is(err, ErrContentNotFound))
+
+	_, err = c.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Pin(context.Background(), helloWorldCID))
+
+	pinned, err = c.IsPinned(context.Background(), helloWorldCID)
+	require.NoError(t, err)
+	require.True(t, pinned)
+
+	_, err = c.IsPinned(context.Background(), "invalid")
+	require.True(t, errors.Is(err, ErrInvalidCID))
+}
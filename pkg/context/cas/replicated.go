@@ -0,0 +1,462 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+)
+
+const (
+	defaultRepairInterval  = 10 * time.Second
+	defaultRepairRetries   = 5
+	defaultRepairQueueSize = 1000
+)
+
+// Replica is a replica (e.g. an IPFS node) of the replicating CAS client.
+type Replica struct {
+	// Name is the name of the replica (used for logging and errors).
+	Name string
+	// Client is the CAS client of the replica.
+	Client casapi.Client
+	// Timeout is the maximum time to wait for the replica to respond. Zero means no timeout (other than
+	// the timeout of the client itself).
+	Timeout time.Duration
+}
+
+// ReplicatedClient is a CAS client that writes content to all of its replicas. A write succeeds once a quorum
+// of replicas has confirmed the write with the same CID. Replicas that missed the write are repaired in the
+// background. Content is read from the replicas in turn. It implements Sidetree CAS interface.
+type ReplicatedClient struct {
+	replicas       []*Replica
+	quorum         int
+	repairInterval time.Duration
+	repairRetries  int
+	repairCh       chan *repair
+	ctx            context.Context
+	cancel         context.CancelFunc
+}
+
+// ReplicatedOpt is a replicating CAS client option.
+type ReplicatedOpt func(c *ReplicatedClient)
+
+// WithRepairInterval sets the interval between attempts to repair a replica that missed a write.
+// Defaults to 10s.
+func WithRepairInterval(interval time.Duration) ReplicatedOpt {
+	return func(c *ReplicatedClient) {
+		c.repairInterval = interval
+	}
+}
+
+// WithRepairRetries sets the maximum number of times that the repair of a replica is retried before giving up.
+// Defaults to 5.
+func WithRepairRetries(retries int) ReplicatedOpt {
+	return func(c *ReplicatedClient) {
+		c.repairRetries = retries
+	}
+}
+
+type repair struct {
+	replica *Replica
+	id      string
+	write   writeFunc
+	attempt int
+}
+
+type writeResult struct {
+	replica *Replica
+	id      string
+	err     error
+}
+
+// NewReplicated creates a replicating CAS client with the given replicas where quorum is the number of replicas
+// that must confirm a write. The background repair of replicas is started and runs until Stop is called.
+func NewReplicated(quorum int, replicas []*Replica, opts ...ReplicatedOpt) (*ReplicatedClient, error) {
+	if len(replicas) == 0 {
+		return nil, errors.New("at least one CAS replica must be provided")
+	}
+
+	if quorum < 1 || quorum > len(replicas) {
+		return nil, fmt.Errorf("invalid write quorum [%d]: must be between 1 and the number of replicas [%d]",
+			quorum, len(replicas))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &ReplicatedClient{
+		replicas:       replicas,
+		quorum:         quorum,
+		repairInterval: defaultRepairInterval,
+		repairRetries:  defaultRepairRetries,
+		repairCh:       make(chan *repair, defaultRepairQueueSize),
+		ctx:            ctx,
+		cancel:         cancel,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	go c.repairReplicas()
+
+	return c, nil
+}
+
+// Stop stops the background repair of replicas. Pending repairs are discarded.
+func (m *ReplicatedClient) Stop() {
+	m.cancel()
+}
+
+// Write writes the given content to all replicas.
+// returns the CID of the content once a quorum of replicas has confirmed the write.
+func (m *ReplicatedClient) Write(content []byte) (string, error) {
+	return m.WriteContext(context.Background(), content)
+}
+
+// WriteContext writes the given content to all replicas.
+// returns the CID of the content once a quorum of replicas has confirmed the write.
+func (m *ReplicatedClient) WriteContext(ctx context.Context, content []byte) (string, error) {
+	return m.write(ctx, func(ctx context.Context, c casapi.Client) (string, error) {
+		return WriteWithContext(ctx, c, content)
+	})
+}
+
+// Read reads the content for the given CID from the replicas in turn.
+// returns the contents of CID.
+func (m *ReplicatedClient) Read(id string) ([]byte, error) {
+	return m.ReadContext(context.Background(), id)
+}
+
+// ReadContext reads the content for the given CID from the replicas in turn.
+// returns the contents of CID.
+func (m *ReplicatedClient) ReadContext(ctx context.Context, id string) ([]byte, error) {
+	return m.read(ctx, id, func(ctx context.Context, c casapi.Client) ([]byte, error) {
+		return ReadWithContext(ctx, c, id)
+	}, func(ctx context.Context, c casapi.Client, content []byte) (string, error) {
+		return WriteWithContext(ctx, c, content)
+	})
+}
+
+// WriteNode writes the given dag-json encoded IPLD node to all replicas.
+// returns the CID of the node once a quorum of replicas has confirmed the write.
+func (m *ReplicatedClient) WriteNode(ctx context.Context, node []byte) (string, error) {
+	return m.write(ctx, func(ctx context.Context, c casapi.Client) (string, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return "", ErrNotSupported
+		}
+
+		return nc.WriteNode(ctx, node)
+	})
+}
+
+// ReadNode reads the IPLD node for the given CID from the replicas in turn.
+// Replicas that don't support IPLD nodes are skipped.
+func (m *ReplicatedClient) ReadNode(ctx context.Context, id string) ([]byte, error) {
+	return m.read(ctx, id, func(ctx context.Context, c casapi.Client) ([]byte, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return nil, ErrNotSupported
+		}
+
+		return nc.ReadNode(ctx, id)
+	}, func(ctx context.Context, c casapi.Client, node []byte) (string, error) {
+		nc, ok := c.(nodeClient)
+		if !ok {
+			return "", ErrNotSupported
+		}
+
+		return nc.WriteNode(ctx, node)
+	})
+}
+
+// Pin pins the content for the given CID in all replicas. Succeeds if the content was pinned in
+// a quorum of replicas.
+func (m *ReplicatedClient) Pin(ctx context.Context, id string) error {
+	var errs []string
+
+	pinned := 0
+
+	for _, replica := range m.replicas {
+		err := pinReplica(ctx, replica, id)
+		if err != nil {
+			log.Debugf("failed to pin cid %s in CAS replica %s: %s", id, replica.Name, err)
+
+			errs = append(errs, fmt.Sprintf("%s: %s", replica.Name, err))
+
+			continue
+		}
+
+		pinned++
+	}
+
+	if pinned < m.quorum {
+		return fmt.Errorf("failed to pin CID [%s] in a quorum of CAS replicas [%d of %d]: %s",
+			id, pinned, m.quorum, strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// IsPinned returns true if the content for the given CID is pinned in a quorum of replicas.
+func (m *ReplicatedClient) IsPinned(ctx context.Context, id string) (bool, error) {
+	var errs []string
+
+	pinned := 0
+
+	for _, replica := range m.replicas {
+		ok, err := isPinnedInReplica(ctx, replica, id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", replica.Name, err))
+
+			continue
+		}
+
+		if ok {
+			pinned++
+		}
+	}
+
+	if pinned >= m.quorum {
+		return true, nil
+	}
+
+	if len(errs) > 0 {
+		return false, fmt.Errorf("failed to get pin for CID [%s] from CAS replicas: %s", id, strings.Join(errs, "; "))
+	}
+
+	return false, nil
+}
+
+// write writes to all replicas and returns once a quorum of replicas has confirmed the write. The replica writes
+// are bound to the lifetime of the client (and to the timeout of each replica) rather than to the given context,
+// so that the writes of the replicas that haven't responded when the quorum is reached (or when the caller gives
+// up) aren't cancelled.
+func (m *ReplicatedClient) write(ctx context.Context, write writeFunc) (string, error) {
+	results := make(chan *writeResult, len(m.replicas))
+
+	for _, replica := range m.replicas {
+		go func(replica *Replica) {
+			id, err := writeReplica(m.ctx, replica, write)
+
+			results <- &writeResult{replica: replica, id: id, err: err}
+		}(replica)
+	}
+
+	votes := make(map[string]int)
+
+	var received []*writeResult
+
+	for i := 0; i < len(m.replicas); i++ {
+		var result *writeResult
+
+		select {
+		case result = <-results:
+		case <-ctx.Done():
+			return "", fmt.Errorf("failed to write to a quorum of CAS replicas: %w", ctx.Err())
+		}
+
+		received = append(received, result)
+
+		if result.err != nil {
+			continue
+		}
+
+		votes[result.id]++
+
+		if votes[result.id] == m.quorum {
+			// The replicas that haven't responded yet are checked in the background.
+			go m.repairMissed(result.id, write, received, results, len(m.replicas)-i-1)
+
+			return result.id, nil
+		}
+	}
+
+	var errs []string
+
+	for _, result := range received {
+		if result.err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", result.replica.Name, result.err))
+		} else {
+			errs = append(errs, fmt.Sprintf("%s: returned CID %s", result.replica.Name, result.id))
+		}
+	}
+
+	return "", fmt.Errorf("failed to write to a quorum of CAS replicas [%d of %d]: %s",
+		m.quorum, len(m.replicas), strings.Join(errs, "; "))
+}
+
+// repairMissed schedules the repair of the replicas that failed to write the content with the given CID.
+func (m *ReplicatedClient) repairMissed(id string, write writeFunc, received []*writeResult,
+	results <-chan *writeResult, remaining int) {
+	for i := 0; i < remaining; i++ {
+		received = append(received, <-results)
+	}
+
+	for _, result := range received {
+		switch {
+		case result.err == nil && result.id == id:
+		case errors.Is(result.err, ErrNotSupported):
+		case result.err == nil:
+			log.Warnf("CAS replica %s returned cid %s for content with cid %s", result.replica.Name, result.id, id)
+		default:
+			log.Infof("CAS replica %s missed the write of cid %s: %s", result.replica.Name, id, result.err)
+
+			m.scheduleRepair(&repair{replica: result.replica, id: id, write: write})
+		}
+	}
+}
+
+func (m *ReplicatedClient) read(ctx context.Context, id string, read readFunc, write fillFunc) ([]byte, error) {
+	var errs []string
+
+	var missing []*Replica
+
+	notFound := true
+
+	for _, replica := range m.replicas {
+		content, err := readReplica(ctx, replica, read)
+		if err == nil {
+			fill := func(ctx context.Context, c casapi.Client) (string, error) {
+				return write(ctx, c, content)
+			}
+
+			// the replicas that don't have the content are repaired in the background
+			for _, r := range missing {
+				m.scheduleRepair(&repair{replica: r, id: id, write: fill})
+			}
+
+			return content, nil
+		}
+
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+
+		// the caller is no longer interested in the content
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("failed to read CID [%s] from CAS: %w", id, ctx.Err())
+		}
+
+		if errors.Is(err, ErrContentNotFound) {
+			missing = append(missing, replica)
+		} else {
+			notFound = false
+		}
+
+		log.Debugf("failed to read cid %s from CAS replica %s: %s", id, replica.Name, err)
+
+		errs = append(errs, fmt.Sprintf("%s: %s", replica.Name, err))
+	}
+
+	if notFound {
+		return nil, fmt.Errorf("%w: %s", ErrContentNotFound, id)
+	}
+
+	return nil, fmt.Errorf("failed to read CID [%s] from CAS: %s", id, strings.Join(errs, "; "))
+}
+
+// scheduleRepair queues the given repair. The repair is discarded if the queue is full.
+func (m *ReplicatedClient) scheduleRepair(r *repair) {
+	select {
+	case m.repairCh <- r:
+	case <-m.ctx.Done():
+	default:
+		log.Warnf("repair queue is full - discarding repair of cid %s in CAS replica %s", r.id, r.replica.Name)
+	}
+}
+
+func (m *ReplicatedClient) repairReplicas() {
+	for {
+		select {
+		case r := <-m.repairCh:
+			m.repair(r)
+		case <-m.ctx.Done():
+			log.Debugf("repair of CAS replicas stopped")
+
+			return
+		}
+	}
+}
+
+func (m *ReplicatedClient) repair(r *repair) {
+	id, err := writeReplica(m.ctx, r.replica, r.write)
+	if err == nil {
+		if id != r.id {
+			log.Warnf("CAS replica %s returned cid %s for content with cid %s", r.replica.Name, id, r.id)
+		} else {
+			log.Infof("repaired cid %s in CAS replica %s", r.id, r.replica.Name)
+		}
+
+		return
+	}
+
+	// the client was stopped
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	r.attempt++
+
+	if r.attempt > m.repairRetries {
+		log.Errorf("giving up on repair of cid %s in CAS replica %s after %d attempts: %s",
+			r.id, r.replica.Name, r.attempt, err)
+
+		return
+	}
+
+	log.Warnf("failed to repair cid %s in CAS replica %s (attempt %d) - retrying in %s: %s",
+		r.id, r.replica.Name, r.attempt, m.repairInterval, err)
+
+	time.AfterFunc(m.repairInterval, func() {
+		m.scheduleRepair(r)
+	})
+}
+
+func readReplica(ctx context.Context, replica *Replica, read readFunc) ([]byte, error) {
+	ctx, cancel := withTimeout(ctx, replica.Timeout)
+	defer cancel()
+
+	return read(ctx, replica.Client)
+}
+
+func writeReplica(ctx context.Context, replica *Replica, write writeFunc) (string, error) {
+	ctx, cancel := withTimeout(ctx, replica.Timeout)
+	defer cancel()
+
+	return write(ctx, replica.Client)
+}
+
+func pinReplica(ctx context.Context, replica *Replica, id string) error {
+	p, ok := replica.Client.(Pinner)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	ctx, cancel := withTimeout(ctx, replica.Timeout)
+	defer cancel()
+
+	return p.Pin(ctx, id)
+}
+
+func isPinnedInReplica(ctx context.Context, replica *Replica, id string) (bool, error) {
+	p, ok := replica.Client.(Pinner)
+	if !ok {
+		return false, ErrNotSupported
+	}
+
+	ctx, cancel := withTimeout(ctx, replica.Timeout)
+	defer cancel()
+
+	return p.IsPinned(ctx, id)
+}
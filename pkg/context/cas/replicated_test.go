@@ -0,0 +1,364 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cas
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+)
+
+func TestNewReplicated(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		c, err := NewReplicated(1, []*Replica{{Name: "ipfs1", Client: newLocal(t)}})
+		require.NoError(t, err)
+		require.NotNil(t, c)
+
+		c.Stop()
+	})
+
+	t.Run("error - no replicas", func(t *testing.T) {
+		c, err := NewReplicated(1, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least one CAS replica must be provided")
+		require.Nil(t, c)
+	})
+
+	t.Run("error - invalid quorum", func(t *testing.T) {
+		replicas := []*Replica{{Name: "ipfs1", Client: newLocal(t)}, {Name: "ipfs2", Client: newLocal(t)}}
+
+		c, err := NewReplicated(0, replicas)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid write quorum [0]")
+		require.Nil(t, c)
+
+		c, err = NewReplicated(3, replicas)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid write quorum [3]")
+		require.Nil(t, c)
+	})
+}
+
+func TestReplicatedClient_Write(t *testing.T) {
+	t.Run("success - written to all replicas", func(t *testing.T) {
+		replica1 := newLocal(t)
+		replica2 := newLocal(t)
+
+		c := newReplicated(t, 2, replica1, replica2)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+
+		for _, replica := range []*LocalClient{replica1, replica2} {
+			content, err := replica.Read(id)
+			require.NoError(t, err)
+			require.Equal(t, "hello world", string(content))
+		}
+	})
+
+	t.Run("success - replica that missed the write is repaired", func(t *testing.T) {
+		flaky := &flakyCAS{LocalClient: newLocal(t), failures: 2}
+
+		c := newReplicated(t, 2, newLocal(t), newLocal(t), flaky)
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+
+		require.Eventually(t, func() bool {
+			_, err := flaky.LocalClient.Read(id)
+
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+
+		require.Equal(t, 3, flaky.getWrites())
+	})
+
+	t.Run("success - quorum doesn't wait for slow replica", func(t *testing.T) {
+		slow := &mockCAS{delay: 2 * time.Second}
+
+		c := newReplicated(t, 2, newLocal(t), newLocal(t), slow)
+
+		start := time.Now()
+
+		id, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.Equal(t, helloWorldCID, id)
+		require.True(t, time.Since(start) < time.Second)
+	})
+
+	t.Run("success - slow replica isn't cancelled by the caller", func(t *testing.T) {
+		slow := &flakyCAS{LocalClient: newLocal(t), delay: 50 * time.Millisecond}
+
+		c := newReplicated(t, 1, newLocal(t), slow)
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		id, err := c.WriteContext(ctx, []byte("hello world"))
+		require.NoError(t, err)
+
+		cancel()
+
+		require.Eventually(t, func() bool {
+			_, err := slow.LocalClient.Read(id)
+
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+
+		// written by the original write (not by a repair)
+		require.Equal(t, 1, slow.getWrites())
+	})
+
+	t.Run("error - caller gives up before quorum", func(t *testing.T) {
+		c := newReplicated(t, 1, &mockCAS{delay: time.Second})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		id, err := c.WriteContext(ctx, []byte("hello world"))
+		require.Error(t, err)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Empty(t, id)
+	})
+
+	t.Run("error - quorum not reached", func(t *testing.T) {
+		c := newReplicated(t, 2, newLocal(t), &mockCAS{err: errors.New("write error")},
+			&mockCAS{err: errors.New("write error")})
+
+		id, err := c.Write([]byte("hello world"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to write to a quorum of CAS replicas [2 of 3]")
+		require.Contains(t, err.Error(), "write error")
+		require.Empty(t, id)
+	})
+
+	t.Run("error - replicas return different CIDs", func(t *testing.T) {
+		c := newReplicated(t, 2, newLocal(t), mocks.NewMockCasClient(nil))
+
+		id, err := c.Write([]byte("hello world"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "returned CID")
+		require.Empty(t, id)
+	})
+
+	t.Run("repair gives up after retries", func(t *testing.T) {
+		broken := &flakyCAS{LocalClient: newLocal(t), failures: 100}
+
+		c, err := NewReplicated(1, []*Replica{
+			{Name: "ipfs1", Client: newLocal(t)},
+			{Name: "broken", Client: broken},
+		}, WithRepairInterval(10*time.Millisecond), WithRepairRetries(1))
+		require.NoError(t, err)
+
+		defer c.Stop()
+
+		_, err = c.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		// the initial write, the repair and one retry
+		require.Eventually(t, func() bool { return broken.getWrites() == 3 }, time.Second, 10*time.Millisecond)
+
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, 3, broken.getWrites())
+	})
+
+	t.Run("no repair after stop", func(t *testing.T) {
+		flaky := &flakyCAS{LocalClient: newLocal(t), failures: 1, delay: 50 * time.Millisecond}
+
+		c := newReplicated(t, 1, newLocal(t), flaky)
+
+		_, err := c.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		// stopped before the flaky replica fails
+		c.Stop()
+
+		time.Sleep(200 * time.Millisecond)
+		require.Equal(t, 1, flaky.getWrites())
+	})
+}
+
+func TestReplicatedClient_Read(t *testing.T) {
+	t.Run("success - replica that is missing content is repaired", func(t *testing.T) {
+		replica1 := newLocal(t)
+		replica2 := newLocal(t)
+
+		id, err := replica2.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		c := newReplicated(t, 1, replica1, replica2)
+
+		content, err := c.Read(id)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+
+		require.Eventually(t, func() bool {
+			_, err := replica1.Read(id)
+
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("success - replica error", func(t *testing.T) {
+		replica2 := newLocal(t)
+
+		id, err := replica2.Write([]byte("hello world"))
+		require.NoError(t, err)
+
+		c := newReplicated(t, 1, &mockCAS{err: errors.New("read error")}, replica2)
+
+		content, err := c.Read(id)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(content))
+	})
+
+	t.Run("error - not found", func(t *testing.T) {
+		c := newReplicated(t, 1, newLocal(t), newLocal(t))
+
+		content, err := c.Read(helloWorldCID)
+		require.True(t, errors.Is(err, ErrContentNotFound))
+		require.Nil(t, content)
+	})
+
+	t.Run("error - read error", func(t *testing.T) {
+		c := newReplicated(t, 1, newLocal(t), &mockCAS{err: errors.New("read error")})
+
+		content, err := c.Read(helloWorldCID)
+		require.Error(t, err)
+		require.False(t, errors.Is(err, ErrContentNotFound))
+		require.Contains(t, err.Error(), "read error")
+		require.Nil(t, content)
+	})
+
+	t.Run("error - cancelled", func(t *testing.T) {
+		c := newReplicated(t, 1, &mockCAS{delay: time.Second}, newLocal(t))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		content, err := c.ReadContext(ctx, helloWorldCID)
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+		require.Nil(t, content)
+	})
+}
+
+func TestReplicatedClient_Node(t *testing.T) {
+	replica1 := newLocal(t)
+	replica2 := newLocal(t)
+
+	c := newReplicated(t, 2, replica1, replica2, mocks.NewMockCasClient(nil))
+
+	id, err := c.WriteNode(context.Background(), []byte("{}"))
+	require.NoError(t, err)
+	require.Equal(t, nodeCID(t), id)
+
+	node, err := c.ReadNode(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(node))
+
+	t.Run("error - quorum not reached", func(t *testing.T) {
+		c := newReplicated(t, 2, newLocal(t), mocks.NewMockCasClient(nil))
+
+		_, err := c.WriteNode(context.Background(), []byte("{}"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), ErrNotSupported.Error())
+	})
+}
+
+func TestReplicatedClient_Pin(t *testing.T) {
+	replica1 := newLocal(t)
+	replica2 := newLocal(t)
+
+	id, err := replica1.Write([]byte("hello world"))
+	require.NoError(t, err)
+
+	t.Run("success", func(t *testing.T) {
+		c := newReplicated(t, 1, replica1, replica2)
+
+		require.NoError(t, c.Pin(context.Background(), id))
+
+		pinned, err := c.IsPinned(context.Background(), id)
+		require.NoError(t, err)
+		require.True(t, pinned)
+	})
+
+	t.Run("not pinned in a quorum of replicas", func(t *testing.T) {
+		c := newReplicated(t, 2, replica1, replica2)
+
+		err := c.Pin(context.Background(), id)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to pin CID")
+
+		pinned, err := c.IsPinned(context.Background(), id)
+		require.NoError(t, err)
+		require.False(t, pinned)
+	})
+
+	t.Run("error - pinning not supported", func(t *testing.T) {
+		c := newReplicated(t, 2, replica1, mocks.NewMockCasClient(nil))
+
+		pinned, err := c.IsPinned(context.Background(), id)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), ErrNotSupported.Error())
+		require.False(t, pinned)
+	})
+}
+
+func newReplicated(t *testing.T, quorum int, clients ...casapi.Client) *ReplicatedClient {
+	t.Helper()
+
+	var replicas []*Replica
+
+	for i, client := range clients {
+		replicas = append(replicas, &Replica{Name: string(rune('a' + i)), Client: client})
+	}
+
+	c, err := NewReplicated(quorum, replicas, WithRepairInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	t.Cleanup(c.Stop)
+
+	return c
+}
+
+// flakyCAS fails the given number of writes before writing to the local CAS.
+type flakyCAS struct {
+	*LocalClient
+	failures int
+	delay    time.Duration
+	mutex    sync.Mutex
+	writes   int
+}
+
+func (m *flakyCAS) WriteContext(ctx context.Context, content []byte) (string, error) {
+	time.Sleep(m.delay)
+
+	m.mutex.Lock()
+	m.writes++
+	fail := m.writes <= m.failures
+	m.mutex.Unlock()
+
+	if fail {
+		return "", errors.New("injected write error")
+	}
+
+	return m.LocalClient.WriteContext(ctx, content)
+}
+
+func (m *flakyCAS) getWrites() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.writes
+}
@@ -0,0 +1,142 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package casresthandler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/trustbloc/edge-core/pkg/log"
+	casapi "github.com/trustbloc/sidetree-core-go/pkg/api/cas"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+var logger = log.New("cas-handler")
+
+const (
+	// Path is the base path of the CAS endpoint.
+	Path = "/cas"
+
+	cidPathVariable = "cid"
+
+	jsonContentType    = "application/json"
+	dagJSONContentType = "application/vnd.ipld.dag-json"
+
+	// content is immutable so it may be cached indefinitely.
+	cacheControl = "public, max-age=31536000, immutable"
+)
+
+// nodeReader is implemented by CAS clients that are able to read IPLD (dag-json) nodes.
+type nodeReader interface {
+	ReadNode(ctx context.Context, cid string) ([]byte, error)
+}
+
+// Handler serves content from CAS by CID.
+type Handler struct {
+	cas casapi.Client
+}
+
+// New returns a new CAS handler.
+func New(cas casapi.Client) *Handler {
+	return &Handler{cas: cas}
+}
+
+// Path returns the HTTP REST endpoint for the CAS handler.
+func (h *Handler) Path() string {
+	return fmt.Sprintf("%s/{%s}", Path, cidPathVariable)
+}
+
+// Method returns the HTTP REST method for the CAS handler.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the CAS handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[cidPathVariable]
+
+	content, err := h.read(req.Context(), id)
+	if err != nil {
+		if errors.Is(err, cas.ErrContentNotFound) {
+			writeResponse(w, http.StatusNotFound, []byte(http.StatusText(http.StatusNotFound)))
+
+			return
+		}
+
+		if errors.Is(err, cas.ErrInvalidCID) {
+			writeResponse(w, http.StatusBadRequest, []byte(err.Error()))
+
+			return
+		}
+
+		logger.Errorf("Error reading content from CAS [%s]: %s", id, err)
+
+		writeResponse(w, http.StatusInternalServerError, []byte(http.StatusText(http.StatusInternalServerError)))
+
+		return
+	}
+
+	// Content is addressed by its hash so the CID is a strong ETag. The content is read (and therefore known to
+	// exist) before a not-modified response is returned.
+	etag := fmt.Sprintf("%q", id)
+
+	if req.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(id, content))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+
+	writeResponse(w, http.StatusOK, content)
+}
+
+func (h *Handler) read(ctx context.Context, id string) ([]byte, error) {
+	if cas.IsDAGJSON(id) {
+		if nr, ok := h.cas.(nodeReader); ok {
+			return nr.ReadNode(ctx, id)
+		}
+	}
+
+	return cas.ReadWithContext(ctx, h.cas, id)
+}
+
+// contentType returns the content type of the given content. IPLD nodes are dag-json and anchor credentials are
+// JSON. Other content (e.g. compressed Sidetree batch files) is sniffed.
+func contentType(id string, content []byte) string {
+	if cas.IsDAGJSON(id) {
+		return dagJSONContentType
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return jsonContentType
+	}
+
+	return http.DetectContentType(content)
+}
+
+func writeResponse(w http.ResponseWriter, status int, body []byte) {
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
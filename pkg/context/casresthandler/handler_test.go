@@ -0,0 +1,169 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package casresthandler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
+
+	"github.com/trustbloc/orb/pkg/context/cas"
+)
+
+func TestHandler(t *testing.T) {
+	casClient, err := cas.NewLocal(mem.NewProvider())
+	require.NoError(t, err)
+
+	jsonCID, err := casClient.Write([]byte(`{"id":"https://orb.domain.com/vc/1234"}`))
+	require.NoError(t, err)
+
+	gzipCID, err := casClient.Write(compress(t, []byte(`{"operations":[]}`)))
+	require.NoError(t, err)
+
+	nodeCID, err := casClient.WriteNode(context.Background(), []byte(`{"anchorCredential":"jwt"}`))
+	require.NoError(t, err)
+
+	h := New(casClient)
+	require.Equal(t, "/cas/{cid}", h.Path())
+	require.Equal(t, http.MethodGet, h.Method())
+	require.NotNil(t, h.Handler())
+
+	router := newRouter(h)
+
+	t.Run("JSON content", func(t *testing.T) {
+		rw := serve(router, "/cas/"+jsonCID, "")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+		require.Equal(t, `"`+jsonCID+`"`, rw.Header().Get("ETag"))
+		require.Equal(t, cacheControl, rw.Header().Get("Cache-Control"))
+		require.Equal(t, `{"id":"https://orb.domain.com/vc/1234"}`, readBody(t, rw))
+	})
+
+	t.Run("compressed content", func(t *testing.T) {
+		rw := serve(router, "/cas/"+gzipCID, "")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "application/x-gzip", rw.Header().Get("Content-Type"))
+	})
+
+	t.Run("IPLD node", func(t *testing.T) {
+		rw := serve(router, "/cas/"+nodeCID, "")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, dagJSONContentType, rw.Header().Get("Content-Type"))
+		require.Equal(t, `{"anchorCredential":"jwt"}`, readBody(t, rw))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		rw := serve(router, "/cas/"+jsonCID, `"`+jsonCID+`"`)
+
+		require.Equal(t, http.StatusNotModified, rw.Code)
+		require.Equal(t, `"`+jsonCID+`"`, rw.Header().Get("ETag"))
+		require.Empty(t, readBody(t, rw))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		rw := serve(router, "/cas/Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD", "")
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("not found - matching ETag", func(t *testing.T) {
+		const missingCID = "Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"
+
+		rw := serve(router, "/cas/"+missingCID, `"`+missingCID+`"`)
+
+		require.Equal(t, http.StatusNotFound, rw.Code)
+	})
+
+	t.Run("invalid CID", func(t *testing.T) {
+		rw := serve(router, "/cas/invalid", "")
+
+		require.Equal(t, http.StatusBadRequest, rw.Code)
+		require.Contains(t, readBody(t, rw), "invalid CID")
+	})
+
+	t.Run("CAS error", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		errCAS, err := cas.NewLocal(provider)
+		require.NoError(t, err)
+
+		rw := serve(newRouter(New(errCAS)), "/cas/"+jsonCID, "")
+
+		require.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+
+	t.Run("CAS without IPLD node support", func(t *testing.T) {
+		sidetreeCAS := mocks.NewMockCasClient(nil)
+
+		address, err := sidetreeCAS.Write([]byte("content"))
+		require.NoError(t, err)
+
+		rw := serve(newRouter(New(sidetreeCAS)), "/cas/"+address, "")
+
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+		require.Equal(t, "content", readBody(t, rw))
+	})
+}
+
+func newRouter(h *Handler) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc(h.Path(), h.Handler()).Methods(h.Method())
+
+	return router
+}
+
+func serve(router *mux.Router, path, ifNoneMatch string) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	router.ServeHTTP(rw, req)
+
+	return rw
+}
+
+func readBody(t *testing.T, rw *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	body, err := ioutil.ReadAll(rw.Result().Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func compress(t *testing.T, content []byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+
+	w := gzip.NewWriter(buf)
+
+	_, err := w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	return buf.Bytes()
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package memdidtxnref
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/trustbloc/orb/pkg/didtxnref"
@@ -33,6 +34,66 @@ func (ref *MemDidTxnRef) Add(suffix, cid string) error {
 	return nil
 }
 
+// AddIfLast adds cid (transaction reference) to the list of transaction references for this did only if the latest
+// transaction reference is expectedLast (an empty expectedLast means that the did has no transaction references).
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *MemDidTxnRef) AddIfLast(suffix, expectedLast, cid string) error {
+	ref.Lock()
+	defer ref.Unlock()
+
+	var last string
+
+	if anchors := ref.m[suffix]; len(anchors) > 0 {
+		last = anchors[len(anchors)-1]
+	}
+
+	if last != expectedLast {
+		return fmt.Errorf("%w: expected last transaction reference [%s] but was [%s] for suffix [%s]",
+			didtxnref.ErrDidTransactionConflict, expectedLast, last, suffix)
+	}
+
+	ref.m[suffix] = append(ref.m[suffix], cid)
+
+	return nil
+}
+
+// RemoveIfLast removes cid (transaction reference) from the list of transaction references for this did
+// only if it is the latest transaction reference. It is used to roll back a reference that was added by AddIfLast.
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *MemDidTxnRef) RemoveIfLast(suffix, cid string) error {
+	ref.Lock()
+	defer ref.Unlock()
+
+	anchors := ref.m[suffix]
+
+	if len(anchors) == 0 || anchors[len(anchors)-1] != cid {
+		return fmt.Errorf("%w: transaction reference [%s] is not the last transaction reference for suffix [%s]",
+			didtxnref.ErrDidTransactionConflict, cid, suffix)
+	}
+
+	// copy the remaining references since the previous slice may have been returned by Get
+	ref.m[suffix] = append([]string(nil), anchors[:len(anchors)-1]...)
+
+	return nil
+}
+
+// ReplaceIfUnchanged replaces the list of transaction references for this did with cids only if the current
+// references are expected (an empty expected means that the did has no transaction references).
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *MemDidTxnRef) ReplaceIfUnchanged(suffix string, expected, cids []string) error {
+	ref.Lock()
+	defer ref.Unlock()
+
+	if !equal(ref.m[suffix], expected) {
+		return fmt.Errorf("%w: transaction references for suffix [%s] were modified",
+			didtxnref.ErrDidTransactionConflict, suffix)
+	}
+
+	ref.m[suffix] = append([]string(nil), cids...)
+
+	return nil
+}
+
 // Get returns all anchor credential CIDs related to this suffix.
 func (ref *MemDidTxnRef) Get(suffix string) ([]string, error) {
 	ref.RLock()
@@ -55,3 +116,17 @@ func (ref *MemDidTxnRef) Last(suffix string) (string, error) {
 
 	return anchors[len(anchors)-1], nil
 }
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
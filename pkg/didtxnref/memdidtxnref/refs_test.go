@@ -7,9 +7,12 @@ SPDX-License-Identifier: Apache-2.0
 package memdidtxnref
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/didtxnref"
 )
 
 func TestMemDidTxnRef_Add(t *testing.T) {
@@ -39,3 +42,116 @@ func TestMemDidTxnRef_Get(t *testing.T) {
 		require.Nil(t, didTxnRefs)
 	})
 }
+
+func TestMemDidTxnRef_Last(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		last, err := refs.Last("did")
+		require.NoError(t, err)
+		require.Equal(t, "cid2", last)
+	})
+
+	t.Run("error - did transaction references not found", func(t *testing.T) {
+		refs := New()
+
+		last, err := refs.Last("non-existent")
+		require.Error(t, err)
+		require.Empty(t, last)
+	})
+}
+
+func TestMemDidTxnRef_AddIfLast(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.AddIfLast("did", "", "cid1"))
+		require.NoError(t, refs.AddIfLast("did", "cid1", "cid2"))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.Add("did", "cid1"))
+
+		err := refs.AddIfLast("did", "", "cid2")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		err = refs.AddIfLast("other", "cid1", "cid2")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1"}, didTxnRefs)
+	})
+}
+
+func TestMemDidTxnRef_RemoveIfLast(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		require.NoError(t, refs.RemoveIfLast("did", "cid2"))
+
+		last, err := refs.Last("did")
+		require.NoError(t, err)
+		require.Equal(t, "cid1", last)
+
+		require.NoError(t, refs.RemoveIfLast("did", "cid1"))
+
+		_, err = refs.Get("did")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionsNotFound))
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		err := refs.RemoveIfLast("did", "cid1")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		err = refs.RemoveIfLast("other", "cid1")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+	})
+}
+
+func TestMemDidTxnRef_ReplaceIfUnchanged(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.ReplaceIfUnchanged("did", nil, []string{"cid2"}))
+		require.NoError(t, refs.ReplaceIfUnchanged("did", []string{"cid2"}, []string{"cid1", "cid2"}))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs := New()
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		err := refs.ReplaceIfUnchanged("did", []string{"cid1"}, []string{"cid0", "cid1"})
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		err = refs.ReplaceIfUnchanged("did", []string{"cid2", "cid1"}, []string{"cid0"})
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+}
@@ -13,8 +13,16 @@ import (
 // ErrDidTransactionsNotFound is did transactions not found error.
 var ErrDidTransactionsNotFound = errors.New("did transactions not found")
 
+// ErrDidTransactionConflict is returned by AddIfLast and RemoveIfLast if the latest transaction reference of the did
+// is not the expected transaction reference (i.e. the references were updated concurrently).
+var ErrDidTransactionConflict = errors.New("did transaction reference conflict")
+
 // DidTransactionReferences manages did transaction references.
 type DidTransactionReferences interface {
 	Add(did, cid string) error
 	Get(did string) ([]string, error)
+	Last(did string) (string, error)
+	AddIfLast(did, expectedLast, cid string) error
+	RemoveIfLast(did, cid string) error
+	ReplaceIfUnchanged(did string, expected, cids []string) error
 }
@@ -0,0 +1,284 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storedidtxnref
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/trustbloc/edge-core/pkg/log"
+
+	"github.com/trustbloc/orb/pkg/didtxnref"
+)
+
+var logger = log.New("store-did-txn-ref")
+
+const (
+	nameSpace = "didtxnref"
+
+	// maxUpdateAttempts is the number of times that an update is attempted if the references of a did
+	// were concurrently modified by another writer.
+	maxUpdateAttempts = 5
+)
+
+// ErrRevisionConflict is returned by ConditionalStore.PutIfRevision if the stored value has a revision other
+// than the expected revision, i.e. it was modified since it was read.
+var ErrRevisionConflict = errors.New("revision conflict")
+
+// ConditionalStore is a store that supports conditional writes, e.g. a CouchDB store (using the document
+// revision) or a MySQL store (using a compare-and-swap update). Stores that are shared by several servers
+// must implement this interface so that concurrent updates of the references of a did are detected.
+type ConditionalStore interface {
+	storage.Store
+
+	// GetWithRevision returns the value and the revision of the value for the given key. An error that wraps
+	// storage.ErrDataNotFound is returned if the key doesn't exist.
+	GetWithRevision(k string) ([]byte, string, error)
+
+	// PutIfRevision stores the value for the given key only if the revision of the stored value is rev
+	// (an empty rev means that the key must not exist). An error that wraps ErrRevisionConflict is returned
+	// otherwise.
+	PutIfRevision(k string, v []byte, rev string) error
+}
+
+// StoreDidTxnRef is a persistent implementation of did/txn references that is backed by an
+// aries storage provider (mem, CouchDB or MySQL). Updates are conditional writes (see ConditionalStore)
+// so the references may be updated by several servers that share the same database.
+type StoreDidTxnRef struct {
+	store ConditionalStore
+}
+
+// New creates a persistent implementation for did transaction references. If the store of the given provider
+// doesn't implement ConditionalStore then conditional writes are only atomic within this process, so such
+// a store (e.g. a mem store) must not be shared by several servers.
+func New(provider storage.Provider) (*StoreDidTxnRef, error) {
+	store, err := provider.OpenStore(nameSpace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open did transaction reference store: %w", err)
+	}
+
+	cs, ok := store.(ConditionalStore)
+	if !ok {
+		cs = &localConditionalStore{Store: store}
+	}
+
+	return &StoreDidTxnRef{store: cs}, nil
+}
+
+// Add adds cid (transaction reference) to the list of transaction references that have been seen for this did.
+func (ref *StoreDidTxnRef) Add(suffix, cid string) error {
+	return ref.update(suffix, func(anchors []string) ([]string, error) {
+		return append(anchors, cid), nil
+	})
+}
+
+// AddIfLast adds cid (transaction reference) to the list of transaction references for this did only if the latest
+// transaction reference is expectedLast (an empty expectedLast means that the did has no transaction references).
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *StoreDidTxnRef) AddIfLast(suffix, expectedLast, cid string) error {
+	return ref.update(suffix, func(anchors []string) ([]string, error) {
+		var last string
+
+		if len(anchors) > 0 {
+			last = anchors[len(anchors)-1]
+		}
+
+		if last != expectedLast {
+			return nil, fmt.Errorf("%w: expected last transaction reference [%s] but was [%s] for suffix [%s]",
+				didtxnref.ErrDidTransactionConflict, expectedLast, last, suffix)
+		}
+
+		return append(anchors, cid), nil
+	})
+}
+
+// RemoveIfLast removes cid (transaction reference) from the list of transaction references for this did
+// only if it is the latest transaction reference. It is used to roll back a reference that was added by AddIfLast.
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *StoreDidTxnRef) RemoveIfLast(suffix, cid string) error {
+	return ref.update(suffix, func(anchors []string) ([]string, error) {
+		if len(anchors) == 0 || anchors[len(anchors)-1] != cid {
+			return nil, fmt.Errorf("%w: transaction reference [%s] is not the last transaction reference for suffix [%s]",
+				didtxnref.ErrDidTransactionConflict, cid, suffix)
+		}
+
+		return anchors[:len(anchors)-1], nil
+	})
+}
+
+// ReplaceIfUnchanged replaces the list of transaction references for this did with cids only if the current
+// references are expected (an empty expected means that the did has no transaction references).
+// An error that wraps didtxnref.ErrDidTransactionConflict is returned otherwise.
+func (ref *StoreDidTxnRef) ReplaceIfUnchanged(suffix string, expected, cids []string) error {
+	return ref.update(suffix, func(anchors []string) ([]string, error) {
+		if !equal(anchors, expected) {
+			return nil, fmt.Errorf("%w: transaction references for suffix [%s] were modified",
+				didtxnref.ErrDidTransactionConflict, suffix)
+		}
+
+		return append([]string(nil), cids...), nil
+	})
+}
+
+// Get returns all anchor credential CIDs related to this suffix.
+func (ref *StoreDidTxnRef) Get(suffix string) ([]string, error) {
+	anchors, _, err := ref.get(suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(anchors) == 0 {
+		return nil, didtxnref.ErrDidTransactionsNotFound
+	}
+
+	return anchors, nil
+}
+
+// Last will return CID of the latest anchor credential for this suffix.
+func (ref *StoreDidTxnRef) Last(suffix string) (string, error) {
+	anchors, err := ref.Get(suffix)
+	if err != nil {
+		return "", err
+	}
+
+	return anchors[len(anchors)-1], nil
+}
+
+// update applies the given modification to the references of the given suffix and stores the result if the
+// references weren't modified (by another writer) in the meantime. Otherwise the modification is applied to
+// the current references and the write is attempted again.
+func (ref *StoreDidTxnRef) update(suffix string, modify func(anchors []string) ([]string, error)) error {
+	var err error
+
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		anchors, rev, e := ref.get(suffix)
+		if e != nil && !errors.Is(e, didtxnref.ErrDidTransactionsNotFound) {
+			return e
+		}
+
+		updated, e := modify(anchors)
+		if e != nil {
+			return e
+		}
+
+		err = ref.put(suffix, updated, rev)
+		if !errors.Is(err, ErrRevisionConflict) {
+			return err
+		}
+
+		logger.Debugf("Did transaction references for suffix [%s] were modified concurrently (attempt %d): %s",
+			suffix, attempt, err)
+	}
+
+	return err
+}
+
+// get returns the references (which may be empty) and the revision of the stored references for the given suffix.
+// An error that wraps didtxnref.ErrDidTransactionsNotFound is returned if no references were stored.
+func (ref *StoreDidTxnRef) get(suffix string) ([]string, string, error) {
+	anchorsBytes, rev, err := ref.store.GetWithRevision(suffix)
+	if err != nil {
+		if errors.Is(err, storage.ErrDataNotFound) {
+			return nil, "", didtxnref.ErrDidTransactionsNotFound
+		}
+
+		return nil, "", fmt.Errorf("failed to get did transaction references for suffix [%s]: %w", suffix, err)
+	}
+
+	var anchors []string
+
+	err = json.Unmarshal(anchorsBytes, &anchors)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to unmarshal did transaction references for suffix [%s]: %w", suffix, err)
+	}
+
+	return anchors, rev, nil
+}
+
+func (ref *StoreDidTxnRef) put(suffix string, anchors []string, rev string) error {
+	if anchors == nil {
+		anchors = []string{}
+	}
+
+	anchorsBytes, err := json.Marshal(anchors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal did transaction references for suffix [%s]: %w", suffix, err)
+	}
+
+	err = ref.store.PutIfRevision(suffix, anchorsBytes, rev)
+	if err != nil {
+		return fmt.Errorf("failed to store did transaction references for suffix [%s]: %w", suffix, err)
+	}
+
+	return nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// localConditionalStore implements conditional writes for a store that doesn't support them. The revision of
+// a value is its hash and writes are serialized within this process only.
+type localConditionalStore struct {
+	storage.Store
+	mutex sync.Mutex
+}
+
+func (s *localConditionalStore) GetWithRevision(k string) ([]byte, string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.getWithRevision(k)
+}
+
+func (s *localConditionalStore) PutIfRevision(k string, v []byte, rev string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, current, err := s.getWithRevision(k)
+	if err != nil && !errors.Is(err, storage.ErrDataNotFound) {
+		return err
+	}
+
+	if current != rev {
+		return fmt.Errorf("%w: expected revision [%s] but was [%s] for key [%s]", ErrRevisionConflict, rev, current, k)
+	}
+
+	return s.Store.Put(k, v)
+}
+
+func (s *localConditionalStore) getWithRevision(k string) ([]byte, string, error) {
+	v, err := s.Store.Get(k)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return v, Revision(v), nil
+}
+
+// Revision returns a revision of the given value (the hex encoded SHA-256 hash) that may be used by stores
+// which don't maintain revisions.
+func Revision(v []byte) string {
+	h := sha256.Sum256(v)
+
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,403 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package storedidtxnref
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
+	"github.com/stretchr/testify/require"
+
+	"github.com/trustbloc/orb/pkg/didtxnref"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+		require.NotNil(t, refs)
+	})
+
+	t.Run("error - open store", func(t *testing.T) {
+		refs, err := New(&mockstore.MockStoreProvider{ErrOpenStoreHandle: errors.New("open error")})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to open did transaction reference store: open error")
+		require.Nil(t, refs)
+	})
+}
+
+func TestStoreDidTxnRef_AddGet(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+
+		last, err := refs.Last("did")
+		require.NoError(t, err)
+		require.Equal(t, "cid2", last)
+	})
+
+	t.Run("success - references survive a new instance", func(t *testing.T) {
+		provider := mem.NewProvider()
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid"))
+
+		refs, err = New(provider)
+		require.NoError(t, err)
+
+		last, err := refs.Last("did")
+		require.NoError(t, err)
+		require.Equal(t, "cid", last)
+	})
+
+	t.Run("error - did transaction references not found", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		didTxnRefs, err := refs.Get("non-existent")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionsNotFound))
+		require.Nil(t, didTxnRefs)
+
+		last, err := refs.Last("non-existent")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionsNotFound))
+		require.Empty(t, last)
+	})
+
+	t.Run("error - put", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = errors.New("put error")
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		err = refs.Add("did", "cid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "put error")
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		err = refs.Add("did", "cid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+
+		last, err := refs.Last("did")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+		require.Empty(t, last)
+	})
+
+	t.Run("error - invalid stored value", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.Store["did"] = []byte("invalid")
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		didTxnRefs, err := refs.Get("did")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to unmarshal did transaction references")
+		require.Nil(t, didTxnRefs)
+	})
+}
+
+func TestStoreDidTxnRef_AddIfLast(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.AddIfLast("did", "", "cid1"))
+		require.NoError(t, refs.AddIfLast("did", "cid1", "cid2"))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+
+		err = refs.AddIfLast("did", "", "cid2")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+		require.Contains(t, err.Error(), "expected last transaction reference [] but was [cid1]")
+
+		err = refs.AddIfLast("other", "cid1", "cid2")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1"}, didTxnRefs)
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		err = refs.AddIfLast("did", "", "cid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+	})
+}
+
+func TestStoreDidTxnRef_RemoveIfLast(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		require.NoError(t, refs.RemoveIfLast("did", "cid2"))
+
+		last, err := refs.Last("did")
+		require.NoError(t, err)
+		require.Equal(t, "cid1", last)
+
+		require.NoError(t, refs.RemoveIfLast("did", "cid1"))
+
+		_, err = refs.Get("did")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionsNotFound))
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		err = refs.RemoveIfLast("did", "cid1")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+		require.Contains(t, err.Error(), "transaction reference [cid1] is not the last transaction reference")
+
+		err = refs.RemoveIfLast("other", "cid1")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+	})
+
+	t.Run("error - get", func(t *testing.T) {
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrGet = errors.New("get error")
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		err = refs.RemoveIfLast("did", "cid")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "get error")
+	})
+}
+
+func TestStoreDidTxnRef_ReplaceIfUnchanged(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.ReplaceIfUnchanged("did", nil, []string{"cid2"}))
+		require.NoError(t, refs.ReplaceIfUnchanged("did", []string{"cid2"}, []string{"cid1", "cid2"}))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict", func(t *testing.T) {
+		refs, err := New(mem.NewProvider())
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+		require.NoError(t, refs.Add("did", "cid2"))
+
+		err = refs.ReplaceIfUnchanged("did", []string{"cid1"}, []string{"cid0", "cid1"})
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+		require.Contains(t, err.Error(), "transaction references for suffix [did] were modified")
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+}
+
+func TestStoreDidTxnRef_ConcurrentWriter(t *testing.T) {
+	t.Run("success - retried after concurrent add", func(t *testing.T) {
+		provider := newConditionalStoreProvider()
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		other, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+
+		// another writer adds a reference between the read and the write
+		provider.store.beforePut = func() {
+			provider.store.beforePut = nil
+
+			require.NoError(t, other.Add("did", "cid2"))
+		}
+
+		require.NoError(t, refs.Add("did", "cid3"))
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2", "cid3"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict with concurrent add", func(t *testing.T) {
+		provider := newConditionalStoreProvider()
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		other, err := New(provider)
+		require.NoError(t, err)
+
+		require.NoError(t, refs.Add("did", "cid1"))
+
+		// another writer anchors the did between the read and the write
+		provider.store.beforePut = func() {
+			provider.store.beforePut = nil
+
+			require.NoError(t, other.AddIfLast("did", "cid1", "cid2"))
+		}
+
+		err = refs.AddIfLast("did", "cid1", "cid3")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+		require.Contains(t, err.Error(), "expected last transaction reference [cid1] but was [cid2]")
+
+		didTxnRefs, err := refs.Get("did")
+		require.NoError(t, err)
+		require.Equal(t, []string{"cid1", "cid2"}, didTxnRefs)
+	})
+
+	t.Run("error - conflict with concurrent create", func(t *testing.T) {
+		provider := newConditionalStoreProvider()
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		other, err := New(provider)
+		require.NoError(t, err)
+
+		provider.store.beforePut = func() {
+			provider.store.beforePut = nil
+
+			require.NoError(t, other.AddIfLast("did", "", "cid1"))
+		}
+
+		err = refs.AddIfLast("did", "", "cid2")
+		require.True(t, errors.Is(err, didtxnref.ErrDidTransactionConflict))
+	})
+
+	t.Run("error - too many concurrent modifications", func(t *testing.T) {
+		provider := newConditionalStoreProvider()
+
+		refs, err := New(provider)
+		require.NoError(t, err)
+
+		other, err := New(provider)
+		require.NoError(t, err)
+
+		i := 0
+
+		provider.store.beforePut = func() {
+			i++
+
+			require.NoError(t, other.Add("did", fmt.Sprintf("other%d", i)))
+		}
+
+		err = refs.Add("did", "cid")
+		require.True(t, errors.Is(err, ErrRevisionConflict))
+		require.Equal(t, maxUpdateAttempts, i)
+	})
+}
+
+func TestLocalConditionalStore(t *testing.T) {
+	store := &localConditionalStore{Store: mockstore.NewMockStoreProvider().Store}
+
+	v, rev, err := store.GetWithRevision("k")
+	require.True(t, errors.Is(err, storage.ErrDataNotFound))
+	require.Nil(t, v)
+	require.Empty(t, rev)
+
+	require.NoError(t, store.PutIfRevision("k", []byte("v1"), ""))
+
+	err = store.PutIfRevision("k", []byte("v2"), "")
+	require.True(t, errors.Is(err, ErrRevisionConflict))
+
+	v, rev, err = store.GetWithRevision("k")
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(v))
+	require.Equal(t, Revision([]byte("v1")), rev)
+
+	require.NoError(t, store.PutIfRevision("k", []byte("v2"), rev))
+
+	err = store.PutIfRevision("k", []byte("v3"), rev)
+	require.True(t, errors.Is(err, ErrRevisionConflict))
+}
+
+// conditionalStoreProvider provides a conditional store that is shared by all did transaction reference
+// instances (as a database is shared by several servers).
+type conditionalStoreProvider struct {
+	*mockstore.MockStoreProvider
+	store *hookedConditionalStore
+}
+
+func newConditionalStoreProvider() *conditionalStoreProvider {
+	p := mockstore.NewMockStoreProvider()
+
+	return &conditionalStoreProvider{
+		MockStoreProvider: p,
+		store:             &hookedConditionalStore{localConditionalStore: &localConditionalStore{Store: p.Store}},
+	}
+}
+
+func (p *conditionalStoreProvider) OpenStore(string) (storage.Store, error) {
+	return p.store, nil
+}
+
+// hookedConditionalStore invokes beforePut (if set) before a conditional write in order to simulate a write
+// of another server. Writes of the hook itself don't invoke the hook.
+type hookedConditionalStore struct {
+	*localConditionalStore
+	beforePut func()
+	inHook    bool
+}
+
+func (s *hookedConditionalStore) PutIfRevision(k string, v []byte, rev string) error {
+	if s.beforePut != nil && !s.inHook {
+		s.inHook = true
+		s.beforePut()
+		s.inHook = false
+	}
+
+	return s.localConditionalStore.PutIfRevision(k, v, rev)
+}
@@ -0,0 +1,46 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+const (
+	// CredentialsContextV1 is the URI of the W3C verifiable credentials context.
+	CredentialsContextV1 = "https://www.w3.org/2018/credentials/v1"
+	// SecurityContextV1 is the URI of the security vocabulary v1 context.
+	SecurityContextV1 = "https://w3id.org/security/v1"
+	// SecurityContextV2 is the URI of the security vocabulary v2 context.
+	SecurityContextV2 = "https://w3id.org/security/v2"
+	// JWS2020ContextV1 is the URI of the JSON Web Signature 2020 suite context.
+	JWS2020ContextV1 = "https://w3id.org/security/suites/jws-2020/v1"
+	// Ed25519Signature2018ContextV1 is the URI of the Ed25519 Signature 2018 suite context.
+	Ed25519Signature2018ContextV1 = "https://w3id.org/security/suites/ed25519-2018/v1"
+	// ActivityStreamsContext is the URI of the ActivityStreams context.
+	ActivityStreamsContext = "https://www.w3.org/ns/activitystreams"
+
+	// AnchorContextV1 is the URI of the orb JSON-LD context that defines anchor credential terms.
+	AnchorContextV1 = "https://trustbloc.github.io/Context/orb-v1.json"
+
+	// AnchorCredentialType is the type of anchor credential.
+	AnchorCredentialType = "AnchorCredential"
+)
+
+// anchorContextV1Doc defines the AnchorCredential type and the anchor credential subject terms.
+// Previous transactions are keyed by DID suffix so they are defined as a JSON literal.
+const anchorContextV1Doc = `{
+  "@context": {
+    "@version": 1.1,
+    "orb": "https://w3id.org/orb#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "AnchorCredential": "orb:AnchorCredential",
+    "anchorString": {"@id": "orb:anchorString", "@type": "xsd:string"},
+    "namespace": {"@id": "orb:namespace", "@type": "xsd:string"},
+    "version": {"@id": "orb:version", "@type": "xsd:integer"},
+    "previousTransactions": {"@id": "orb:previousTransactions", "@type": "@json"},
+    "operationCount": {"@id": "orb:operationCount", "@type": "xsd:integer"},
+    "operationTypes": {"@id": "orb:operationTypes", "@type": "xsd:string", "@container": "@set"},
+    "coreIndex": {"@id": "orb:coreIndex", "@type": "xsd:string"}
+  }
+}`
@@ -0,0 +1,205 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/trustbloc/edge-core/pkg/log"
+)
+
+var logger = log.New("orb-jsonld")
+
+// ErrContextNotFound is returned if the requested context is not available locally and remote fetching of
+// contexts isn't enabled.
+var ErrContextNotFound = errors.New("JSON-LD context not found")
+
+// embeddedContexts contains all of the contexts that are always served from local copies.
+var embeddedContexts = map[string]string{ //nolint:gochecknoglobals
+	CredentialsContextV1:          credentialsV1Doc,
+	SecurityContextV1:             securityV1Doc,
+	SecurityContextV2:             securityV2Doc,
+	JWS2020ContextV1:              jws2020V1Doc,
+	Ed25519Signature2018ContextV1: ed25519Signature2018V1Doc,
+	ActivityStreamsContext:        activityStreamsDoc,
+	AnchorContextV1:               anchorContextV1Doc,
+}
+
+// ContextDocument holds a JSON-LD context document and the URL under which it is served.
+type ContextDocument struct {
+	URL      string      `json:"url"`
+	Document interface{} `json:"document"`
+}
+
+// Options holds the document loader options. The zero value is strict, i.e. contexts that are not available
+// locally are never fetched from the network.
+type Options struct {
+	RemoteFetch bool
+	HTTPClient  *http.Client
+	Contexts    []ContextDocument
+}
+
+// Opt is a document loader option.
+type Opt func(opts *Options)
+
+// WithRemoteFetch allows the document loader to fetch (and cache) contexts that are not available locally
+// from the network.
+func WithRemoteFetch() Opt {
+	return func(opts *Options) {
+		opts.RemoteFetch = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to fetch contexts that are not available locally (if remote fetching
+// is enabled).
+func WithHTTPClient(client *http.Client) Opt {
+	return func(opts *Options) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithContexts adds the given context documents to the document loader. Embedded contexts
+// may not be overridden.
+func WithContexts(contexts ...ContextDocument) Opt {
+	return func(opts *Options) {
+		opts.Contexts = append(opts.Contexts, contexts...)
+	}
+}
+
+// DocumentLoader is a JSON-LD document loader that serves contexts from local copies.
+// Contexts that are not available locally are only fetched remotely (and cached) if remote fetching is enabled.
+type DocumentLoader struct {
+	mutex     sync.RWMutex
+	documents map[string]*ld.RemoteDocument
+	remote    ld.DocumentLoader
+}
+
+// NewDocumentLoader returns a new JSON-LD document loader.
+func NewDocumentLoader(opts ...Opt) *DocumentLoader {
+	options := &Options{}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	l := &DocumentLoader{
+		documents: make(map[string]*ld.RemoteDocument),
+	}
+
+	if options.RemoteFetch {
+		client := options.HTTPClient
+		if client == nil {
+			client = &http.Client{}
+		}
+
+		l.remote = ld.NewDefaultDocumentLoader(client)
+	}
+
+	for _, c := range options.Contexts {
+		if _, ok := embeddedContexts[c.URL]; ok {
+			logger.Warnf("Ignoring context [%s] since it may not be overridden", c.URL)
+
+			continue
+		}
+
+		l.add(c.URL, c.Document)
+	}
+
+	for u, doc := range embeddedContexts {
+		document, err := ld.DocumentFromReader(strings.NewReader(doc))
+		if err != nil {
+			// embedded documents are constants so this can only happen if they are malformed
+			panic(err)
+		}
+
+		l.add(u, document)
+	}
+
+	return l
+}
+
+// LoadDocument returns the document for the given URL. If the document is not available locally
+// then it is fetched remotely if remote fetching is enabled, otherwise ErrContextNotFound is returned.
+func (l *DocumentLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	l.mutex.RLock()
+	doc, ok := l.documents[u]
+	l.mutex.RUnlock()
+
+	if ok {
+		return doc, nil
+	}
+
+	if l.remote == nil {
+		return nil, fmt.Errorf("%w: %s", ErrContextNotFound, u)
+	}
+
+	logger.Infof("Loading remote context: %s", u)
+
+	doc, err := l.remote.LoadDocument(u)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mutex.Lock()
+	l.documents[u] = doc
+	l.mutex.Unlock()
+
+	return doc, nil
+}
+
+func (l *DocumentLoader) add(u string, document interface{}) {
+	l.documents[u] = &ld.RemoteDocument{DocumentURL: u, Document: document}
+}
+
+// ReadContextsDir reads all of the context documents (*.json and *.jsonld) in the given directory.
+// Each file must contain a JSON object with the "url" of the context and the context "document".
+func ReadContextsDir(dir string) ([]ContextDocument, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read contexts dir [%s]: %w", dir, err)
+	}
+
+	var contexts []ContextDocument
+
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+
+		if file.IsDir() || (ext != ".json" && ext != ".jsonld") {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+
+		contextBytes, err := ioutil.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("read context file [%s]: %w", path, err)
+		}
+
+		var c ContextDocument
+
+		err = json.Unmarshal(contextBytes, &c)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal context file [%s]: %w", path, err)
+		}
+
+		if c.URL == "" || c.Document == nil {
+			return nil, fmt.Errorf("context file [%s] must contain url and document", path)
+		}
+
+		contexts = append(contexts, c)
+	}
+
+	return contexts, nil
+}
@@ -0,0 +1,340 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonld
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/aries-framework-go/pkg/doc/jose"
+	ariesjsonld "github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util/signature"
+	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	gojose "github.com/square/go-jose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDocumentLoader(t *testing.T) {
+	loader := NewDocumentLoader()
+	require.NotNil(t, loader)
+
+	t.Run("orb context", func(t *testing.T) {
+		doc, err := loader.LoadDocument(AnchorContextV1)
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+	})
+
+	t.Run("embedded contexts", func(t *testing.T) {
+		for u := range embeddedContexts {
+			doc, err := loader.LoadDocument(u)
+			require.NoError(t, err, u)
+			require.NotNil(t, doc.Document, u)
+		}
+	})
+
+	t.Run("anchor credential subject is canonicalized", func(t *testing.T) {
+		vc := map[string]interface{}{
+			"@context": []interface{}{"https://www.w3.org/2018/credentials/v1", AnchorContextV1},
+			"type":     []interface{}{"VerifiableCredential", AnchorCredentialType},
+			"issuer":   "https://example.com/issuer",
+			"credentialSubject": map[string]interface{}{
+				"anchorString": "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt",
+				"namespace":    "did:orb",
+				"version":      float64(1),
+				"previousTransactions": map[string]interface{}{
+					"EiBjQ3HcS1NRWdoUzyb2cCHHhl8GjHp8vCZkx0Jy8ox3Ug": "QmaFEE1PiEz2ueRZ9kEAqoFxwtcadpJgLYCRkNCXtPT5QR",
+				},
+				"operationCount": float64(1),
+				"operationTypes": []interface{}{"create", "update"},
+				"coreIndex":      "QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt",
+			},
+		}
+
+		canonicalDoc, err := ariesjsonld.Default().GetCanonicalDocument(vc, ariesjsonld.WithDocumentLoader(loader))
+		require.NoError(t, err)
+
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#AnchorCredential>")
+		require.Contains(t, string(canonicalDoc), "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt")
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#namespace>")
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#version>")
+		require.Contains(t, string(canonicalDoc), "QmaFEE1PiEz2ueRZ9kEAqoFxwtcadpJgLYCRkNCXtPT5QR")
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#operationCount>")
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#operationTypes> \"update\"")
+		require.Contains(t, string(canonicalDoc), "<https://w3id.org/orb#coreIndex>")
+	})
+}
+
+func TestDocumentLoader_Default(t *testing.T) {
+	loader := NewDocumentLoader()
+
+	t.Run("embedded context", func(t *testing.T) {
+		doc, err := loader.LoadDocument(CredentialsContextV1)
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		doc, err := loader.LoadDocument("https://example.com/unknown/v1")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrContextNotFound))
+		require.Nil(t, doc)
+	})
+}
+
+func TestDocumentLoader_Remote(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		if r.URL.Path != "/context/v1" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/ld+json")
+
+		_, err := w.Write([]byte(testContextDoc))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	loader := NewDocumentLoader(WithRemoteFetch(), WithHTTPClient(server.Client()))
+
+	t.Run("success", func(t *testing.T) {
+		doc, err := loader.LoadDocument(server.URL + "/context/v1")
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+
+		// The second load should be served from the cache.
+		doc, err = loader.LoadDocument(server.URL + "/context/v1")
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		doc, err := loader.LoadDocument(server.URL + "/context/v2")
+		require.Error(t, err)
+		require.Nil(t, doc)
+	})
+}
+
+func TestWithContexts(t *testing.T) {
+	const contextURL = "https://example.com/context/v1"
+
+	loader := NewDocumentLoader(WithContexts(
+		ContextDocument{
+			URL:      contextURL,
+			Document: map[string]interface{}{"@context": map[string]interface{}{"name": "https://example.com#name"}},
+		},
+		ContextDocument{
+			URL:      CredentialsContextV1,
+			Document: map[string]interface{}{"@context": map[string]interface{}{}},
+		},
+	))
+
+	doc, err := loader.LoadDocument(contextURL)
+	require.NoError(t, err)
+	require.NotNil(t, doc.Document)
+
+	doc, err = loader.LoadDocument(CredentialsContextV1)
+	require.NoError(t, err)
+
+	docMap, ok := doc.Document.(map[string]interface{})
+	require.True(t, ok)
+
+	ctx, ok := docMap["@context"].(map[string]interface{})
+	require.True(t, ok)
+	require.NotEmpty(t, ctx, "embedded context must not be overridden")
+}
+
+func TestReadContextsDir(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		dir := newTempDir(t)
+
+		writeFile(t, dir, "context1.json",
+			fmt.Sprintf(`{"url":"https://example.com/context/v1","document":%s}`, testContextDoc))
+		writeFile(t, dir, "context2.jsonld",
+			fmt.Sprintf(`{"url":"https://example.com/context/v2","document":%s}`, testContextDoc))
+		writeFile(t, dir, "README.md", "not a context")
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir.json"), 0700))
+
+		contexts, err := ReadContextsDir(dir)
+		require.NoError(t, err)
+		require.Len(t, contexts, 2)
+
+		loader := NewDocumentLoader(WithContexts(contexts...))
+
+		doc, err := loader.LoadDocument("https://example.com/context/v2")
+		require.NoError(t, err)
+		require.NotNil(t, doc.Document)
+	})
+
+	t.Run("dir not found", func(t *testing.T) {
+		contexts, err := ReadContextsDir(filepath.Join(newTempDir(t), "invalid"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "read contexts dir")
+		require.Empty(t, contexts)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		dir := newTempDir(t)
+
+		writeFile(t, dir, "context.json", "{")
+
+		contexts, err := ReadContextsDir(dir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unmarshal context file")
+		require.Empty(t, contexts)
+	})
+
+	t.Run("missing URL", func(t *testing.T) {
+		dir := newTempDir(t)
+
+		writeFile(t, dir, "context.json", fmt.Sprintf(`{"document":%s}`, testContextDoc))
+
+		contexts, err := ReadContextsDir(dir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "must contain url and document")
+		require.Empty(t, contexts)
+	})
+
+	t.Run("missing document", func(t *testing.T) {
+		dir := newTempDir(t)
+
+		writeFile(t, dir, "context.json", `{"url":"https://example.com/context/v1"}`)
+
+		contexts, err := ReadContextsDir(dir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "must contain url and document")
+		require.Empty(t, contexts)
+	})
+}
+
+func TestDocumentLoader_SignAndVerifyOffline(t *testing.T) {
+	loader := NewDocumentLoader()
+
+	pubKey, privKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("Ed25519Signature2018", func(t *testing.T) {
+		vc := newAnchorCredential()
+
+		err := vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+			SignatureType:           "Ed25519Signature2018",
+			Suite:                   ed25519signature2018.New(suite.WithSigner(signature.GetEd25519Signer(privKey, pubKey))),
+			SignatureRepresentation: verifiable.SignatureJWS,
+			VerificationMethod:      "did:web:example.com#key1",
+		}, ariesjsonld.WithDocumentLoader(loader))
+		require.NoError(t, err)
+
+		vcBytes, err := vc.MarshalJSON()
+		require.NoError(t, err)
+
+		_, err = verifiable.ParseCredential(vcBytes,
+			verifiable.WithJSONLDDocumentLoader(loader),
+			verifiable.WithEmbeddedSignatureSuites(
+				ed25519signature2018.New(suite.WithVerifier(ed25519signature2018.NewPublicKeyVerifier())),
+			),
+			verifiable.WithPublicKeyFetcher(func(_, _ string) (*verifier.PublicKey, error) {
+				return &verifier.PublicKey{Type: kms.ED25519, Value: pubKey}, nil
+			}),
+		)
+		require.NoError(t, err)
+	})
+
+	t.Run("JsonWebSignature2020", func(t *testing.T) {
+		vc := newAnchorCredential()
+
+		err := vc.AddLinkedDataProof(&verifiable.LinkedDataProofContext{
+			SignatureType:           "JsonWebSignature2020",
+			Suite:                   jsonwebsignature2020.New(suite.WithSigner(signature.GetEd25519Signer(privKey, pubKey))),
+			SignatureRepresentation: verifiable.SignatureJWS,
+			VerificationMethod:      "did:web:example.com#key1",
+		}, ariesjsonld.WithDocumentLoader(loader))
+		require.NoError(t, err)
+
+		vcBytes, err := vc.MarshalJSON()
+		require.NoError(t, err)
+
+		_, err = verifiable.ParseCredential(vcBytes,
+			verifiable.WithJSONLDDocumentLoader(loader),
+			verifiable.WithEmbeddedSignatureSuites(
+				jsonwebsignature2020.New(suite.WithVerifier(jsonwebsignature2020.NewPublicKeyVerifier())),
+			),
+			verifiable.WithPublicKeyFetcher(func(_, _ string) (*verifier.PublicKey, error) {
+				return &verifier.PublicKey{
+					Type: "JwsVerificationKey2020",
+					JWK: &jose.JWK{
+						JSONWebKey: gojose.JSONWebKey{Key: pubKey},
+						Kty:        "OKP",
+						Crv:        "Ed25519",
+					},
+				}, nil
+			}),
+		)
+		require.NoError(t, err)
+	})
+}
+
+func newAnchorCredential() *verifiable.Credential {
+	return &verifiable.Credential{
+		Context: []string{CredentialsContextV1, AnchorContextV1},
+		Types:   []string{"VerifiableCredential", AnchorCredentialType},
+		Issuer:  verifiable.Issuer{ID: "https://example.com/issuer"},
+		Issued:  util.NewTime(time.Now()),
+		Subject: map[string]interface{}{
+			"anchorString": "1.QmWyXXiJq9aWQaSKqYyVAMwsMfs29zi1gnFMoJ6MhgWkjt",
+			"namespace":    "did:orb",
+			"version":      float64(1),
+		},
+	}
+}
+
+func newTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "contexts")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, os.RemoveAll(dir))
+	})
+
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+}
+
+const testContextDoc = `{
+  "@context": {
+    "name": "https://example.com#name"
+  }
+}`
@@ -0,0 +1,68 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/trustbloc/edge-core/pkg/log"
+	"github.com/trustbloc/sidetree-core-go/pkg/restapi/common"
+)
+
+var logger = log.New("metrics")
+
+const (
+	// Path is the path of the metrics endpoint.
+	Path = "/metrics"
+
+	jsonContentType = "application/json"
+)
+
+// registry contains the metrics of the server. Only these metrics are served (as opposed to all expvar variables
+// which include the command line).
+var registry = expvar.NewMap("orb") //nolint:gochecknoglobals
+
+// RegisterGauge registers a gauge whose current value is returned by the given function. A gauge that's
+// registered with the same name replaces the existing gauge.
+func RegisterGauge(name string, value func() int64) {
+	registry.Set(name, expvar.Func(func() interface{} {
+		return value()
+	}))
+}
+
+// Handler serves the metrics of the server as a JSON object.
+type Handler struct{}
+
+// NewHandler returns a new metrics handler.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Path returns the HTTP REST endpoint for the metrics handler.
+func (h *Handler) Path() string {
+	return Path
+}
+
+// Method returns the HTTP REST method for the metrics handler.
+func (h *Handler) Method() string {
+	return http.MethodGet
+}
+
+// Handler returns the HTTP REST handler for the metrics handler.
+func (h *Handler) Handler() common.HTTPRequestHandler {
+	return h.handle
+}
+
+func (h *Handler) handle(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(registry.String())); err != nil {
+		logger.Warnf("Unable to write response: %s", err)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright SecureKey Technologies Inc. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	RegisterGauge("testGauge", func() int64 { return 1 })
+
+	// replaces the existing gauge
+	RegisterGauge("testGauge", func() int64 { return 5 })
+
+	h := NewHandler()
+	require.Equal(t, Path, h.Path())
+	require.Equal(t, http.MethodGet, h.Method())
+	require.NotNil(t, h.Handler())
+
+	rw := httptest.NewRecorder()
+
+	h.Handler()(rw, httptest.NewRequest(http.MethodGet, Path, nil))
+
+	require.Equal(t, http.StatusOK, rw.Code)
+	require.Equal(t, jsonContentType, rw.Header().Get("Content-Type"))
+
+	metrics := make(map[string]int64)
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &metrics))
+	require.Equal(t, int64(5), metrics["testGauge"])
+}
@@ -7,10 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package mocks
 
 import (
-	"errors"
 	"fmt"
 	"sync"
 
+	"github.com/hyperledger/aries-framework-go/pkg/storage"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/observer"
 )
@@ -56,14 +56,15 @@ func (m *MockOperationStore) Put(ops []*operation.AnchoredOperation) error {
 	return nil
 }
 
-// Get retrieves the operations for the given suffix.
+// Get retrieves the operations for the given suffix. An error that wraps storage.ErrDataNotFound is returned
+// if there are no operations for the suffix.
 func (m *MockOperationStore) Get(suffix string) ([]*operation.AnchoredOperation, error) {
 	m.RLock()
 	defer m.RUnlock()
 
 	ops := m.operations[suffix]
 	if len(ops) == 0 {
-		return nil, errors.New("uniqueSuffix not found in the store")
+		return nil, fmt.Errorf("uniqueSuffix not found in the store: %w", storage.ErrDataNotFound)
 	}
 
 	return ops, nil
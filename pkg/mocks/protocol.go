@@ -29,6 +29,9 @@ import (
 // DefaultNS is default namespace used in mocks.
 const DefaultNS = "did:sidetree"
 
+// DefaultMultihashAlgorithms are the multihash algorithms of the protocol (SHA2-256).
+var DefaultMultihashAlgorithms = []uint{18} //nolint:gochecknoglobals
+
 // maximum batch files size in bytes.
 const maxBatchFileSize = 20000
 
@@ -75,6 +78,7 @@ type MockProtocolClientProvider struct {
 	opStore       txnprocessor.OperationStore
 	casClient     cas.Client
 	txnGraph      txnprocessor.TxnGraph
+	didIndex      txnprocessor.DIDIndex
 	methodCtx     []string
 	baseEnabled   bool
 }
@@ -107,6 +111,13 @@ func (m *MockProtocolClientProvider) WithTxnGraph(txnGraph txnprocessor.TxnGraph
 	return m
 }
 
+// WithDIDIndex sets the reverse (anchor to DIDs) index that is populated by the transaction processor.
+func (m *MockProtocolClientProvider) WithDIDIndex(didIndex txnprocessor.DIDIndex) *MockProtocolClientProvider {
+	m.didIndex = didIndex
+
+	return m
+}
+
 // WithMethodContext sets method context for document transformer.
 func (m *MockProtocolClientProvider) WithMethodContext(ctx []string) *MockProtocolClientProvider {
 	m.methodCtx = ctx
@@ -139,7 +150,7 @@ func (m *MockProtocolClientProvider) create() *MockProtocolClient {
 	//nolint:gomnd
 	latest := protocol.Protocol{
 		GenesisTime:                 0,
-		MultihashAlgorithms:         []uint{18},
+		MultihashAlgorithms:         DefaultMultihashAlgorithms,
 		MaxOperationCount:           1,    // one operation per batch - batch gets cut right away
 		MaxOperationSize:            2500, // has to be bigger than max delta + max proof + small number for type
 		MaxOperationHashLength:      100,
@@ -171,6 +182,7 @@ func (m *MockProtocolClientProvider) create() *MockProtocolClient {
 			OpStore:                   m.opStore,
 			OperationProtocolProvider: op,
 			TxnGraph:                  m.txnGraph,
+			DIDIndex:                  m.didIndex,
 		},
 	)
 
@@ -7,13 +7,19 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"context"
+	"errors"
+
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/trustbloc/edge-core/pkg/log"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	txnapi "github.com/trustbloc/sidetree-core-go/pkg/api/txn"
 
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
+	"github.com/trustbloc/orb/pkg/anchor/txn"
 	"github.com/trustbloc/orb/pkg/anchor/util"
+	"github.com/trustbloc/orb/pkg/txnprocessor"
 )
 
 var logger = log.New("orb-observer")
@@ -25,7 +31,7 @@ type TxnProvider interface {
 
 // TxnGraph interface to access orb transactions.
 type TxnGraph interface {
-	Read(cid string) (*verifiable.Credential, error)
+	Read(ctx context.Context, cid string) (*verifiable.Credential, error)
 }
 
 // OperationStore interface to access operation store.
@@ -38,11 +44,33 @@ type OperationFilter interface {
 	Filter(uniqueSuffix string, ops []*operation.AnchoredOperation) ([]*operation.AnchoredOperation, error)
 }
 
+// AnchorPinner pins an anchor along with the batch files that it references.
+type AnchorPinner interface {
+	PinAnchor(ctx context.Context, cid string) error
+}
+
+// AnchorStatus records the lifecycle status of anchors.
+type AnchorStatus interface {
+	Observed(anchor, cid string) error
+	Fail(anchor string, cause error) error
+}
+
+// AnchorLog is the ordered log of the anchors that were written or observed by this node.
+type AnchorLog interface {
+	Append(entry *anchorlog.Entry) (uint64, error)
+}
+
 // Providers contains all of the providers required by the TxnProcessor.
 type Providers struct {
 	TxnProvider            TxnProvider
 	ProtocolClientProvider protocol.ClientProvider
 	TxnGraph
+	// AnchorPinner is optional. If set then observed anchors are pinned after they're processed.
+	AnchorPinner AnchorPinner
+	// AnchorStatus is optional. If set then the status of observed anchors is updated after they're processed.
+	AnchorStatus AnchorStatus
+	// AnchorLog is optional. If set then observed anchors are added to the anchor log after they're processed.
+	AnchorLog AnchorLog
 }
 
 // Observer receives transactions over a channel and processes them by storing them to an operation store.
@@ -50,13 +78,19 @@ type Observer struct {
 	*Providers
 
 	stopCh chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // New returns a new observer.
 func New(providers *Providers) *Observer {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return &Observer{
 		Providers: providers,
 		stopCh:    make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 }
 
@@ -65,8 +99,9 @@ func (o *Observer) Start() {
 	go o.listen(o.TxnProvider.RegisterForOrbTxn())
 }
 
-// Stop stops the observer.
+// Stop stops the observer. Transactions that are currently being processed are cancelled.
 func (o *Observer) Stop() {
+	o.cancel()
 	o.stopCh <- struct{}{}
 }
 
@@ -85,15 +120,27 @@ func (o *Observer) listen(txnsCh <-chan []string) {
 				return
 			}
 
-			o.process(txns)
+			o.process(o.ctx, txns)
 		}
 	}
 }
 
-func (o *Observer) process(txns []string) {
+func (o *Observer) process(ctx context.Context, txns []string) {
 	for _, txn := range txns {
-		txnNode, err := o.TxnGraph.Read(txn)
+		if ctx.Err() != nil {
+			logger.Infof("Processing of transactions was cancelled.")
+
+			return
+		}
+
+		txnNode, err := o.TxnGraph.Read(ctx, txn)
 		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				logger.Infof("Reading txn node [%s] from txn graph was cancelled.", txn)
+
+				return
+			}
+
 			logger.Warnf("Failed to get txn node from txn graph: %s", txn, err.Error())
 
 			continue
@@ -127,13 +174,74 @@ func (o *Observer) process(txns []string) {
 			Reference:           txn,
 		}
 
-		err = v.TransactionProcessor().Process(sidetreeTxn)
+		err = txnprocessor.ProcessWithContext(ctx, v.TransactionProcessor(), sidetreeTxn)
 		if err != nil {
 			logger.Warnf("failed to process anchor[%s]: %s", txnPayload.AnchorString, err.Error())
 
+			o.failed(txnPayload.AnchorString, err)
+
 			continue
 		}
 
 		logger.Debugf("successfully processed anchor[%s]", txnPayload.AnchorString)
+
+		o.appendToLog(txn, txnPayload)
+		o.observed(txnPayload.AnchorString, txn)
+		o.pin(ctx, txn)
+	}
+}
+
+func (o *Observer) observed(anchor, txn string) {
+	if o.AnchorStatus == nil {
+		return
+	}
+
+	err := o.AnchorStatus.Observed(anchor, txn)
+	if err != nil {
+		logger.Warnf("failed to update status of anchor [%s]: %s", anchor, err.Error())
+	}
+}
+
+func (o *Observer) appendToLog(cid string, payload *txn.Payload) {
+	if o.AnchorLog == nil {
+		return
+	}
+
+	_, err := o.AnchorLog.Append(&anchorlog.Entry{
+		CID:       cid,
+		Anchor:    payload.AnchorString,
+		Namespace: payload.Namespace,
+		Version:   payload.Version,
+		Source:    anchorlog.SourceObserved,
+	})
+	if err != nil {
+		logger.Warnf("failed to add anchor [%s] to anchor log: %s", cid, err.Error())
+	}
+}
+
+func (o *Observer) failed(anchor string, cause error) {
+	if o.AnchorStatus == nil {
+		return
+	}
+
+	err := o.AnchorStatus.Fail(anchor, cause)
+	if err != nil {
+		// e.g. the status of an anchor that was written by another node isn't tracked until it's observed
+		logger.Debugf("failed to save error status of anchor [%s]: %s", anchor, err.Error())
+	}
+}
+
+func (o *Observer) pin(ctx context.Context, txn string) {
+	if o.AnchorPinner == nil {
+		return
 	}
+
+	err := o.AnchorPinner.PinAnchor(ctx, txn)
+	if err != nil {
+		logger.Warnf("failed to pin anchor [%s]: %s", txn, err.Error())
+
+		return
+	}
+
+	logger.Debugf("successfully pinned anchor [%s]", txn)
 }
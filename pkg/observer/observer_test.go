@@ -7,21 +7,29 @@ SPDX-License-Identifier: Apache-2.0
 package observer
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/verifier"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
+	mockstore "github.com/hyperledger/aries-framework-go/pkg/mock/storage"
+	"github.com/hyperledger/aries-framework-go/pkg/storage/mem"
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
 	"github.com/trustbloc/sidetree-core-go/pkg/mocks"
 	"github.com/trustbloc/sidetree-core-go/pkg/versions/0_1/txnprocessor"
 
+	"github.com/trustbloc/orb/pkg/anchor/anchorlog"
 	"github.com/trustbloc/orb/pkg/anchor/graph"
 	orbtxn "github.com/trustbloc/orb/pkg/anchor/txn"
+	"github.com/trustbloc/orb/pkg/context/cas"
 )
 
 func TestStartObserver(t *testing.T) {
@@ -62,13 +70,13 @@ func TestStartObserver(t *testing.T) {
 
 		payload1 := orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "1.address"}
 
-		cid, err := txnGraph.Add(buildCredential(payload1))
+		cid, err := txnGraph.Add(context.Background(), buildCredential(t, payload1))
 		require.NoError(t, err)
 		txns = append(txns, cid)
 
 		payload2 := orbtxn.Payload{Namespace: namespace2, Version: 1, AnchorString: "2.address"}
 
-		cid, err = txnGraph.Add(buildCredential(payload2))
+		cid, err = txnGraph.Add(context.Background(), buildCredential(t, payload2))
 		require.NoError(t, err)
 		txns = append(txns, cid)
 
@@ -89,6 +97,238 @@ func TestStartObserver(t *testing.T) {
 
 		require.Equal(t, 1, tp.ProcessCallCount())
 	})
+
+	t.Run("test pin anchors", func(t *testing.T) {
+		sidetreeTxnCh := make(chan []string, 100)
+
+		tp := &mocks.TxnProcessor{}
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Protocol.GenesisTime = 1
+		pc.Versions[0].TransactionProcessorReturns(tp)
+		pc.Versions[0].ProtocolReturns(pc.Protocol)
+
+		txnGraph := graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		cid1, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "1.address"}))
+		require.NoError(t, err)
+
+		cid2, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "2.address"}))
+		require.NoError(t, err)
+
+		pinner := &mockAnchorPinner{err: fmt.Errorf("injected pin error"), errCID: cid1}
+
+		providers := &Providers{
+			TxnProvider:            mockLedger{registerForSidetreeTxnValue: sidetreeTxnCh},
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+			TxnGraph:               txnGraph,
+			AnchorPinner:           pinner,
+		}
+
+		o := New(providers)
+		require.NotNil(t, o)
+
+		o.Start()
+		defer o.Stop()
+
+		sidetreeTxnCh <- []string{cid1, cid2}
+		time.Sleep(200 * time.Millisecond)
+
+		// the pin error for the first anchor doesn't prevent the second anchor from being processed
+		require.Equal(t, 2, tp.ProcessCallCount())
+		require.Equal(t, []string{cid1, cid2}, pinner.getPinned())
+	})
+
+	t.Run("test anchor status", func(t *testing.T) {
+		sidetreeTxnCh := make(chan []string, 100)
+
+		tp := &mocks.TxnProcessor{}
+		tp.ProcessReturnsOnCall(0, fmt.Errorf("injected process error"))
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Protocol.GenesisTime = 1
+		pc.Versions[0].TransactionProcessorReturns(tp)
+		pc.Versions[0].ProtocolReturns(pc.Protocol)
+
+		txnGraph := graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		cid1, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "1.address"}))
+		require.NoError(t, err)
+
+		cid2, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "2.address"}))
+		require.NoError(t, err)
+
+		anchorStatus := &mockAnchorStatus{err: fmt.Errorf("injected status error")}
+
+		providers := &Providers{
+			TxnProvider:            mockLedger{registerForSidetreeTxnValue: sidetreeTxnCh},
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+			TxnGraph:               txnGraph,
+			AnchorStatus:           anchorStatus,
+		}
+
+		o := New(providers)
+		require.NotNil(t, o)
+
+		o.Start()
+		defer o.Stop()
+
+		sidetreeTxnCh <- []string{cid1, cid2}
+		time.Sleep(200 * time.Millisecond)
+
+		require.Equal(t, 2, tp.ProcessCallCount())
+
+		failed, observed := anchorStatus.get()
+		require.Equal(t, []string{"1.address"}, failed)
+		require.Equal(t, []string{"2.address:" + cid2}, observed)
+	})
+
+	t.Run("test anchor log", func(t *testing.T) {
+		sidetreeTxnCh := make(chan []string, 100)
+
+		tp := &mocks.TxnProcessor{}
+		tp.ProcessReturnsOnCall(0, fmt.Errorf("injected process error"))
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Protocol.GenesisTime = 1
+		pc.Versions[0].TransactionProcessorReturns(tp)
+		pc.Versions[0].ProtocolReturns(pc.Protocol)
+
+		txnGraph := graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		cid1, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "1.address"}))
+		require.NoError(t, err)
+
+		cid2, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "2.address"}))
+		require.NoError(t, err)
+
+		anchorLog, err := anchorlog.New(mem.NewProvider())
+		require.NoError(t, err)
+
+		providers := &Providers{
+			TxnProvider:            mockLedger{registerForSidetreeTxnValue: sidetreeTxnCh},
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+			TxnGraph:               txnGraph,
+			AnchorLog:              anchorLog,
+		}
+
+		o := New(providers)
+		require.NotNil(t, o)
+
+		o.Start()
+		defer o.Stop()
+
+		sidetreeTxnCh <- []string{cid1, cid2}
+		time.Sleep(200 * time.Millisecond)
+
+		require.Equal(t, 2, tp.ProcessCallCount())
+
+		// the anchor that failed to be processed isn't added to the log
+		entries, err := anchorLog.List(0, 10)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, cid2, entries[0].CID)
+		require.Equal(t, "2.address", entries[0].Anchor)
+		require.Equal(t, namespace1, entries[0].Namespace)
+		require.Equal(t, uint64(1), entries[0].Version)
+		require.Equal(t, anchorlog.SourceObserved, entries[0].Source)
+	})
+
+	t.Run("test anchor log error", func(t *testing.T) {
+		sidetreeTxnCh := make(chan []string, 100)
+
+		tp := &mocks.TxnProcessor{}
+
+		pc := mocks.NewMockProtocolClient()
+		pc.Protocol.GenesisTime = 1
+		pc.Versions[0].TransactionProcessorReturns(tp)
+		pc.Versions[0].ProtocolReturns(pc.Protocol)
+
+		txnGraph := graph.New(mocks.NewMockCasClient(nil), pubKeyFetcherFnc)
+
+		cid, err := txnGraph.Add(context.Background(), buildCredential(t,
+			orbtxn.Payload{Namespace: namespace1, Version: 1, AnchorString: "1.address"}))
+		require.NoError(t, err)
+
+		provider := mockstore.NewMockStoreProvider()
+		provider.Store.ErrPut = fmt.Errorf("injected put error")
+
+		anchorLog, err := anchorlog.New(provider)
+		require.NoError(t, err)
+
+		pinner := &mockAnchorPinner{}
+
+		providers := &Providers{
+			TxnProvider:            mockLedger{registerForSidetreeTxnValue: sidetreeTxnCh},
+			ProtocolClientProvider: mocks.NewMockProtocolClientProvider().WithProtocolClient(namespace1, pc),
+			TxnGraph:               txnGraph,
+			AnchorLog:              anchorLog,
+			AnchorPinner:           pinner,
+		}
+
+		o := New(providers)
+		require.NotNil(t, o)
+
+		o.Start()
+		defer o.Stop()
+
+		sidetreeTxnCh <- []string{cid}
+		time.Sleep(200 * time.Millisecond)
+
+		// the error is only logged
+		require.Equal(t, []string{cid}, pinner.getPinned())
+	})
+
+	t.Run("test stop cancels in-flight read", func(t *testing.T) {
+		sidetreeTxnCh := make(chan []string, 100)
+
+		requested := make(chan struct{}, 1)
+		cancelled := make(chan struct{}, 1)
+
+		// IPFS server that never responds to a request (unless the request is cancelled)
+		ipfs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested <- struct{}{}
+
+			select {
+			case <-r.Context().Done():
+				cancelled <- struct{}{}
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer ipfs.Close()
+
+		providers := &Providers{
+			TxnProvider: mockLedger{registerForSidetreeTxnValue: sidetreeTxnCh},
+			TxnGraph:    graph.New(cas.New(ipfs.URL, cas.WithReadTimeout(0)), pubKeyFetcherFnc),
+		}
+
+		o := New(providers)
+		require.NotNil(t, o)
+
+		o.Start()
+
+		sidetreeTxnCh <- []string{"Qmf412jQZiuVUtdgnB36FXFX7xg5V6KEbSJ4dpQuhkLyfD"}
+
+		select {
+		case <-requested:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for read from IPFS")
+		}
+
+		o.Stop()
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("in-flight read was not cancelled")
+		}
+	})
 }
 
 func TestTxnProcessor_Process(t *testing.T) {
@@ -119,6 +359,65 @@ func (m mockLedger) RegisterForOrbTxn() <-chan []string {
 	return m.registerForSidetreeTxnValue
 }
 
+type mockAnchorPinner struct {
+	mutex  sync.Mutex
+	pinned []string
+	err    error
+	errCID string
+}
+
+func (m *mockAnchorPinner) PinAnchor(_ context.Context, cid string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pinned = append(m.pinned, cid)
+
+	if cid == m.errCID {
+		return m.err
+	}
+
+	return nil
+}
+
+func (m *mockAnchorPinner) getPinned() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.pinned
+}
+
+type mockAnchorStatus struct {
+	mutex    sync.Mutex
+	failed   []string
+	observed []string
+	err      error
+}
+
+func (m *mockAnchorStatus) Observed(anchor, cid string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.observed = append(m.observed, anchor+":"+cid)
+
+	return m.err
+}
+
+func (m *mockAnchorStatus) Fail(anchor string, _ error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.failed = append(m.failed, anchor)
+
+	return m.err
+}
+
+func (m *mockAnchorStatus) get() ([]string, []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.failed, m.observed
+}
+
 type mockOperationStore struct {
 	putFunc func(ops []*operation.AnchoredOperation) error
 	getFunc func(suffix string) ([]*operation.AnchoredOperation, error)
@@ -156,7 +455,9 @@ func (m *mockTxnOpsProvider) GetTxnOperations(_ *txn.SidetreeTxn) ([]*operation.
 	return []*operation.AnchoredOperation{op}, nil
 }
 
-func buildCredential(payload orbtxn.Payload) *verifiable.Credential {
+func buildCredential(t *testing.T, payload orbtxn.Payload) []byte {
+	t.Helper()
+
 	const defVCContext = "https://www.w3.org/2018/credentials/v1"
 
 	vc := &verifiable.Credential{
@@ -169,7 +470,10 @@ func buildCredential(payload orbtxn.Payload) *verifiable.Credential {
 		Issued: &util.TimeWithTrailingZeroMsec{Time: time.Now()},
 	}
 
-	return vc
+	vcBytes, err := vc.MarshalJSON()
+	require.NoError(t, err)
+
+	return vcBytes
 }
 
 var pubKeyFetcherFnc = func(issuerID, keyID string) (*verifier.PublicKey, error) {
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txnprocessor
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -15,6 +16,8 @@ import (
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/protocol"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
 )
 
 var logger = log.New("orb-txn-processor")
@@ -27,7 +30,17 @@ type OperationStore interface {
 
 // TxnGraph interface to access orb transactions.
 type TxnGraph interface {
-	GetDidTransactions(cid, did string) ([]string, error)
+	GetDidTransactions(ctx context.Context, cid, did string) ([]string, error)
+}
+
+// DIDIndex is the reverse index from an anchor to the DIDs that were anchored in the anchor.
+type DIDIndex interface {
+	Put(cid string, dids []*didindex.DID) error
+}
+
+// ContextTxnProcessor is implemented by transaction processors that support cancellation.
+type ContextTxnProcessor interface {
+	ProcessContext(ctx context.Context, sidetreeTxn txn.SidetreeTxn) error
 }
 
 // Providers contains the providers required by the TxnProcessor.
@@ -35,6 +48,8 @@ type Providers struct {
 	OpStore                   OperationStore
 	OperationProtocolProvider protocol.OperationProvider
 	TxnGraph                  TxnGraph
+	// DIDIndex is optional. If set then the DIDs of each processed anchor are added to the index.
+	DIDIndex DIDIndex
 }
 
 // TxnProcessor processes Sidetree transactions by persisting them to an operation store.
@@ -51,6 +66,12 @@ func New(providers *Providers) *TxnProcessor {
 
 // Process persists all of the operations for the given anchor.
 func (p *TxnProcessor) Process(sidetreeTxn txn.SidetreeTxn) error {
+	return p.ProcessContext(context.Background(), sidetreeTxn)
+}
+
+// ProcessContext persists all of the operations for the given anchor. Processing is aborted
+// if the given context is done.
+func (p *TxnProcessor) ProcessContext(ctx context.Context, sidetreeTxn txn.SidetreeTxn) error {
 	logger.Debugf("processing sidetree txn:%+v", sidetreeTxn)
 
 	txnOps, err := p.OperationProtocolProvider.GetTxnOperations(&sidetreeTxn)
@@ -58,10 +79,21 @@ func (p *TxnProcessor) Process(sidetreeTxn txn.SidetreeTxn) error {
 		return fmt.Errorf("failed to retrieve operations for anchor string[%s]: %s", sidetreeTxn.AnchorString, err)
 	}
 
-	return p.processTxnOperations(txnOps, sidetreeTxn)
+	return p.processTxnOperations(ctx, txnOps, sidetreeTxn)
 }
 
-func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperation, sidetreeTxn txn.SidetreeTxn) error {
+// ProcessWithContext processes the given transaction with the given transaction processor. Processing is aborted
+// if the given context is done and the transaction processor supports cancellation (see ContextTxnProcessor).
+func ProcessWithContext(ctx context.Context, tp protocol.TxnProcessor, sidetreeTxn txn.SidetreeTxn) error {
+	if ctp, ok := tp.(ContextTxnProcessor); ok {
+		return ctp.ProcessContext(ctx, sidetreeTxn)
+	}
+
+	return tp.Process(sidetreeTxn)
+}
+
+func (p *TxnProcessor) processTxnOperations(ctx context.Context, txnOps []*operation.AnchoredOperation,
+	sidetreeTxn txn.SidetreeTxn) error {
 	logger.Debugf("processing %d transaction operations", len(txnOps))
 
 	batchSuffixes := make(map[string]bool)
@@ -82,7 +114,7 @@ func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperatio
 		}
 
 		// Get all references for this did from transaction graph starting from Sidetree txn reference
-		didRefs, err := p.TxnGraph.GetDidTransactions(sidetreeTxn.Reference, op.UniqueSuffix)
+		didRefs, err := p.TxnGraph.GetDidTransactions(ctx, sidetreeTxn.Reference, op.UniqueSuffix)
 		if err != nil {
 			return err
 		}
@@ -110,10 +142,35 @@ func (p *TxnProcessor) processTxnOperations(txnOps []*operation.AnchoredOperatio
 		batchSuffixes[op.UniqueSuffix] = true
 	}
 
-	err := p.OpStore.Put(ops)
+	err := p.indexDIDs(sidetreeTxn.Reference, ops)
+	if err != nil {
+		return err
+	}
+
+	err = p.OpStore.Put(ops)
 	if err != nil {
 		return errors.Wrapf(err, "failed to store operation from anchor string[%s]", sidetreeTxn.AnchorString)
 	}
 
 	return nil
 }
+
+// indexDIDs adds the DIDs of the given operations to the reverse (anchor to DIDs) index.
+func (p *TxnProcessor) indexDIDs(cid string, ops []*operation.AnchoredOperation) error {
+	if p.DIDIndex == nil {
+		return nil
+	}
+
+	dids := make([]*didindex.DID, len(ops))
+
+	for i, op := range ops {
+		dids[i] = &didindex.DID{Suffix: op.UniqueSuffix, Type: op.Type}
+	}
+
+	err := p.DIDIndex.Put(cid, dids)
+	if err != nil {
+		return errors.Wrapf(err, "failed to index DIDs of anchor[%s]", cid)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txnprocessor
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"testing"
@@ -14,6 +15,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/operation"
 	"github.com/trustbloc/sidetree-core-go/pkg/api/txn"
+
+	"github.com/trustbloc/orb/pkg/anchor/didindex"
 )
 
 const anchorString = "1.coreIndexURI"
@@ -38,6 +41,28 @@ func TestTxnProcessor_Process(t *testing.T) {
 	})
 }
 
+func TestProcessWithContext(t *testing.T) {
+	t.Run("context transaction processor", func(t *testing.T) {
+		errExpected := fmt.Errorf("txn operations provider error")
+
+		p := New(&Providers{
+			OpStore:                   &mockOperationStore{},
+			OperationProtocolProvider: &mockTxnOpsProvider{err: errExpected},
+		})
+
+		err := ProcessWithContext(context.Background(), p, txn.SidetreeTxn{})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), errExpected.Error())
+	})
+
+	t.Run("transaction processor", func(t *testing.T) {
+		errExpected := fmt.Errorf("process error")
+
+		err := ProcessWithContext(context.Background(), &mockTxnProcessor{err: errExpected}, txn.SidetreeTxn{})
+		require.True(t, errors.Is(err, errExpected))
+	})
+}
+
 func TestProcessTxnOperations(t *testing.T) {
 	t.Run("test error from operationStore Put", func(t *testing.T) {
 		providers := &Providers{
@@ -48,7 +73,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		}
 
 		p := New(providers)
-		err := p.processTxnOperations([]*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
+		err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
 			txn.SidetreeTxn{AnchorString: anchorString})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "failed to store operation from anchor string")
@@ -63,7 +88,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		}
 
 		p := New(providers)
-		err := p.processTxnOperations([]*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
+		err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
 			txn.SidetreeTxn{AnchorString: anchorString})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "graph error")
@@ -78,7 +103,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		}
 
 		p := New(providers)
-		err := p.processTxnOperations([]*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
+		err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
 			txn.SidetreeTxn{AnchorString: anchorString})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "discrepancy between transactions in the graph and anchored operations for did: abc")
@@ -95,10 +120,46 @@ func TestProcessTxnOperations(t *testing.T) {
 		batchOps, err := p.OperationProtocolProvider.GetTxnOperations(&txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 
-		err = p.processTxnOperations(batchOps, txn.SidetreeTxn{AnchorString: anchorString})
+		err = p.processTxnOperations(context.Background(), batchOps, txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 	})
 
+	t.Run("success - DIDs are indexed", func(t *testing.T) {
+		didIndex := &mockDIDIndex{}
+
+		p := New(&Providers{
+			OpStore:  &mockOperationStore{},
+			TxnGraph: &mockTxnGraph{},
+			DIDIndex: didIndex,
+		})
+
+		err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{
+			{UniqueSuffix: "abc", Type: operation.TypeCreate},
+			{UniqueSuffix: "abc", Type: operation.TypeUpdate},
+			{UniqueSuffix: "xyz", Type: operation.TypeUpdate},
+		}, txn.SidetreeTxn{AnchorString: anchorString, Reference: "cid"})
+		require.NoError(t, err)
+
+		require.Equal(t, "cid", didIndex.cid)
+		require.Equal(t, []*didindex.DID{
+			{Suffix: "abc", Type: operation.TypeCreate},
+			{Suffix: "xyz", Type: operation.TypeUpdate},
+		}, didIndex.dids)
+	})
+
+	t.Run("error - DID index error", func(t *testing.T) {
+		p := New(&Providers{
+			OpStore:  &mockOperationStore{},
+			TxnGraph: &mockTxnGraph{},
+			DIDIndex: &mockDIDIndex{err: errors.New("index error")},
+		})
+
+		err := p.processTxnOperations(context.Background(), []*operation.AnchoredOperation{{UniqueSuffix: "abc"}},
+			txn.SidetreeTxn{AnchorString: anchorString, Reference: "cid"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to index DIDs of anchor[cid]: index error")
+	})
+
 	t.Run("success - multiple operations with same suffix in transaction operations", func(t *testing.T) {
 		providers := &Providers{
 			OperationProtocolProvider: &mockTxnOpsProvider{},
@@ -114,7 +175,7 @@ func TestProcessTxnOperations(t *testing.T) {
 		// only first operation will be processed, subsequent operations will be discarded
 		batchOps = append(batchOps, batchOps...)
 
-		err = p.processTxnOperations(batchOps, txn.SidetreeTxn{AnchorString: anchorString})
+		err = p.processTxnOperations(context.Background(), batchOps, txn.SidetreeTxn{AnchorString: anchorString})
 		require.NoError(t, err)
 	})
 }
@@ -161,10 +222,35 @@ type mockTxnGraph struct {
 	Err     error
 }
 
-func (m *mockTxnGraph) GetDidTransactions(cid, did string) ([]string, error) {
+func (m *mockTxnGraph) GetDidTransactions(ctx context.Context, cid, did string) ([]string, error) {
 	if m.Err != nil {
 		return nil, m.Err
 	}
 
 	return m.DidTxns, nil
 }
+
+type mockDIDIndex struct {
+	cid  string
+	dids []*didindex.DID
+	err  error
+}
+
+func (m *mockDIDIndex) Put(cid string, dids []*didindex.DID) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.cid = cid
+	m.dids = dids
+
+	return nil
+}
+
+type mockTxnProcessor struct {
+	err error
+}
+
+func (m *mockTxnProcessor) Process(txn.SidetreeTxn) error {
+	return m.err
+}
@@ -13,12 +13,16 @@ import (
 	"time"
 
 	ariescrypto "github.com/hyperledger/aries-framework-go/pkg/crypto"
+	ariesjsonld "github.com/hyperledger/aries-framework-go/pkg/doc/signature/jsonld"
 	ariessigner "github.com/hyperledger/aries-framework-go/pkg/doc/signature/signer"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/ed25519signature2018"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/signature/suite/jsonwebsignature2020"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	"github.com/hyperledger/aries-framework-go/pkg/kms"
+	"github.com/piprate/json-gold/ld"
+
+	"github.com/trustbloc/orb/pkg/jsonld"
 )
 
 const (
@@ -38,17 +42,37 @@ type SigningParams struct {
 	Domain             string
 }
 
+// Opt is a signer option.
+type Opt func(s *Signer)
+
+// WithDocumentLoader sets the JSON-LD document loader that is used for signing.
+func WithDocumentLoader(loader ld.DocumentLoader) Opt {
+	return func(s *Signer) {
+		s.documentLoader = loader
+	}
+}
+
 // New returns new instance of VC signer.
-func New(keyManager kms.KeyManager, c ariescrypto.Crypto, params SigningParams) (*Signer, error) {
+func New(keyManager kms.KeyManager, c ariescrypto.Crypto, params SigningParams, opts ...Opt) (*Signer, error) {
 	if err := verifySigningParams(params); err != nil {
 		return nil, fmt.Errorf("failed to verify signing parameters: %s", err.Error())
 	}
 
-	return &Signer{
+	s := &Signer{
 		keyManager: keyManager,
 		crypto:     c,
 		params:     params,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.documentLoader == nil {
+		s.documentLoader = jsonld.NewDocumentLoader()
+	}
+
+	return s, nil
 }
 
 func verifySigningParams(params SigningParams) error {
@@ -69,9 +93,10 @@ func verifySigningParams(params SigningParams) error {
 
 // Signer to sign verifiable credential.
 type Signer struct {
-	keyManager kms.KeyManager
-	crypto     ariescrypto.Crypto
-	params     SigningParams
+	keyManager     kms.KeyManager
+	crypto         ariescrypto.Crypto
+	params         SigningParams
+	documentLoader ld.DocumentLoader
 }
 
 // Sign will sign verifiable credential.
@@ -81,7 +106,7 @@ func (s *Signer) Sign(vc *verifiable.Credential) (*verifiable.Credential, error)
 		return nil, err
 	}
 
-	err = vc.AddLinkedDataProof(signingCtx)
+	err = vc.AddLinkedDataProof(signingCtx, ariesjsonld.WithDocumentLoader(s.documentLoader))
 	if err != nil {
 		return nil, fmt.Errorf("failed to sign vc: %w", err)
 	}
@@ -89,6 +114,27 @@ func (s *Signer) Sign(vc *verifiable.Credential) (*verifiable.Credential, error)
 	return vc, nil
 }
 
+// SignJWT will sign verifiable credential as a JWT (JWS with the credential in the "vc" claim).
+func (s *Signer) SignJWT(vc *verifiable.Credential) (string, error) {
+	kmsSigner, err := s.getKMSSigner()
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := vc.JWTClaims(false)
+	if err != nil {
+		return "", fmt.Errorf("failed to create JWT claims: %w", err)
+	}
+
+	// anchor credential signing keys are ED25519 keys
+	jws, err := claims.MarshalJWS(verifiable.EdDSA, kmsSigner, s.params.VerificationMethod)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign vc as JWT: %w", err)
+	}
+
+	return jws, nil
+}
+
 func (s *Signer) getLinkedDataProofContext() (*verifiable.LinkedDataProofContext, error) {
 	kmsSigner, err := s.getKMSSigner()
 	if err != nil {
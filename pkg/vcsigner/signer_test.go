@@ -8,8 +8,11 @@ package vcsigner
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hyperledger/aries-framework-go/pkg/doc/util"
 	"github.com/hyperledger/aries-framework-go/pkg/doc/verifiable"
 	cryptomock "github.com/hyperledger/aries-framework-go/pkg/mock/crypto"
 	mockkms "github.com/hyperledger/aries-framework-go/pkg/mock/kms"
@@ -118,6 +121,60 @@ func TestSigner_Sign(t *testing.T) {
 	})
 }
 
+func TestSigner_SignJWT(t *testing.T) {
+	signingParams := SigningParams{
+		VerificationMethod: "did:abc:123#key1",
+		SignatureSuite:     Ed25519Signature2018,
+		Domain:             "domain",
+	}
+
+	vc := &verifiable.Credential{
+		ID:      "http://example.edu/credentials/1872",
+		Context: []string{"https://www.w3.org/2018/credentials/v1"},
+		Types:   []string{"VerifiableCredential"},
+		Issuer:  verifiable.Issuer{ID: "http://peer1.com"},
+		Issued:  util.NewTime(time.Now()),
+		Subject: verifiable.Subject{CustomFields: map[string]interface{}{"anchorString": "anchor"}},
+	}
+
+	t.Run("success", func(t *testing.T) {
+		s, err := New(&mockkms.KeyManager{}, &cryptomock.Crypto{SignValue: []byte("signature")}, signingParams)
+		require.NoError(t, err)
+
+		jws, err := s.SignJWT(vc)
+		require.NoError(t, err)
+		require.Len(t, strings.Split(jws, "."), 3)
+	})
+
+	t.Run("error - invalid verification method", func(t *testing.T) {
+		invalidSigningParams := SigningParams{
+			VerificationMethod: "key1",
+			SignatureSuite:     Ed25519Signature2018,
+			Domain:             "domain",
+		}
+
+		s, err := New(&mockkms.KeyManager{}, &cryptomock.Crypto{}, invalidSigningParams)
+		require.NoError(t, err)
+
+		jws, err := s.SignJWT(vc)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid verification method format")
+		require.Empty(t, jws)
+	})
+
+	t.Run("error - error from crypto", func(t *testing.T) {
+		s, err := New(&mockkms.KeyManager{},
+			&cryptomock.Crypto{SignErr: fmt.Errorf("failed to sign")},
+			signingParams)
+		require.NoError(t, err)
+
+		jws, err := s.SignJWT(vc)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to sign vc as JWT")
+		require.Empty(t, jws)
+	})
+}
+
 func TestSigner_verifySigningParams(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		signingParams := SigningParams{